@@ -23,15 +23,25 @@ type StatsResponse struct {
 	AllUsers      []UserStats    `json:"all_users"`
 	FeedbackStats FeedbackStats  `json:"feedback_stats"`
 	AllFeedbacks  []Feedback     `json:"all_feedbacks"`
+	Churn         ChurnStats     `json:"churn"`
 }
 
 type UserStats struct {
-	TelegramID int64     `json:"telegram_id"`
-	Username   string    `json:"username"`
-	FirstName  string    `json:"first_name"`
-	LastName   string    `json:"last_name"`
-	LastActive time.Time `json:"last_active"`
-	JoinDate   time.Time `json:"join_date"`
+	TelegramID     int64     `json:"telegram_id"`
+	Username       string    `json:"username"`
+	FirstName      string    `json:"first_name"`
+	LastName       string    `json:"last_name"`
+	LastActive     time.Time `json:"last_active"`
+	JoinDate       time.Time `json:"join_date"`
+	DeliveryStatus string    `json:"delivery_status"`
+}
+
+type ChurnStats struct {
+	Blocked       int `json:"blocked"`
+	Deactivated   int `json:"deactivated"`
+	BlockedWeek   int `json:"blocked_week"`
+	BlockedMonth  int `json:"blocked_month"`
+	ReturnedMonth int `json:"returned_month"`
 }
 
 type FeedbackStats struct {
@@ -75,6 +85,9 @@ func main() {
 	http.HandleFunc("/api/stats", apiStatsHandler)
 	http.HandleFunc("/api/users", apiUsersHandler)
 	http.HandleFunc("/api/feedbacks", apiFeedbacksHandler)
+	http.HandleFunc("/api/broadcasts", botProxyHandler)
+	http.HandleFunc("/api/broadcasts/preview", botProxyHandler)
+	http.HandleFunc("/api/broadcasts/recipients", botProxyHandler)
 
 	port := 3000
 	if envPort := os.Getenv("ADMIN_PORT"); envPort != "" {
@@ -123,6 +136,33 @@ func apiFeedbacksHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(stats.AllFeedbacks)
 }
 
+func botProxyHandler(w http.ResponseWriter, r *http.Request) {
+	target := botAPIURL + r.URL.Path
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+
+	req, err := http.NewRequest(r.Method, target, r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Admin-Token", r.Header.Get("X-Admin-Token"))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("❌ Proxy to %s failed: %v", target, err)
+		http.Error(w, fmt.Sprintf("bot API unavailable: %v", err), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
 func fetchStats() (*StatsResponse, error) {
 	log.Printf("🔗 Trying to connect to: %s/api/stats", botAPIURL)
 
@@ -580,8 +620,80 @@ const dashboardHTML = `<!DOCTYPE html>
             box-shadow: 0 0 0 3px rgba(99, 102, 241, 0.1);
         }
 
+        .broadcast-layout {
+            display: grid;
+            grid-template-columns: 1fr 1fr;
+            gap: 25px;
+            padding: 25px 30px;
+        }
+
+        .broadcast-form label {
+            display: block;
+            font-weight: 600;
+            color: var(--gray);
+            font-size: 0.9em;
+            margin: 12px 0 6px;
+        }
+
+        .broadcast-form textarea,
+        .broadcast-form input,
+        .broadcast-form select {
+            width: 100%;
+            border: 2px solid var(--gray-light);
+            border-radius: var(--border-radius);
+            padding: 10px 14px;
+            font-size: 1em;
+            font-family: inherit;
+        }
+
+        .broadcast-form textarea {
+            min-height: 160px;
+            resize: vertical;
+        }
+
+        .broadcast-form .checkbox-label {
+            display: flex;
+            align-items: center;
+            gap: 8px;
+        }
+
+        .broadcast-form .checkbox-label input {
+            width: auto;
+        }
+
+        .form-row {
+            display: grid;
+            grid-template-columns: 1fr 1fr 1fr;
+            gap: 12px;
+        }
+
+        .form-actions {
+            display: flex;
+            gap: 12px;
+            margin-top: 20px;
+        }
+
+        .tg-preview {
+            background: #e7f3ff;
+            border-radius: var(--border-radius);
+            padding: 16px 20px;
+            min-height: 80px;
+            white-space: normal;
+            word-wrap: break-word;
+        }
+
+        .preview-info {
+            margin-top: 12px;
+            color: var(--gray);
+            font-weight: 500;
+        }
+
+        .status-failed { background: rgba(239, 68, 68, 0.1); color: var(--danger); }
+        .status-blocked { background: rgba(245, 158, 11, 0.1); color: var(--warning); }
+
         @media (max-width: 768px) {
-            .charts-container {
+            .charts-container,
+            .broadcast-layout {
                 grid-template-columns: 1fr;
             }
             
@@ -660,6 +772,9 @@ const dashboardHTML = `<!DOCTYPE html>
                 <button class="tab-btn" onclick="switchTab('feedback')">
                     <i class="fas fa-comments"></i> Все отзывы
                 </button>
+                <button class="tab-btn" onclick="switchTab('broadcast'); loadBroadcasts()">
+                    <i class="fas fa-bullhorn"></i> Рассылки
+                </button>
             </div>
             
             <div class="tab-content active" id="usersTab">
@@ -679,6 +794,75 @@ const dashboardHTML = `<!DOCTYPE html>
                     </div>
                 </div>
             </div>
+
+            <div class="tab-content" id="broadcastTab">
+                <div class="broadcast-layout">
+                    <div class="broadcast-form">
+                        <label for="broadcastText">Текст сообщения</label>
+                        <textarea id="broadcastText" oninput="renderPreview()" placeholder="Текст рассылки..."></textarea>
+
+                        <label for="broadcastParseMode">Форматирование</label>
+                        <select id="broadcastParseMode" onchange="renderPreview()">
+                            <option value="">Без форматирования</option>
+                            <option value="HTML">HTML</option>
+                            <option value="Markdown">Markdown</option>
+                            <option value="MarkdownV2">MarkdownV2</option>
+                        </select>
+
+                        <div class="form-row">
+                            <div>
+                                <label for="segActiveDays">Активны за N дней</label>
+                                <input type="number" id="segActiveDays" min="0" value="0" title="0 — все пользователи">
+                            </div>
+                            <div>
+                                <label for="segCurrency">Валюта</label>
+                                <select id="segCurrency">
+                                    <option value="">Любая</option>
+                                    <option value="RUB">RUB</option>
+                                    <option value="USD">USD</option>
+                                    <option value="EUR">EUR</option>
+                                </select>
+                            </div>
+                            <div>
+                                <label>&nbsp;</label>
+                                <label class="checkbox-label"><input type="checkbox" id="segHasSavings"> Есть копилки</label>
+                            </div>
+                        </div>
+
+                        <div class="form-row">
+                            <div>
+                                <label for="testChatId">Telegram ID для теста</label>
+                                <input type="number" id="testChatId" placeholder="необязательно">
+                            </div>
+                            <div style="grid-column: span 2">
+                                <label for="adminToken">Токен администратора</label>
+                                <input type="password" id="adminToken" placeholder="ADMIN_API_TOKEN">
+                            </div>
+                        </div>
+
+                        <div class="form-actions">
+                            <button class="refresh-btn" onclick="previewBroadcast()">
+                                <i class="fas fa-eye"></i> Предпросмотр
+                            </button>
+                            <button class="refresh-btn" onclick="sendBroadcast()">
+                                <i class="fas fa-paper-plane"></i> Отправить
+                            </button>
+                        </div>
+                    </div>
+
+                    <div>
+                        <label class="feedback-label">Предпросмотр</label>
+                        <div class="tg-preview" id="broadcastPreview"></div>
+                        <div class="preview-info" id="previewInfo"></div>
+                    </div>
+                </div>
+
+                <div class="table-card">
+                    <h3><i class="fas fa-paper-plane"></i> История рассылок</h3>
+                    <div id="broadcastsTable"></div>
+                    <div id="broadcastRecipients"></div>
+                </div>
+            </div>
         </div>
     </div>
 
@@ -738,6 +922,18 @@ const dashboardHTML = `<!DOCTYPE html>
                 '<div class="stat-trend trend-up">За 30 дней</div>' +
                 '</div>' +
                 '<div class="stat-card">' +
+                '<div class="stat-icon"><i class="fas fa-user-slash"></i></div>' +
+                '<h3>Заблокировали бота</h3>' +
+                '<div class="stat-number">' + stats.churn.blocked + '</div>' +
+                '<div class="stat-trend trend-down">+' + stats.churn.blocked_week + ' за неделю · +' + stats.churn.blocked_month + ' за месяц</div>' +
+                '</div>' +
+                '<div class="stat-card">' +
+                '<div class="stat-icon"><i class="fas fa-user-check"></i></div>' +
+                '<h3>Вернулись за месяц</h3>' +
+                '<div class="stat-number">' + stats.churn.returned_month + '</div>' +
+                '<div class="stat-trend trend-down">Удалили аккаунт: ' + stats.churn.deactivated + '</div>' +
+                '</div>' +
+                '<div class="stat-card">' +
                 '<div class="stat-icon"><i class="fas fa-comment-dots"></i></div>' +
                 '<h3>Всего отзывов</h3>' +
                 '<div class="stat-number">' + stats.feedback_stats.total + '</div>' +
@@ -852,8 +1048,15 @@ const dashboardHTML = `<!DOCTYPE html>
             
             stats.all_users.forEach(function(user) {
                 const isActive = new Date(user.last_active) > new Date(Date.now() - 7 * 24 * 60 * 60 * 1000);
-                const statusClass = isActive ? 'status-active' : 'status-inactive';
-                const statusText = isActive ? 'Активен' : 'Неактивен';
+                let statusClass = isActive ? 'status-active' : 'status-inactive';
+                let statusText = isActive ? 'Активен' : 'Неактивен';
+                if (user.delivery_status === 'blocked') {
+                    statusClass = 'status-blocked';
+                    statusText = 'Заблокировал бота';
+                } else if (user.delivery_status === 'deactivated') {
+                    statusClass = 'status-failed';
+                    statusText = 'Аккаунт удалён';
+                }
                 const userInitial = user.first_name ? user.first_name.charAt(0).toUpperCase() : 'U';
                 
                 usersHTML += '<tr class="user-row">' +
@@ -904,6 +1107,172 @@ const dashboardHTML = `<!DOCTYPE html>
             feedbackTable.innerHTML = feedbackHTML;
         }
 
+        function escapeHTML(text) {
+            return String(text)
+                .replace(/&/g, '&amp;')
+                .replace(/</g, '&lt;')
+                .replace(/>/g, '&gt;')
+                .replace(/"/g, '&quot;');
+        }
+
+        function adminHeaders() {
+            const token = document.getElementById('adminToken').value;
+            localStorage.setItem('adminToken', token);
+            return { 'Content-Type': 'application/json', 'X-Admin-Token': token };
+        }
+
+        function broadcastRequest() {
+            return {
+                text: document.getElementById('broadcastText').value,
+                parse_mode: document.getElementById('broadcastParseMode').value,
+                segment: {
+                    active_days: parseInt(document.getElementById('segActiveDays').value, 10) || 0,
+                    currency: document.getElementById('segCurrency').value,
+                    has_savings: document.getElementById('segHasSavings').checked
+                },
+                test_chat_id: parseInt(document.getElementById('testChatId').value, 10) || 0
+            };
+        }
+
+        function renderPreview() {
+            const text = document.getElementById('broadcastText').value;
+            const mode = document.getElementById('broadcastParseMode').value;
+            let html = escapeHTML(text);
+
+            if (mode === 'HTML') {
+                html = html
+                    .replace(/&lt;(\/?)(b|strong|i|em|u|ins|s|strike|del|code|pre)&gt;/g, '<$1$2>')
+                    .replace(/&lt;a href=&quot;(https?:\/\/[^&]+?)&quot;&gt;/g, '<a href="$1" target="_blank">')
+                    .replace(/&lt;\/a&gt;/g, '</a>');
+            } else if (mode === 'Markdown' || mode === 'MarkdownV2') {
+                html = html
+                    .replace(/\*([^*\n]+)\*/g, '<b>$1</b>')
+                    .replace(/_([^_\n]+)_/g, '<i>$1</i>')
+                    .replace(/\x60([^\x60\n]+)\x60/g, '<code>$1</code>')
+                    .replace(/\[([^\]]+)\]\((https?:\/\/[^)\s]+)\)/g, '<a href="$2" target="_blank">$1</a>');
+                if (mode === 'MarkdownV2') {
+                    html = html.replace(/\\([_*\[\]()~>#+\-=|{}.!])/g, '$1');
+                }
+            }
+
+            document.getElementById('broadcastPreview').innerHTML = html.replace(/\n/g, '<br>');
+        }
+
+        async function previewBroadcast() {
+            const info = document.getElementById('previewInfo');
+            try {
+                const response = await fetch('/api/broadcasts/preview', {
+                    method: 'POST',
+                    headers: adminHeaders(),
+                    body: JSON.stringify(broadcastRequest())
+                });
+                if (!response.ok) {
+                    info.textContent = 'Ошибка: ' + await response.text();
+                    return;
+                }
+                const result = await response.json();
+                let text = 'Получателей: ' + result.recipients;
+                if (result.preview_sent) text += ' · тестовое сообщение отправлено';
+                if (result.error) text += ' · ' + result.error;
+                info.textContent = text;
+            } catch (error) {
+                info.textContent = 'Ошибка: ' + error;
+            }
+        }
+
+        async function sendBroadcast() {
+            const request = broadcastRequest();
+            request.test_chat_id = 0;
+
+            const preview = await fetch('/api/broadcasts/preview', {
+                method: 'POST',
+                headers: adminHeaders(),
+                body: JSON.stringify(request)
+            });
+            if (!preview.ok) {
+                alert('Ошибка: ' + await preview.text());
+                return;
+            }
+            const check = await preview.json();
+            if (check.error) {
+                alert('Ошибка: ' + check.error);
+                return;
+            }
+            if (!confirm('Отправить рассылку ' + check.recipients + ' получателям?')) return;
+
+            const response = await fetch('/api/broadcasts', {
+                method: 'POST',
+                headers: adminHeaders(),
+                body: JSON.stringify(request)
+            });
+            if (!response.ok) {
+                alert('Ошибка: ' + await response.text());
+                return;
+            }
+            document.getElementById('broadcastText').value = '';
+            renderPreview();
+            loadBroadcasts();
+        }
+
+        async function loadBroadcasts() {
+            const table = document.getElementById('broadcastsTable');
+            const response = await fetch('/api/broadcasts', { headers: adminHeaders() });
+            if (!response.ok) {
+                table.innerHTML = '<div class="empty-state"><i class="fas fa-lock"></i><p>' + escapeHTML(await response.text()) + '</p></div>';
+                return;
+            }
+            const broadcasts = await response.json();
+            if (broadcasts.length === 0) {
+                table.innerHTML = '<div class="empty-state"><i class="fas fa-bullhorn"></i><p>Рассылок пока не было</p></div>';
+                return;
+            }
+
+            let html = '<table><thead><tr><th>Дата</th><th>Текст</th><th>Статус</th><th>Всего</th><th>Отправлено</th><th>В очереди</th><th>Ошибки</th><th>Заблокировали</th></tr></thead><tbody>';
+            broadcasts.forEach(function(b) {
+                const statusText = { queued: 'В очереди', sending: 'Отправляется', done: 'Завершена' }[b.status] || b.status;
+                html += '<tr class="broadcast-row" style="cursor:pointer" onclick="showRecipients(' + b.id + ')">' +
+                    '<td>' + new Date(b.created_at).toLocaleString('ru-RU') + '</td>' +
+                    '<td class="feedback-content"><div class="feedback-text">' + escapeHTML(b.text.slice(0, 120)) + '</div></td>' +
+                    '<td><span class="status-badge status-active">' + statusText + '</span></td>' +
+                    '<td>' + b.stats.total + '</td>' +
+                    '<td>' + b.stats.sent + '</td>' +
+                    '<td>' + b.stats.pending + '</td>' +
+                    '<td>' + b.stats.failed + '</td>' +
+                    '<td>' + b.stats.blocked + '</td>' +
+                    '</tr>';
+            });
+            html += '</tbody></table>';
+            table.innerHTML = html;
+        }
+
+        async function showRecipients(id) {
+            const container = document.getElementById('broadcastRecipients');
+            const response = await fetch('/api/broadcasts/recipients?id=' + id, { headers: adminHeaders() });
+            if (!response.ok) {
+                container.innerHTML = '';
+                return;
+            }
+            const recipients = (await response.json()).filter(function(r) { return r.status !== 'sent'; });
+            if (recipients.length === 0) {
+                container.innerHTML = '<div class="empty-state"><p>Все сообщения рассылки #' + id + ' доставлены или ещё в очереди</p></div>';
+                return;
+            }
+
+            let html = '<h3>Недоставленные сообщения рассылки #' + id + '</h3><table><thead><tr><th>Пользователь</th><th>Статус</th><th>Попыток</th><th>Ошибка</th></tr></thead><tbody>';
+            recipients.forEach(function(r) {
+                html += '<tr>' +
+                    '<td>@' + escapeHTML(r.username || r.telegram_id) + '</td>' +
+                    '<td><span class="status-badge status-' + r.status + '">' + r.status + '</span></td>' +
+                    '<td>' + r.attempts + '</td>' +
+                    '<td>' + escapeHTML(r.error || '—') + '</td>' +
+                    '</tr>';
+            });
+            html += '</tbody></table>';
+            container.innerHTML = html;
+        }
+
+        document.getElementById('adminToken').value = localStorage.getItem('adminToken') || '';
+
         function switchTab(tabName) {
         
             document.querySelectorAll('.tab-content').forEach(tab => {
@@ -13,6 +13,7 @@ import (
 	"github.com/IlyaMakar/finance_bot/internal/bot/handlers"
 	"github.com/IlyaMakar/finance_bot/internal/logger"
 	"github.com/IlyaMakar/finance_bot/internal/repository"
+	"github.com/IlyaMakar/finance_bot/internal/scheduler"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/joho/godotenv"
 
@@ -78,10 +79,15 @@ func main() {
 		logger.Fatal("Failed to create bot", "error", err)
 	}
 
+	jobs := scheduler.New(repo)
+	if err := botInstance.RegisterJobs(jobs, isTestMode); err != nil {
+		logger.Fatal("Failed to register jobs", "error", err)
+	}
+
 	botInstance.CheckForUpdates()
 	botInstance.NotifyUsersAboutUpdate()
 
-	loc, err := time.LoadLocation("Asia/Yekaterinburg")
+	loc, err := time.LoadLocation(repository.DefaultTimezone)
 	if err != nil {
 		logger.Fatal("Failed to load timezone", "error", err)
 	}
@@ -90,7 +96,8 @@ func main() {
 
 	go botInstance.Start()
 	go startAdminAPI(botInstance, repo)
-	go startReminder(botInstance, repo, isTestMode)
+	go jobs.Start()
+	go sendTestReminder(botInstance, repo, isTestMode)
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -111,8 +118,12 @@ func getEnv(key, defaultValue string) string {
 
 func startAdminAPI(botInstance *handlers.Bot, repo *repository.SQLiteRepository) {
 	statsAPI := handlers.NewStatsAPI(repo)
+	broadcastAPI := handlers.NewBroadcastAPI(botInstance, os.Getenv("ADMIN_API_TOKEN"))
 
 	http.HandleFunc("/api/stats", statsAPI.GetStats)
+	http.HandleFunc("/api/broadcasts", broadcastAPI.Broadcasts)
+	http.HandleFunc("/api/broadcasts/preview", broadcastAPI.Preview)
+	http.HandleFunc("/api/broadcasts/recipients", broadcastAPI.Recipients)
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("OK"))
 	})
@@ -149,73 +160,12 @@ func printSimpleStats(db *sql.DB, loc *time.Location) {
 		"active_24h", activeUsers)
 }
 
-func startReminder(botInstance *handlers.Bot, repo *repository.SQLiteRepository, testMode bool) {
-	checkInterval := time.Minute
-	reminderHour := -1
-
-	if !testMode {
-		checkInterval = time.Hour
-		reminderHour = 16
-	}
-
-	loc, err := time.LoadLocation("Europe/Moscow")
-	if err != nil {
-		logger.Fatal("Failed to load Moscow timezone", "error", err)
-	}
-
-	time.Sleep(10 * time.Second)
-	sendTestReminder(botInstance, repo, testMode)
-
-	ticker := time.NewTicker(checkInterval)
-	defer ticker.Stop()
-
-	for now := range ticker.C {
-		localTime := now.In(loc)
-
-		if reminderHour >= 0 && localTime.Hour() != reminderHour {
-			continue
-		}
-
-		logger.Debug("Checking reminders")
-		users, err := repo.GetAllUsers()
-		if err != nil {
-			logger.Error("Reminder error getting users", "error", err)
-			continue
-		}
-
-		remindersSent := 0
-		for _, user := range users {
-			enabled, err := repo.GetUserNotificationsEnabled(user.ID)
-			if err != nil {
-				logger.Error("Notification check error", "user_id", user.TelegramID, "error", err)
-				continue
-			}
-
-			if !enabled {
-				continue
-			}
-
-			hasTransactions, err := repo.HasTransactionsToday(user.ID)
-			if err != nil {
-				logger.Error("Transaction check error", "user_id", user.TelegramID, "error", err)
-				continue
-			}
-
-			if !hasTransactions {
-				logger.Info("Sending reminder", "user_id", user.TelegramID)
-				sendReminderMessage(botInstance, user.TelegramID, testMode)
-				remindersSent++
-			}
-		}
-		logger.Info("Reminders completed", "sent", remindersSent, "total_users", len(users))
-	}
-}
-
 func sendTestReminder(botInstance *handlers.Bot, repo *repository.SQLiteRepository, testMode bool) {
 	if !testMode {
 		return
 	}
 
+	time.Sleep(10 * time.Second)
 	logger.Info("Sending test reminders")
 	users, err := repo.GetAllUsers()
 	if err != nil {
@@ -224,31 +174,17 @@ func sendTestReminder(botInstance *handlers.Bot, repo *repository.SQLiteReposito
 	}
 
 	for _, user := range users {
+		if !user.Reachable() {
+			continue
+		}
 		logger.Debug("Sending test reminder", "user_id", user.TelegramID)
 		msg := tgbotapi.NewMessage(
 			user.TelegramID,
 			"🔔 <b>Тестовое напоминание</b>\n\n"+
 				"Это тестовая проверка системы напоминаний.\n"+
-				"Реальное напоминание приходит ежедневно в 16:00, если вы не добавили транзакции.",
+				"Реальное напоминание приходит в выбранное в настройках время, если вы не добавили транзакции.",
 		)
 		msg.ParseMode = "HTML"
 		botInstance.SendMessage(msg)
 	}
 }
-
-func sendReminderMessage(botInstance *handlers.Bot, chatID int64, testMode bool) {
-	message := "💡 <b>Напоминание о транзакциях</b>\n\n" +
-		"Привет! Похоже, ты сегодня еще не добавлял(а) ни одной транзакции.\n\n" +
-		"Не забывай вести учет своих финансов — это поможет лучше контролировать бюджет!\n\n" +
-		"➕ Нажми \"Добавить операцию\" "
-
-	if testMode {
-		message = "🔔 <b>ТЕСТ: Напоминание о транзакциях</b>\n\n" +
-			"Это тестовое напоминание (в рабочем режиме приходит в 20:00).\n\n" +
-			message
-	}
-
-	msg := tgbotapi.NewMessage(chatID, message)
-	msg.ParseMode = "HTML"
-	botInstance.SendMessage(msg)
-}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/IlyaMakar/finance_bot/internal/logger"
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+
+	_ "modernc.org/sqlite"
+)
+
+func main() {
+	dbPath := flag.String("db", "finance.db", "path to the bot database")
+	checkOnly := flag.Bool("check", false, "only report mismatches, do not rebuild")
+	flag.Parse()
+
+	if err := logger.Init("INFO", false); err != nil {
+		log.Printf("Failed to initialize logger: %v", err)
+	}
+	defer logger.Close()
+
+	db, err := repository.NewSQLiteDB(*dbPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := repository.InitDB(db); err != nil {
+		log.Fatal(err)
+	}
+	repo := repository.NewRepository(db)
+
+	mismatches, err := repo.CheckMonthlyAggregates()
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Monthly aggregates checked: %d mismatches", mismatches)
+
+	if *checkOnly || mismatches == 0 {
+		return
+	}
+
+	if err := repo.RebuildMonthlyAggregates(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Monthly aggregates rebuilt")
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/logger"
+	"github.com/IlyaMakar/finance_bot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CallbackAnomalyToggle = "anomaly_toggle"
+
+	anomalyReportLimit = 5
+)
+
+func (b *Bot) handleAnomalyCallback(chatID int64, messageID int, data string, svc *service.FinanceService) bool {
+	if data != CallbackAnomalyToggle {
+		return false
+	}
+
+	enabled, err := svc.ToggleAnomalyAlerts()
+	if err != nil {
+		b.sendError(chatID, err)
+		return true
+	}
+
+	text := "🔕 Оповещения о необычных тратах отключены"
+	if enabled {
+		text = "📈 Оповещения о необычных тратах включены. Я напишу, если трата или неделя в категории заметно выбьется из вашей обычной картины."
+	}
+	b.deleteMessage(chatID, messageID)
+	b.send(chatID, tgbotapi.NewMessage(chatID, text))
+	b.showNotificationSettings(chatID)
+	return true
+}
+
+func (b *Bot) anomalyText(a service.Anomaly, chatID int64, loc *time.Location) string {
+	if a.Kind == service.AnomalySpike {
+		return fmt.Sprintf("%s: %s — в %.1f раза больше обычного (обычно %s)",
+			a.Category, b.formatCurrency(a.Amount, chatID), a.Ratio(), b.formatCurrency(a.Baseline, chatID))
+	}
+	text := fmt.Sprintf("%s %s: %s — в %.1f раза больше обычной покупки (обычно %s)",
+		a.Transaction.Date.In(loc).Format("02.01"), a.Category, b.formatCurrency(a.Amount, chatID), a.Ratio(), b.formatCurrency(a.Baseline, chatID))
+	if a.Transaction.Comment != "" {
+		text += ", «" + a.Transaction.Comment + "»"
+	}
+	return text
+}
+
+func (b *Bot) writeAnomalyReportSection(text *strings.Builder, chatID int64, svc *service.FinanceService, start, end time.Time) {
+	anomalies, err := svc.DetectAnomalies(start, end)
+	if err != nil {
+		logger.Error("Failed to detect anomalies", "chat_id", chatID, "error", err)
+		return
+	}
+	if len(anomalies) == 0 {
+		return
+	}
+
+	text.WriteString("\n\n⚠️ <b>Аномалии:</b>\n")
+	for i, a := range anomalies {
+		if i == anomalyReportLimit {
+			text.WriteString(fmt.Sprintf("┗ и ещё %d\n", len(anomalies)-anomalyReportLimit))
+			break
+		}
+		icon := "📈"
+		if a.Kind == service.AnomalyTransaction {
+			icon = "🧾"
+		}
+		text.WriteString(fmt.Sprintf("┣ %s %s\n", icon, b.anomalyText(a, chatID, svc.Location())))
+	}
+}
+
+func (b *Bot) notifyTransactionAnomaly(chatID int64, transactionID int, svc *service.FinanceService) {
+	enabled, err := svc.GetAnomalyAlertsEnabled()
+	if err != nil || !enabled {
+		return
+	}
+
+	a, err := svc.CheckTransactionAnomaly(transactionID)
+	if err != nil {
+		logger.Error("Failed to check transaction anomaly", "chat_id", chatID, "error", err)
+		return
+	}
+	if a == nil {
+		return
+	}
+	if first, err := svc.MarkAnomalyAlerted(*a, a.Transaction.Date); err != nil || !first {
+		return
+	}
+
+	b.send(chatID, tgbotapi.NewMessage(chatID, "🧐 Необычная трата!\n"+b.anomalyText(*a, chatID, svc.Location())))
+}
+
+func (b *Bot) SendAnomalyAlerts() {
+	users, err := b.repo.GetAllUsers()
+	if err != nil {
+		logger.Error("Anomaly alerts error getting users", "error", err)
+		return
+	}
+
+	for i := range users {
+		user := users[i]
+		if !user.CanNotify() {
+			continue
+		}
+
+		now := time.Now().In(user.Location())
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		weekStart := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+
+		svc := service.NewService(b.repo, &user)
+		if enabled, err := svc.GetAnomalyAlertsEnabled(); err != nil || !enabled {
+			continue
+		}
+
+		spikes, err := svc.DetectSpikes(weekStart, weekStart.AddDate(0, 0, 7))
+		if err != nil {
+			logger.Error("Anomaly detection error", "user_id", user.TelegramID, "error", err)
+			continue
+		}
+
+		var text strings.Builder
+		for _, a := range spikes {
+			if first, err := svc.MarkAnomalyAlerted(a, weekStart); err != nil || !first {
+				continue
+			}
+			text.WriteString(fmt.Sprintf("┣ %s\n", b.anomalyText(a, user.TelegramID, svc.Location())))
+		}
+		if text.Len() == 0 {
+			continue
+		}
+
+		msg := tgbotapi.NewMessage(user.TelegramID, "📈 <b>Необычные траты на этой неделе</b>\n\n"+text.String()+
+			"\nЕщё есть время притормозить 😉")
+		msg.ParseMode = tgbotapi.ModeHTML
+		b.send(user.TelegramID, msg)
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"log"
 	"math"
 	"strings"
+	"time"
 
 	"github.com/IlyaMakar/finance_bot/internal/logger"
 
 	"github.com/IlyaMakar/finance_bot/internal/repository"
+	"github.com/IlyaMakar/finance_bot/internal/scheduler"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
@@ -25,6 +27,9 @@ const (
 	CallbackSetPeriodStart      = "set_period_start"
 	CallbackCurrencySettings    = "currency_settings"
 	CallbackSetCurrency         = "set_currency_"
+	CallbackSavingDeadline      = "saving_deadline_"
+	CallbackSavingDeadlineClear = "saving_deadline_clear_"
+	CallbackSavingPace          = "saving_pace_"
 
 	CallbackWriteSupport = "write_support"
 	CallbackFAQ          = "faq"
@@ -42,6 +47,8 @@ type Bot struct {
 	bot       *tgbotapi.BotAPI
 	repo      *repository.SQLiteRepository
 	reportGen *ReportGenerator
+	jobs      *scheduler.Scheduler
+	bulk      bulkLimiter
 }
 
 type UserState struct {
@@ -51,6 +58,11 @@ type UserState struct {
 	TempCategoryName string
 	TempComment      string
 	TempType         string
+	TempCurrency     string
+	TempRate         float64
+	TempTerm         int
+	TempDays         int
+	TempDate         time.Time
 	FeedbackStep     string
 	FeedbackData     map[string]string
 }
@@ -83,6 +95,7 @@ func (b *Bot) send(chatID int64, c tgbotapi.Chattable) {
 	_, err := b.bot.Send(c)
 	if err != nil {
 		logger.Error("Error sending message", "chat_id", chatID, "error", err)
+		b.trackDeliveryError(chatID, err)
 	}
 }
 
@@ -90,11 +103,20 @@ func (b *Bot) SendMessage(msg tgbotapi.MessageConfig) {
 	_, err := b.bot.Send(msg)
 	if err != nil {
 		logger.Error("Error sending message", "chat_id", msg.ChatID, "error", err)
+		b.trackDeliveryError(msg.ChatID, err)
 	} else {
 		logger.Debug("Message sent successfully", "chat_id", msg.ChatID)
 	}
 }
 
+func (b *Bot) deliver(chatID int64, msg tgbotapi.Chattable) error {
+	if _, err := b.bot.Send(msg); err != nil {
+		b.trackDeliveryError(chatID, err)
+		return fmt.Errorf("send message: %w", err)
+	}
+	return nil
+}
+
 func (b *Bot) sendError(chatID int64, err error) {
 	logger.Warn("Sending error to user", "chat_id", chatID, "error", err)
 	b.send(chatID, tgbotapi.NewMessage(chatID, fmt.Sprintf("⚠️ Ошибка: %s", err.Error())))
@@ -111,6 +133,14 @@ func (b *Bot) sendMainMenu(chatID int64, text string) {
 			tgbotapi.NewInlineKeyboardButtonData("📊 Статистика", "show_stats"),
 			tgbotapi.NewInlineKeyboardButtonData("💰 Накопления", "show_savings"),
 		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🤝 Долги", CallbackDebts),
+			tgbotapi.NewInlineKeyboardButtonData("🏦 Кредиты", CallbackLoans),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🧾 Счета", CallbackBills),
+			tgbotapi.NewInlineKeyboardButtonData("🏛 Капитал", CallbackNetWorth),
+		),
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("⚙️ Настройки", "show_settings"),
 		),
@@ -146,10 +176,17 @@ func (b *Bot) Start() {
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 	for upd := range b.bot.GetUpdatesChan(u) {
-		if upd.Message != nil {
+		switch {
+		case upd.Message != nil && !upd.Message.Chat.IsPrivate():
+			b.handleGroupMessage(upd.Message)
+		case upd.Message != nil:
 			b.handleMessage(upd.Message)
-		} else if upd.CallbackQuery != nil {
+		case upd.CallbackQuery != nil && upd.CallbackQuery.Message != nil && !upd.CallbackQuery.Message.Chat.IsPrivate():
+			b.handleGroupCallback(upd.CallbackQuery)
+		case upd.CallbackQuery != nil:
 			b.handleCallback(upd.CallbackQuery)
+		case upd.MyChatMember != nil && upd.MyChatMember.Chat.IsPrivate():
+			b.handleMyChatMember(upd.MyChatMember)
 		}
 	}
 }
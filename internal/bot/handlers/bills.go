@@ -0,0 +1,499 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/logger"
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+	"github.com/IlyaMakar/finance_bot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CallbackBills        = "bills"
+	CallbackBillNew      = "bill_new"
+	CallbackBillView     = "bill_view_"
+	CallbackBillCategory = "bill_cat_"
+	CallbackBillAccount  = "bill_acc_"
+	CallbackBillRemind   = "bill_remind_"
+	CallbackBillPaid     = "bill_paid_"
+	CallbackBillDel      = "bill_delete_"
+)
+
+const (
+	billReminderHour = 10
+	billDueLayout    = "20060102"
+)
+
+func (b *Bot) handleBillCallback(chatID int64, messageID int, data string, svc *service.FinanceService) bool {
+	switch {
+	case data == CallbackBills:
+		b.deleteMessage(chatID, messageID)
+		b.showBills(chatID, svc)
+
+	case data == CallbackBillNew:
+		userStates[chatID] = UserState{Step: "enter_bill_name"}
+		b.deleteMessage(chatID, messageID)
+		b.send(chatID, tgbotapi.NewMessage(chatID, "🧾 Введите название счёта (например, «Интернет»):"))
+
+	case strings.HasPrefix(data, CallbackBillView):
+		billID, _ := strconv.Atoi(data[len(CallbackBillView):])
+		b.deleteMessage(chatID, messageID)
+		b.showBill(chatID, billID, svc)
+
+	case strings.HasPrefix(data, CallbackBillCategory):
+		state, ok := userStates[chatID]
+		if !ok || state.Step != "choose_bill_category" {
+			b.sendError(chatID, fmt.Errorf("операция не найдена, начните заново"))
+			return true
+		}
+		state.TempCategoryID, _ = strconv.Atoi(data[len(CallbackBillCategory):])
+		b.deleteMessage(chatID, messageID)
+
+		cards := b.loadCreditCards(svc)
+		if len(cards) == 0 {
+			b.createBill(chatID, state, 0, svc)
+			return true
+		}
+		state.Step = "choose_bill_account"
+		userStates[chatID] = state
+		b.askBillAccount(chatID, cards)
+
+	case strings.HasPrefix(data, CallbackBillAccount):
+		state, ok := userStates[chatID]
+		if !ok || state.Step != "choose_bill_account" {
+			b.sendError(chatID, fmt.Errorf("операция не найдена, начните заново"))
+			return true
+		}
+		cardID, _ := strconv.Atoi(data[len(CallbackBillAccount):])
+		b.deleteMessage(chatID, messageID)
+		b.createBill(chatID, state, cardID, svc)
+
+	case strings.HasPrefix(data, CallbackBillRemind):
+		parts := strings.Split(data[len(CallbackBillRemind):], "_")
+		if len(parts) != 2 {
+			b.sendError(chatID, fmt.Errorf("неверный формат напоминания"))
+			return true
+		}
+		billID, _ := strconv.Atoi(parts[0])
+		days, _ := strconv.Atoi(parts[1])
+		if err := svc.SetBillRemindDays(billID, days); err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		b.deleteMessage(chatID, messageID)
+		b.showBill(chatID, billID, svc)
+
+	case strings.HasPrefix(data, CallbackBillPaid):
+		parts := strings.Split(data[len(CallbackBillPaid):], "_")
+		if len(parts) != 2 {
+			b.sendError(chatID, fmt.Errorf("неверный формат платежа"))
+			return true
+		}
+		billID, _ := strconv.Atoi(parts[0])
+		due, err := time.ParseInLocation(billDueLayout, parts[1], svc.Location())
+		if err != nil {
+			b.sendError(chatID, fmt.Errorf("неверная дата платежа"))
+			return true
+		}
+		b.payBill(chatID, messageID, billID, due, svc)
+
+	case strings.HasPrefix(data, CallbackBillDel):
+		billID, _ := strconv.Atoi(data[len(CallbackBillDel):])
+		if err := svc.DeleteBill(billID); err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		b.deleteMessage(chatID, messageID)
+		b.send(chatID, tgbotapi.NewMessage(chatID, "🗑 Счёт удалён"))
+		b.showBills(chatID, svc)
+
+	default:
+		return false
+	}
+	return true
+}
+
+func (b *Bot) showBills(chatID int64, svc *service.FinanceService) {
+	bills, err := svc.GetBills()
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString("🧾 <b>Счета и регулярные платежи</b>\n\n")
+	if len(bills) == 0 {
+		text.WriteString("Добавьте интернет, связь, аренду и другие платежи — бот напомнит о них заранее, а отметить оплату можно одной кнопкой.")
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	now := svc.Now()
+	var total float64
+	for i := range bills {
+		bill := bills[i]
+		status := svc.GetBillStatus(&bill, now)
+		total += bill.Amount
+
+		text.WriteString(fmt.Sprintf("<b>%s</b> — %s\n", html.EscapeString(bill.Name), b.formatCurrency(bill.Amount, chatID)))
+		text.WriteString(fmt.Sprintf("┗ %s\n\n", billDueLabel(status)))
+
+		icon := "🧾 "
+		if status.Overdue {
+			icon = "⚠️ "
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(icon+bill.Name, fmt.Sprintf("%s%d", CallbackBillView, bill.ID)),
+			tgbotapi.NewInlineKeyboardButtonData("✅ Оплачено", billPaidData(bill.ID, status)),
+		))
+	}
+	if len(bills) > 0 {
+		text.WriteString(fmt.Sprintf("💰 Всего в месяц: %s", b.formatCurrency(total, chatID)))
+	}
+
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("➕ Добавить счёт", CallbackBillNew),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("◀️ Главное меню", "main_menu"),
+		),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) showBill(chatID int64, billID int, svc *service.FinanceService) {
+	bill, err := svc.GetBillByID(billID)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+	status := svc.GetBillStatus(bill, svc.Now())
+
+	categoryName := "Неизвестно"
+	if cat, err := svc.GetCategoryByID(bill.CategoryID); err == nil && cat != nil {
+		categoryName = cat.Name
+	}
+	account := "💳 Дебетовая карта / наличные"
+	if bill.CreditCardID != nil {
+		if card, err := svc.GetCreditCardByID(*bill.CreditCardID); err == nil {
+			account = "🟥 " + card.Name
+		}
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("🧾 <b>%s</b>\n\n", html.EscapeString(bill.Name)))
+	text.WriteString(fmt.Sprintf("┣ Сумма: %s\n", b.formatCurrency(bill.Amount, chatID)))
+	text.WriteString(fmt.Sprintf("┣ День оплаты: %d-е число\n", bill.DueDay))
+	text.WriteString(fmt.Sprintf("┣ Категория: %s\n", html.EscapeString(categoryName)))
+	text.WriteString(fmt.Sprintf("┣ Счёт списания: %s\n", html.EscapeString(account)))
+	text.WriteString(fmt.Sprintf("┣ Напоминание: %s\n", billRemindLabel(bill.RemindDays)))
+	text.WriteString(fmt.Sprintf("┗ %s\n", billDueLabel(status)))
+	if bill.PaidThrough != nil {
+		text.WriteString(fmt.Sprintf("\n✅ Последняя оплата за %s", bill.PaidThrough.Format("02.01.2006")))
+	}
+
+	var remindRow []tgbotapi.InlineKeyboardButton
+	for _, days := range service.BillRemindDays {
+		label := billRemindShortLabel(days)
+		if days == bill.RemindDays {
+			label = "✅ " + label
+		}
+		remindRow = append(remindRow, tgbotapi.NewInlineKeyboardButtonData(label,
+			fmt.Sprintf("%s%d_%d", CallbackBillRemind, bill.ID, days)))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("✅ Оплачено %s", b.formatCurrency(bill.Amount, chatID)),
+				billPaidData(bill.ID, status)),
+		),
+		remindRow,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🗑 Удалить", fmt.Sprintf("%s%d", CallbackBillDel, bill.ID)),
+			tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", CallbackBills),
+		),
+	)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) payBill(chatID int64, messageID, billID int, due time.Time, svc *service.FinanceService) {
+	bill, transactionID, err := svc.PayBill(billID, due)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+	b.deleteMessage(chatID, messageID)
+
+	next := svc.GetBillStatus(bill, svc.Now())
+	text := fmt.Sprintf("✅ Оплачено: %s, %s\n📅 Следующий платёж %s",
+		bill.Name, b.formatCurrency(bill.Amount, chatID), next.DueDate.Format("02.01.2006"))
+	if bill.CreditCardID != nil {
+		text += b.creditCardNotice(chatID, *bill.CreditCardID, svc)
+	}
+	b.send(chatID, tgbotapi.NewMessage(chatID, text))
+
+	if moves, err := svc.GetAutoMovesForTransaction(transactionID); err == nil {
+		b.notifyAutoMoves(chatID, moves)
+	}
+	b.notifyTransactionAnomaly(chatID, transactionID, svc)
+}
+
+func (b *Bot) askBillCategory(chatID int64, svc *service.FinanceService) {
+	categories, err := svc.GetCategories()
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, cat := range categoryTree(categories) {
+		if cat.Type != "expense" {
+			continue
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(categoryLabel(cat), fmt.Sprintf("%s%d", CallbackBillCategory, cat.ID)),
+		))
+	}
+	if len(rows) == 0 {
+		delete(userStates, chatID)
+		b.send(chatID, tgbotapi.NewMessage(chatID, "😔 Сначала создайте категорию расходов."))
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, "📂 В какую категорию записывать оплату?")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) askBillAccount(chatID int64, cards []repository.CreditCard) {
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("💳 Дебетовая карта / наличные", CallbackBillAccount+"0"),
+		),
+	}
+	for _, card := range cards {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🟥 "+card.Name, fmt.Sprintf("%s%d", CallbackBillAccount, card.ID)),
+		))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, "💳 С какого счёта оплачивается?")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) createBill(chatID int64, state UserState, cardID int, svc *service.FinanceService) {
+	id, err := svc.CreateBill(state.TempComment, state.TempAmount, state.TempTerm, state.TempCategoryID, cardID, 1)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	delete(userStates, chatID)
+	b.send(chatID, tgbotapi.NewMessage(chatID, "✅ Счёт добавлен! Напомню о нём за день до оплаты."))
+	b.showBill(chatID, id, svc)
+}
+
+func (b *Bot) handleBillName(m *tgbotapi.Message) {
+	name := strings.TrimSpace(m.Text)
+	if name == "" {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Название не может быть пустым. Попробуйте снова:"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	state.Step = "enter_bill_amount"
+	state.TempComment = name
+	userStates[m.From.ID] = state
+
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "💰 Введите сумму платежа:"))
+}
+
+func (b *Bot) handleBillAmount(m *tgbotapi.Message) {
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(m.Text), ",", "."), 64)
+	if err != nil || amount <= 0 {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите корректную сумму (например, 650):"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	state.Step = "enter_bill_due_day"
+	state.TempAmount = amount
+	userStates[m.From.ID] = state
+
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID,
+		"📅 Какого числа нужно платить? Введите число от 1 до 31.\nЕсли в месяце меньше дней, срок сдвинется на последний день."))
+}
+
+func (b *Bot) handleBillDueDay(m *tgbotapi.Message, svc *service.FinanceService) {
+	day, err := strconv.Atoi(strings.TrimSpace(m.Text))
+	if err != nil || day < 1 || day > 31 {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите число от 1 до 31:"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	state.Step = "choose_bill_category"
+	state.TempTerm = day
+	userStates[m.From.ID] = state
+
+	b.askBillCategory(m.Chat.ID, svc)
+}
+
+func billPaidData(billID int, status service.BillStatus) string {
+	return fmt.Sprintf("%s%d_%s", CallbackBillPaid, billID, status.DueDate.Format(billDueLayout))
+}
+
+func billDueLabel(status service.BillStatus) string {
+	switch {
+	case status.Overdue:
+		return fmt.Sprintf("⚠️ Просрочен с %s", status.DueDate.Format("02.01.2006"))
+	case status.DaysLeft == 0:
+		return "⏰ Оплатить сегодня"
+	default:
+		return fmt.Sprintf("Оплатить %s, %s", daysLeftLabel(status.DaysLeft), status.DueDate.Format("02.01.2006"))
+	}
+}
+
+func billRemindLabel(days int) string {
+	if days == 0 {
+		return "в день оплаты"
+	}
+	return fmt.Sprintf("за %d дн. до оплаты", days)
+}
+
+func billRemindShortLabel(days int) string {
+	if days == 0 {
+		return "В день"
+	}
+	return fmt.Sprintf("За %d дн.", days)
+}
+
+func (b *Bot) billPlanner(job repository.Job) (string, error) {
+	users, err := b.repo.GetAllUsers()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	planned := 0
+	for i := range users {
+		user := users[i]
+		if !user.CanNotify() {
+			continue
+		}
+
+		svc := service.NewService(b.repo, &user)
+		local := svc.Now()
+		if svc.IsHouseholdGuest() || local.Hour() < billReminderHour {
+			continue
+		}
+
+		bills, err := svc.GetBills()
+		if err != nil {
+			logger.Error("Bill reminder error getting bills", "user_id", user.TelegramID, "error", err)
+			continue
+		}
+		for i := range bills {
+			bill := bills[i]
+			status := svc.GetBillStatus(&bill, local)
+			due := status.DueDate.Format("2006-01-02")
+
+			key := fmt.Sprintf("%d:%s", bill.ID, due)
+			switch {
+			case status.Overdue:
+				key += ":overdue"
+			case status.DaysLeft > bill.RemindDays:
+				continue
+			}
+
+			payload := fmt.Sprintf("%d:%d:%s", user.ID, bill.ID, due)
+			added, err := b.jobs.Enqueue(JobBillReminder, key, payload, now)
+			if err != nil {
+				logger.Error("Bill reminder enqueue error", "bill_id", bill.ID, "error", err)
+				continue
+			}
+			if added {
+				planned++
+			}
+		}
+	}
+	return fmt.Sprintf("planned %d bill reminders", planned), nil
+}
+
+func (b *Bot) runBillReminder(job repository.Job) (string, error) {
+	parts := strings.SplitN(job.Payload, ":", 3)
+	if len(parts) != 3 {
+		return "", fmt.Errorf("bad bill reminder payload %q", job.Payload)
+	}
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return "", fmt.Errorf("bad bill reminder payload %q", job.Payload)
+	}
+	billID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("bad bill reminder payload %q", job.Payload)
+	}
+
+	user, err := b.repo.GetUserByID(userID)
+	if err != nil {
+		return "", err
+	}
+	if user == nil || !user.CanNotify() {
+		return "skipped: notifications off or chat unreachable", nil
+	}
+
+	svc := service.NewService(b.repo, user)
+	bills, err := svc.GetBills()
+	if err != nil {
+		return "", err
+	}
+	var bill *repository.Bill
+	for i := range bills {
+		if bills[i].ID == billID {
+			bill = &bills[i]
+		}
+	}
+	if bill == nil {
+		return "skipped: bill deleted", nil
+	}
+	status := svc.GetBillStatus(bill, svc.Now())
+	if status.DueDate.Format("2006-01-02") != parts[2] {
+		return "skipped: already paid", nil
+	}
+
+	var text string
+	if status.Overdue {
+		text = fmt.Sprintf("⚠️ <b>Счёт «%s» просрочен</b>\n\n"+
+			"%s нужно было оплатить %s.\nЕсли уже заплатили — отметьте оплату, и расход запишется сам.",
+			html.EscapeString(bill.Name), b.formatCurrency(bill.Amount, user.TelegramID), status.DueDate.Format("02.01.2006"))
+	} else {
+		text = fmt.Sprintf("🧾 <b>%s %s — оплатить %s</b>\n\nСрок оплаты: %s",
+			html.EscapeString(bill.Name), b.formatCurrency(bill.Amount, user.TelegramID), daysLeftLabel(status.DaysLeft),
+			status.DueDate.Format("02.01.2006"))
+	}
+
+	msg := tgbotapi.NewMessage(user.TelegramID, text)
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Оплачено", billPaidData(bill.ID, status)),
+			tgbotapi.NewInlineKeyboardButtonData("🧾 Все счета", CallbackBills),
+		),
+	)
+	if err := b.deliver(user.TelegramID, msg); err != nil {
+		return "", err
+	}
+	return "sent", nil
+}
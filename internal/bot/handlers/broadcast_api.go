@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/IlyaMakar/finance_bot/internal/logger"
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+)
+
+const broadcastListLimit = 50
+
+type BroadcastAPI struct {
+	bot   *Bot
+	token string
+}
+
+func NewBroadcastAPI(bot *Bot, token string) *BroadcastAPI {
+	return &BroadcastAPI{bot: bot, token: token}
+}
+
+type BroadcastRequest struct {
+	Text       string                      `json:"text"`
+	ParseMode  string                      `json:"parse_mode"`
+	Segment    repository.BroadcastSegment `json:"segment"`
+	TestChatID int64                       `json:"test_chat_id"`
+}
+
+type BroadcastPreviewResponse struct {
+	Recipients  int    `json:"recipients"`
+	PreviewSent bool   `json:"preview_sent"`
+	Error       string `json:"error,omitempty"`
+}
+
+func (a *BroadcastAPI) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if a.token == "" {
+		http.Error(w, "broadcasts are disabled: ADMIN_API_TOKEN is not set", http.StatusForbidden)
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Admin-Token")), []byte(a.token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+func (a *BroadcastAPI) Broadcasts(w http.ResponseWriter, r *http.Request) {
+	if !a.authorized(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		list, err := a.bot.repo.GetBroadcasts(broadcastListLimit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, list)
+
+	case http.MethodPost:
+		var req BroadcastRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		broadcast, err := a.bot.CreateBroadcast(req.Text, req.ParseMode, req.Segment)
+		if err != nil {
+			logger.Warn("Broadcast rejected", "error", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusCreated, broadcast)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (a *BroadcastAPI) Preview(w http.ResponseWriter, r *http.Request) {
+	if !a.authorized(w, r) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req BroadcastRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	recipients, err := a.bot.PreviewBroadcast(req.Text, req.ParseMode, req.Segment, req.TestChatID)
+	resp := BroadcastPreviewResponse{Recipients: recipients, PreviewSent: err == nil && req.TestChatID != 0}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (a *BroadcastAPI) Recipients(w http.ResponseWriter, r *http.Request) {
+	if !a.authorized(w, r) {
+		return
+	}
+
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "invalid broadcast id", http.StatusBadRequest)
+		return
+	}
+	recipients, err := a.bot.repo.GetBroadcastRecipients(id, r.URL.Query().Get("status"), -1)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, recipients)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Error("Failed to encode JSON response", "error", err)
+	}
+}
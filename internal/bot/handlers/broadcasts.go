@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/logger"
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	broadcastBatchSize   = 100
+	broadcastMaxAttempts = 3
+	bulkSendInterval     = 50 * time.Millisecond
+)
+
+var broadcastParseModes = map[string]bool{
+	"":                      true,
+	tgbotapi.ModeMarkdown:   true,
+	tgbotapi.ModeMarkdownV2: true,
+	tgbotapi.ModeHTML:       true,
+}
+
+type bulkLimiter struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+func (l *bulkLimiter) wait() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if d := bulkSendInterval - time.Since(l.last); d > 0 {
+		time.Sleep(d)
+	}
+	l.last = time.Now()
+}
+
+func (b *Bot) CreateBroadcast(text, parseMode string, seg repository.BroadcastSegment) (*repository.Broadcast, error) {
+	if err := validateBroadcast(text, parseMode, seg); err != nil {
+		return nil, err
+	}
+
+	users, err := b.repo.GetSegmentUsers(seg, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("в сегменте нет получателей")
+	}
+
+	id, err := b.repo.CreateBroadcast(repository.Broadcast{Text: text, ParseMode: parseMode, Segment: seg}, users)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := b.jobs.Enqueue(JobBroadcast, strconv.Itoa(id), strconv.Itoa(id), time.Now()); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Broadcast queued", "broadcast_id", id, "recipients", len(users))
+	return b.repo.GetBroadcast(id)
+}
+
+func (b *Bot) PreviewBroadcast(text, parseMode string, seg repository.BroadcastSegment, chatID int64) (int, error) {
+	if err := validateBroadcast(text, parseMode, seg); err != nil {
+		return 0, err
+	}
+
+	users, err := b.repo.GetSegmentUsers(seg, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	if chatID != 0 {
+		msg := tgbotapi.NewMessage(chatID, text)
+		msg.ParseMode = parseMode
+		if err := b.deliver(chatID, msg); err != nil {
+			return len(users), fmt.Errorf("не удалось отправить предпросмотр: %v", err)
+		}
+	}
+	return len(users), nil
+}
+
+func validateBroadcast(text, parseMode string, seg repository.BroadcastSegment) error {
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("текст рассылки не может быть пустым")
+	}
+	if len([]rune(text)) > 4096 {
+		return fmt.Errorf("текст длиннее 4096 символов")
+	}
+	if !broadcastParseModes[parseMode] {
+		return fmt.Errorf("неизвестный формат %q", parseMode)
+	}
+	if seg.ActiveDays < 0 {
+		return fmt.Errorf("число дней активности не может быть отрицательным")
+	}
+	return nil
+}
+
+func (b *Bot) runBroadcast(job repository.Job) (string, error) {
+	id, err := strconv.Atoi(job.Payload)
+	if err != nil {
+		return "", fmt.Errorf("bad broadcast payload %q", job.Payload)
+	}
+	broadcast, err := b.repo.GetBroadcast(id)
+	if err != nil {
+		return "", err
+	}
+	if broadcast == nil {
+		return "skipped: broadcast not found", nil
+	}
+
+	recipients, err := b.repo.GetBroadcastRecipients(id, repository.RecipientPending, broadcastBatchSize)
+	if err != nil {
+		return "", err
+	}
+	if len(recipients) == 0 {
+		if err := b.repo.UpdateBroadcastStatus(id, repository.BroadcastDone); err != nil {
+			return "", err
+		}
+		logger.Info("Broadcast finished", "broadcast_id", id,
+			"sent", broadcast.Stats.Sent, "failed", broadcast.Stats.Failed, "blocked", broadcast.Stats.Blocked)
+		return "done", nil
+	}
+	if broadcast.Status == repository.BroadcastQueued {
+		if err := b.repo.UpdateBroadcastStatus(id, repository.BroadcastSending); err != nil {
+			return "", err
+		}
+	}
+
+	sent := 0
+	resumeAt := time.Now()
+	for _, rc := range recipients {
+		b.bulk.wait()
+
+		msg := tgbotapi.NewMessage(rc.TelegramID, broadcast.Text)
+		msg.ParseMode = broadcast.ParseMode
+		err := b.deliver(rc.TelegramID, msg)
+
+		status, lastError := repository.RecipientSent, ""
+		if err != nil {
+			failure, retryAfter := classifySendError(err)
+			switch {
+			case failure == sendRateLimited:
+				resumeAt = time.Now().Add(time.Duration(retryAfter) * time.Second)
+				logger.Warn("Broadcast rate limited", "broadcast_id", id, "retry_after", retryAfter)
+			case failure == sendBlocked || failure == sendDeactivated:
+				status, lastError = repository.RecipientBlocked, err.Error()
+			case failure == sendRejected || rc.Attempts+1 >= broadcastMaxAttempts:
+				status, lastError = repository.RecipientFailed, err.Error()
+			default:
+				status, lastError = repository.RecipientPending, err.Error()
+			}
+			if failure == sendRateLimited {
+				break
+			}
+		}
+
+		if err := b.repo.MarkBroadcastRecipient(id, rc.UserID, status, lastError); err != nil {
+			return "", err
+		}
+		if status == repository.RecipientSent {
+			sent++
+		}
+	}
+
+	key := fmt.Sprintf("%d:after:%d", id, job.ID)
+	if _, err := b.jobs.Enqueue(JobBroadcast, key, job.Payload, resumeAt); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("sent %d of %d", sent, len(recipients)), nil
+}
@@ -28,6 +28,7 @@ func (b *Bot) handleCallback(q *tgbotapi.CallbackQuery) {
 		b.sendError(chatID, err)
 		return
 	}
+	b.markReachable(user)
 	if strings.HasPrefix(data, "export_report_") {
 		parts := strings.Split(data, "_")
 		if len(parts) != 4 {
@@ -35,38 +36,28 @@ func (b *Bot) handleCallback(q *tgbotapi.CallbackQuery) {
 			return
 		}
 
-		start, err := time.Parse("2006-01-02", parts[2])
+		svc := service.NewService(b.repo, user)
+		start, err := time.ParseInLocation("2006-01-02", parts[2], svc.Location())
 		if err != nil {
 			b.sendError(chatID, fmt.Errorf("ошибка парсинга даты начала"))
 			return
 		}
 
-		end, err := time.Parse("2006-01-02", parts[3])
+		end, err := time.ParseInLocation("2006-01-02", parts[3], svc.Location())
 		if err != nil {
 			b.sendError(chatID, fmt.Errorf("ошибка парсинга даты окончания"))
 			return
 		}
 
-		user, err := b.repo.GetOrCreateUser(
-			q.From.ID,
-			q.From.UserName,
-			q.From.FirstName,
-			q.From.LastName,
-		)
-		if err != nil {
-			b.sendError(chatID, err)
-			return
-		}
-		svc := service.NewService(b.repo, user)
-
-		pdfData, err := b.reportGen.GeneratePDFReport(chatID, start, end, svc)
+		lang := reportLanguage(q.From.LanguageCode)
+		pdfData, err := b.reportGen.GeneratePDFReport(chatID, start, end, svc, lang)
 		if err != nil {
 			b.sendError(chatID, fmt.Errorf("ошибка генерации отчета"))
 			return
 		}
 
 		file := tgbotapi.FileBytes{
-			Name:  fmt.Sprintf("Отчет_%s_%s.pdf", start.Format("02.01.2006"), end.Format("02.01.2006")),
+			Name:  reportLocale{lang: lang}.textf("file_name", start.Format("02.01.2006"), end.Format("02.01.2006")),
 			Bytes: pdfData,
 		}
 		doc := tgbotapi.NewDocument(chatID, file)
@@ -269,6 +260,124 @@ func (b *Bot) handleCallback(q *tgbotapi.CallbackQuery) {
 		return
 	}
 
+	if b.handleSavingRuleCallback(chatID, q.Message.MessageID, data, svc) {
+		return
+	}
+
+	if b.handleDepositCallback(chatID, q.Message.MessageID, data, svc) {
+		return
+	}
+
+	if b.handleDebtCallback(chatID, q.Message.MessageID, data, svc) {
+		return
+	}
+
+	if b.handleLoanCallback(chatID, q.Message.MessageID, data, svc) {
+		return
+	}
+
+	if b.handleCreditCardCallback(chatID, q.Message.MessageID, data, q.From, svc) {
+		return
+	}
+
+	if b.handleBillCallback(chatID, q.Message.MessageID, data, svc) {
+		return
+	}
+
+	if b.handleHouseholdCallback(chatID, q.Message.MessageID, data, svc) {
+		return
+	}
+
+	if b.handleDateRangeCallback(chatID, q.Message.MessageID, data, svc) {
+		return
+	}
+
+	if b.handleCategoryCallback(chatID, q.Message.MessageID, data, svc) {
+		return
+	}
+
+	if b.handleChartCallback(chatID, data, svc) {
+		return
+	}
+
+	if b.handleDigestCallback(chatID, q.Message.MessageID, data, svc) {
+		return
+	}
+
+	if b.handleReminderCallback(chatID, q.Message.MessageID, data, svc) {
+		return
+	}
+
+	if b.handleNetWorthCallback(chatID, q.Message.MessageID, data, svc) {
+		return
+	}
+
+	if b.handleAnomalyCallback(chatID, q.Message.MessageID, data, svc) {
+		return
+	}
+
+	if b.handlePatternCallback(chatID, data, svc) {
+		return
+	}
+
+	if b.handleYearReviewCallback(chatID, data, reportLanguage(q.From.LanguageCode), svc) {
+		return
+	}
+
+	if strings.HasPrefix(data, CallbackSavingDeadlineClear) {
+		savingID, _ := strconv.Atoi(data[len(CallbackSavingDeadlineClear):])
+		if err := svc.SetSavingTargetDate(savingID, nil); err != nil {
+			b.sendError(chatID, err)
+			return
+		}
+		delete(userStates, chatID)
+		b.deleteMessage(chatID, q.Message.MessageID)
+		b.send(chatID, tgbotapi.NewMessage(chatID, "✅ Срок цели убран"))
+		b.showSavingActions(chatID, savingID, svc)
+		return
+	}
+
+	if strings.HasPrefix(data, CallbackSavingDeadline) {
+		savingID, _ := strconv.Atoi(data[len(CallbackSavingDeadline):])
+		state := userStates[chatID]
+		state.Step = "enter_saving_deadline"
+		state.TempCategoryID = savingID
+		userStates[chatID] = state
+
+		b.deleteMessage(chatID, q.Message.MessageID)
+		msg := tgbotapi.NewMessage(chatID, "📅 Введите дату, к которой нужно накопить (ДД.ММ.ГГГГ):")
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🗑 Убрать срок", fmt.Sprintf("%s%d", CallbackSavingDeadlineClear, savingID)),
+				tgbotapi.NewInlineKeyboardButtonData("◀️ Отмена", fmt.Sprintf("%s%d", CallbackEditSaving, savingID)),
+			),
+		)
+		b.send(chatID, msg)
+		return
+	}
+
+	if strings.HasPrefix(data, CallbackSavingPace) {
+		savingID, _ := strconv.Atoi(data[len(CallbackSavingPace):])
+		saving, err := svc.GetSavingByID(savingID)
+		if err != nil {
+			b.sendError(chatID, err)
+			return
+		}
+
+		period := service.ContributionWeekly
+		if saving.ContributionPeriod == service.ContributionWeekly {
+			period = service.ContributionMonthly
+		}
+		if err := svc.SetSavingContributionPeriod(savingID, period); err != nil {
+			b.sendError(chatID, err)
+			return
+		}
+
+		b.deleteMessage(chatID, q.Message.MessageID)
+		b.showSavingActions(chatID, savingID, svc)
+		return
+	}
+
 	if strings.HasPrefix(data, "saving_delete_") {
 		savingID, _ := strconv.Atoi(data[len("saving_delete_"):])
 		b.deleteMessage(chatID, q.Message.MessageID)
@@ -334,6 +443,14 @@ func (b *Bot) handleCallback(q *tgbotapi.CallbackQuery) {
 			From: q.From,
 			Text: "Пропустить",
 		})
+	case "skip_saving_deadline":
+		editMsg := tgbotapi.NewEditMessageReplyMarkup(chatID, q.Message.MessageID, tgbotapi.InlineKeyboardMarkup{})
+		b.bot.Send(editMsg)
+		b.handleCreateSavingDeadline(&tgbotapi.Message{
+			Chat: &tgbotapi.Chat{ID: chatID},
+			From: q.From,
+			Text: "Пропустить",
+		}, svc)
 	case "type_income", "type_expense":
 		b.handleTypeSelect(chatID, q.Message.MessageID, data, svc)
 	case "notification_settings":
@@ -380,6 +497,9 @@ func (b *Bot) handleCallback(q *tgbotapi.CallbackQuery) {
 		b.showMonthlyReport(chatID, svc)
 	case "stats_year":
 		b.showYearlyReport(chatID, svc)
+	case CallbackCompareMonth, CallbackCompareYear:
+		b.deleteMessage(chatID, q.Message.MessageID)
+		b.showPeriodComparison(chatID, data == CallbackCompareYear, svc)
 	case "stats_back":
 		b.showReportPeriodMenu(chatID)
 	case "show_history":
@@ -486,13 +606,27 @@ func (b *Bot) handleTypeSelect(chatID int64, msgID int, data string, svc *servic
 	userStates[chatID] = u
 
 	cats, _ := svc.GetCategories()
+	hasChildren := make(map[int]bool)
+	for _, c := range cats {
+		if c.ParentID != nil {
+			hasChildren[*c.ParentID] = true
+		}
+	}
+
 	var rows [][]tgbotapi.InlineKeyboardButton
 	for _, c := range cats {
-		if c.Type == u.TempType {
+		if c.Type != u.TempType || c.ParentID != nil {
+			continue
+		}
+		if hasChildren[c.ID] {
 			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData(c.Name, "cat_"+strconv.Itoa(c.ID)),
+				tgbotapi.NewInlineKeyboardButtonData(c.Name+" ›", CallbackPickSubcategory+strconv.Itoa(c.ID)),
 			))
+			continue
 		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(c.Name, "cat_"+strconv.Itoa(c.ID)),
+		))
 	}
 	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
 		tgbotapi.NewInlineKeyboardButtonData("✨ Новая категория", "other_cat"),
@@ -560,20 +694,12 @@ func (b *Bot) handleToggleNotifications(chatID int64, enable bool, user *tgbotap
 }
 
 func (b *Bot) handleDeleteCategory(chatID int64, categoryID int, messageID int, svc *service.FinanceService) {
-	transactions, err := svc.GetTransactionsForPeriod(time.Now().AddDate(-10, 0, 0), time.Now())
+	hasTransactions, err := svc.CategoryHasTransactions(categoryID)
 	if err != nil {
 		b.sendError(chatID, err)
 		return
 	}
 
-	hasTransactions := false
-	for _, t := range transactions {
-		if t.CategoryID == categoryID {
-			hasTransactions = true
-			break
-		}
-	}
-
 	if hasTransactions {
 		msg := tgbotapi.NewMessage(chatID, "⚠️ Нельзя удалить категорию, связанную с транзакциями!")
 		b.send(chatID, msg)
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+	"github.com/IlyaMakar/finance_bot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	CallbackNewSubcategory    = "subcat_new_"
+	CallbackAddSubcategory    = "subcat_add_"
+	CallbackPickSubcategory   = "subcat_pick_"
+	CallbackMoveCategory      = "catmove_"
+	CallbackMoveCategoryTo    = "catmoveto_"
+	CallbackCategoryDrillDown = "drill_"
+
+	drillDownButtonsLimit = 6
+)
+
+func (b *Bot) handleCategoryCallback(chatID int64, messageID int, data string, svc *service.FinanceService) bool {
+	switch {
+	case strings.HasPrefix(data, CallbackNewSubcategory):
+		parentID, _ := strconv.Atoi(data[len(CallbackNewSubcategory):])
+		userStates[chatID] = UserState{Step: "new_subcat", TempCategoryID: parentID}
+		b.send(chatID, tgbotapi.NewMessage(chatID, "📝 Введите название подкатегории:"))
+
+	case strings.HasPrefix(data, CallbackAddSubcategory):
+		parentID, _ := strconv.Atoi(data[len(CallbackAddSubcategory):])
+		state := userStates[chatID]
+		state.Step = "new_subcat"
+		state.TempCategoryID = parentID
+		userStates[chatID] = state
+		b.send(chatID, tgbotapi.NewMessage(chatID, "📝 Введите название подкатегории:"))
+
+	case strings.HasPrefix(data, CallbackPickSubcategory):
+		parentID, _ := strconv.Atoi(data[len(CallbackPickSubcategory):])
+		b.showSubcategoryPicker(chatID, messageID, parentID, svc)
+
+	case strings.HasPrefix(data, CallbackMoveCategoryTo):
+		parts := strings.Split(data[len(CallbackMoveCategoryTo):], "_")
+		if len(parts) != 2 {
+			return true
+		}
+		id, _ := strconv.Atoi(parts[0])
+		parentID, _ := strconv.Atoi(parts[1])
+
+		var parent *int
+		if parentID > 0 {
+			parent = &parentID
+		}
+		if err := svc.MoveCategory(id, parent); err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		b.deleteMessage(chatID, messageID)
+		b.send(chatID, tgbotapi.NewMessage(chatID, "✅ Категория перемещена!"))
+		b.showCategoryManagement(chatID, svc)
+
+	case strings.HasPrefix(data, CallbackMoveCategory):
+		id, _ := strconv.Atoi(data[len(CallbackMoveCategory):])
+		b.showMoveCategory(chatID, messageID, id, svc)
+
+	case strings.HasPrefix(data, CallbackCategoryDrillDown):
+		parts := strings.Split(data[len(CallbackCategoryDrillDown):], "_")
+		if len(parts) != 3 {
+			return true
+		}
+		parentID, _ := strconv.Atoi(parts[0])
+		start, err1 := time.ParseInLocation("20060102", parts[1], svc.Location())
+		end, err2 := time.ParseInLocation("20060102", parts[2], svc.Location())
+		if err1 != nil || err2 != nil {
+			b.sendError(chatID, fmt.Errorf("неверный период"))
+			return true
+		}
+		b.showCategoryDrillDown(chatID, parentID, start, end, svc)
+
+	default:
+		return false
+	}
+	return true
+}
+
+func categoryTree(categories []repository.Category) []repository.Category {
+	children := make(map[int][]repository.Category)
+	for _, c := range categories {
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		}
+	}
+
+	tree := make([]repository.Category, 0, len(categories))
+	for _, c := range categories {
+		if c.ParentID == nil {
+			tree = append(tree, c)
+			tree = append(tree, children[c.ID]...)
+		}
+	}
+	return tree
+}
+
+func categoryLabel(c repository.Category) string {
+	if c.ParentID != nil {
+		return "   └ " + c.Name
+	}
+	return c.Name
+}
+
+func (b *Bot) showSubcategoryPicker(chatID int64, messageID, parentID int, svc *service.FinanceService) {
+	parent, err := svc.GetCategoryByID(parentID)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+	children, err := svc.GetSubcategories(parentID)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(parent.Name+" · без подкатегории", "cat_"+strconv.Itoa(parent.ID)),
+		),
+	}
+	for _, c := range children {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(c.Name, "cat_"+strconv.Itoa(c.ID)),
+		))
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✨ Новая подкатегория", CallbackAddSubcategory+strconv.Itoa(parent.ID)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", "type_"+parent.Type),
+		),
+	)
+
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID,
+		fmt.Sprintf("📂 %s\n\nВыберите подкатегорию:", parent.Name), tgbotapi.NewInlineKeyboardMarkup(rows...))
+	b.send(chatID, edit)
+}
+
+func (b *Bot) showMoveCategory(chatID int64, messageID, id int, svc *service.FinanceService) {
+	category, err := svc.GetCategoryByID(id)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+	candidates, err := svc.GetParentCandidates(id)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if category.ParentID != nil {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⬆️ На верхний уровень", fmt.Sprintf("%s%d_0", CallbackMoveCategoryTo, id)),
+		))
+	}
+	for _, c := range candidates {
+		if category.ParentID != nil && *category.ParentID == c.ID {
+			continue
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(c.Name, fmt.Sprintf("%s%d_%d", CallbackMoveCategoryTo, id, c.ID)),
+		))
+	}
+
+	text := fmt.Sprintf("📂 Куда переместить «%s»?", category.Name)
+	if len(rows) == 0 {
+		text = "😔 Нет подходящих категорий того же типа для вложения."
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", CallbackEditCategory+strconv.Itoa(id)),
+	))
+
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, text, tgbotapi.NewInlineKeyboardMarkup(rows...))
+	b.send(chatID, edit)
+}
+
+func (b *Bot) handleNewSubcategory(m *tgbotapi.Message, svc *service.FinanceService) {
+	state := userStates[m.From.ID]
+	id, err := svc.CreateSubcategory(state.TempCategoryID, m.Text)
+	if err != nil {
+		b.sendError(m.Chat.ID, err)
+		return
+	}
+
+	if state.TempType == "" {
+		delete(userStates, m.From.ID)
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "✅ Подкатегория создана!"))
+		b.showCategoryManagement(m.Chat.ID, svc)
+		return
+	}
+
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "✅ Подкатегория создана!"))
+	b.handleCatSelect(int(m.Chat.ID), id)
+}
+
+func drillDownButtons(sums []repository.CategorySum, start, end time.Time) [][]tgbotapi.InlineKeyboardButton {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for i, g := range service.GroupBySubcategory(sums) {
+		if i == drillDownButtonsLimit {
+			break
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("🔍 "+g.Name, fmt.Sprintf("%s%d_%s_%s",
+			CallbackCategoryDrillDown, g.ParentID, start.Format("20060102"), end.Format("20060102"))))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func (b *Bot) showCategoryDrillDown(chatID int64, parentID int, start, end time.Time, svc *service.FinanceService) {
+	sums, err := svc.GetCategorySums(start, end)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	var group *service.SubcategoryBreakdown
+	for _, g := range service.GroupBySubcategory(sums) {
+		if g.ParentID == parentID {
+			group = &g
+			break
+		}
+	}
+	if group == nil {
+		b.send(chatID, tgbotapi.NewMessage(chatID, "😔 За этот период нет операций по подкатегориям."))
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("🔍 <b>%s</b>\n<i>%s</i>\n\n", group.Name, rangeLabel(start, end.AddDate(0, 0, -1))))
+	text.WriteString(fmt.Sprintf("Итого: %s\n", b.formatCurrency(group.Total, chatID)))
+	for _, name := range sortCategoriesByAmount(group.Items) {
+		amount := group.Items[name]
+		text.WriteString(fmt.Sprintf("┣ %s: %s (%.1f%%)\n", name, b.formatCurrency(amount, chatID), amount/group.Total*100))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", "stats_back")),
+	)
+	b.send(chatID, msg)
+}
+
+func (rg *ReportGenerator) addSubcategorySection(pdf *gofpdf.Fpdf, sums []repository.CategorySum, loc reportLocale) {
+	groups := service.GroupBySubcategory(sums)
+	if len(groups) == 0 {
+		return
+	}
+
+	pdf.Ln(10)
+	pdf.SetFont("DejaVuSans", "B", 14)
+	pdf.CellFormat(190, 10, loc.text("subcategories"), "", 1, "L", false, 0, "")
+
+	for _, g := range groups {
+		if pdf.GetY()+60 > 280 {
+			pdf.AddPage()
+		}
+
+		pdf.SetFont("DejaVuSans", "B", 11)
+		pdf.CellFormat(190, 7, fmt.Sprintf("%s – %s", removeEmoji(g.Name), loc.money(g.Total)), "", 1, "L", false, 0, "")
+
+		items := make(map[string]float64, len(g.Items))
+		for name, amount := range g.Items {
+			if name == service.NoSubcategory {
+				name = loc.text("no_subcategory")
+			}
+			items[removeEmoji(name)] += amount
+		}
+
+		y := pdf.GetY()
+		img, legend, colors := rg.generatePieWithLegend(items, loc)
+		rg.addImageToPDF(pdf, img, "", 10, y, 75, 50)
+		rg.addLegendWithColor(pdf, legend, colors, 95, y+5)
+		if pdf.GetY() < y+52 {
+			pdf.SetY(y + 52)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+	"github.com/IlyaMakar/finance_bot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+)
+
+const (
+	CallbackChartPie     = "chart_pie_"
+	CallbackChartBalance = "chart_balance_"
+	CallbackChartYear    = "chart_year"
+	CallbackChartTrend   = "chart_trend"
+
+	chartPieLimit   = 8
+	chartTrendLimit = 5
+)
+
+func (b *Bot) handleChartCallback(chatID int64, data string, svc *service.FinanceService) bool {
+	switch {
+	case data == CallbackChartYear:
+		b.sendMonthlyBarsChart(chatID, svc)
+	case data == CallbackChartTrend:
+		b.sendCategoryTrendChart(chatID, svc)
+	case strings.HasPrefix(data, CallbackChartPie), strings.HasPrefix(data, CallbackChartBalance):
+		prefix := CallbackChartPie
+		if strings.HasPrefix(data, CallbackChartBalance) {
+			prefix = CallbackChartBalance
+		}
+		parts := strings.Split(data[len(prefix):], "_")
+		if len(parts) != 2 {
+			return true
+		}
+		start, err1 := time.ParseInLocation("20060102", parts[0], svc.Location())
+		end, err2 := time.ParseInLocation("20060102", parts[1], svc.Location())
+		if err1 != nil || err2 != nil {
+			b.sendError(chatID, fmt.Errorf("неверный период"))
+			return true
+		}
+		if prefix == CallbackChartPie {
+			b.sendExpensePieChart(chatID, start, end, svc)
+		} else {
+			b.sendBalanceChart(chatID, start, end, svc)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+func chartButtons(start, end time.Time) []tgbotapi.InlineKeyboardButton {
+	period := start.Format("20060102") + "_" + end.Format("20060102")
+	return tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("📈 График расходов", CallbackChartPie+period),
+		tgbotapi.NewInlineKeyboardButtonData("📈 График баланса", CallbackChartBalance+period),
+	)
+}
+
+func (b *Bot) sendChart(chatID int64, img []byte, err error, caption string) {
+	if err != nil {
+		b.send(chatID, tgbotapi.NewMessage(chatID, "😔 Недостаточно данных для построения графика."))
+		return
+	}
+
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: "chart.png", Bytes: img})
+	photo.Caption = caption
+	photo.ParseMode = tgbotapi.ModeHTML
+	b.send(chatID, photo)
+}
+
+func (b *Bot) sendExpensePieChart(chatID int64, start, end time.Time, svc *service.FinanceService) {
+	sums, err := svc.GetCategorySums(start, end)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	_, details := service.RootCategoryTotals(sums)
+	total := sum(details)
+	if total == 0 {
+		b.send(chatID, tgbotapi.NewMessage(chatID, "😔 За этот период нет расходов."))
+		return
+	}
+
+	img, err := b.reportGen.renderExpensePie(details)
+	b.sendChart(chatID, img, err, fmt.Sprintf("🥧 <b>Расходы по категориям</b>\n%s\nИтого: %s",
+		rangeLabel(start, end.AddDate(0, 0, -1)), b.formatCurrency(total, chatID)))
+}
+
+func (b *Bot) sendBalanceChart(chatID int64, start, end time.Time, svc *service.FinanceService) {
+	sums, err := svc.GetDailySums(start, end)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+	if len(sums) == 0 {
+		b.send(chatID, tgbotapi.NewMessage(chatID, "😔 За этот период нет операций."))
+		return
+	}
+
+	if today := svc.Now(); end.After(today) {
+		end = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, svc.Location()).AddDate(0, 0, 1)
+	}
+	days, balances := dailyBalance(sums, start, end)
+
+	img, err := b.reportGen.renderBalanceLine(days, balances)
+	b.sendChart(chatID, img, err, fmt.Sprintf("📈 <b>Баланс по дням</b>\n%s\nНа конец периода: %s",
+		rangeLabel(start, end.AddDate(0, 0, -1)), b.formatCurrency(balances[len(balances)-1], chatID)))
+}
+
+func (b *Bot) sendMonthlyBarsChart(chatID int64, svc *service.FinanceService) {
+	now := svc.Now()
+	totals, err := svc.GetMonthlyTotals(time.Date(now.Year(), 1, 1, 0, 0, 0, 0, svc.Location()), 12)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	var income, expense float64
+	for _, t := range totals {
+		income += t.Income
+		expense += t.Expense
+	}
+	if income == 0 && expense == 0 {
+		b.send(chatID, tgbotapi.NewMessage(chatID, "😔 В этом году ещё нет операций."))
+		return
+	}
+
+	img, err := b.reportGen.renderMonthlyBars(totals, monthShortName)
+	b.sendChart(chatID, img, err, fmt.Sprintf("📊 <b>Доходы и расходы за %d год</b>\n🟩 доходы: %s\n🟧 расходы: %s",
+		now.Year(), b.formatCurrency(income, chatID), b.formatCurrency(expense, chatID)))
+}
+
+func (b *Bot) sendCategoryTrendChart(chatID int64, svc *service.FinanceService) {
+	now := svc.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, svc.Location()).AddDate(0, -11, 0)
+	trends, err := svc.GetCategoryTrends(start, 12, chartTrendLimit)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+	if len(trends) == 0 {
+		b.send(chatID, tgbotapi.NewMessage(chatID, "😔 За последние 12 месяцев нет расходов."))
+		return
+	}
+
+	img, err := b.reportGen.renderCategoryTrends(start, trends, monthShortName)
+	b.sendChart(chatID, img, err, fmt.Sprintf("📉 <b>Тренд категорий расходов</b>\n%s – %s",
+		monthNames[start.Month()-1]+" "+start.Format("2006"), monthNames[now.Month()-1]+" "+now.Format("2006")))
+}
+
+func dailyBalance(sums []repository.PeriodSum, start, end time.Time) ([]time.Time, []float64) {
+	byDay := make(map[string]float64)
+	for _, d := range sums {
+		byDay[d.Period] += d.Income - d.Expense
+	}
+
+	var days []time.Time
+	var balances []float64
+	var balance float64
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		balance += byDay[day.Format("2006-01-02")]
+		days = append(days, day)
+		balances = append(balances, balance)
+	}
+	return days, balances
+}
+
+func monthShortName(m time.Month) string {
+	return string([]rune(monthNames[m-1])[:3])
+}
+
+func chartAmountFormatter(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return fmt.Sprintf("%.0f", f)
+	}
+	return ""
+}
+
+func (rg *ReportGenerator) renderExpensePie(details map[string]float64) ([]byte, error) {
+	total := sum(details)
+	var values []chart.Value
+	var other float64
+	for i, name := range sortCategoriesByAmount(details) {
+		if i >= chartPieLimit-1 && len(details) > chartPieLimit {
+			other += details[name]
+			continue
+		}
+		values = append(values, chart.Value{
+			Value: details[name],
+			Label: fmt.Sprintf("%s %.0f%%", removeEmoji(name), details[name]/total*100),
+		})
+	}
+	if other > 0 {
+		values = append(values, chart.Value{Value: other, Label: fmt.Sprintf("Прочее %.0f%%", other/total*100)})
+	}
+
+	graph := chart.PieChart{
+		Width:  800,
+		Height: 800,
+		Values: values,
+	}
+
+	var buf bytes.Buffer
+	err := graph.Render(chart.PNG, &buf)
+	return buf.Bytes(), err
+}
+
+func (rg *ReportGenerator) renderBalanceLine(days []time.Time, balances []float64) ([]byte, error) {
+	if len(days) == 1 {
+		days = append(days, days[0].AddDate(0, 0, 1))
+		balances = append(balances, balances[0])
+	}
+
+	graph := chart.Chart{
+		Width:  1000,
+		Height: 500,
+		Background: chart.Style{
+			Padding: chart.Box{Top: 20, Left: 20, Right: 20, Bottom: 10},
+		},
+		XAxis: chart.XAxis{
+			ValueFormatter: func(v interface{}) string {
+				if f, ok := v.(float64); ok {
+					return time.Unix(0, int64(f)).Format("02.01")
+				}
+				return ""
+			},
+		},
+		YAxis: chart.YAxis{ValueFormatter: chartAmountFormatter},
+		Series: []chart.Series{
+			chart.TimeSeries{
+				XValues: days,
+				YValues: balances,
+				Style: chart.Style{
+					StrokeColor: drawing.ColorFromHex("70AD47"),
+					StrokeWidth: 3,
+					FillColor:   drawing.ColorFromHex("70AD47").WithAlpha(40),
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := graph.Render(chart.PNG, &buf)
+	return buf.Bytes(), err
+}
+
+func (rg *ReportGenerator) renderMonthlyBars(totals []service.MonthlyTotals, monthLabel func(time.Month) string) ([]byte, error) {
+	incomeStyle := chart.Style{FillColor: drawing.ColorFromHex("70AD47"), StrokeColor: drawing.ColorFromHex("70AD47")}
+	expenseStyle := chart.Style{FillColor: drawing.ColorFromHex("ED7D31"), StrokeColor: drawing.ColorFromHex("ED7D31")}
+
+	var bars []chart.Value
+	for _, t := range totals {
+		bars = append(bars,
+			chart.Value{Value: t.Income, Label: monthLabel(t.Month.Month()), Style: incomeStyle},
+			chart.Value{Value: t.Expense, Label: " ", Style: expenseStyle},
+		)
+	}
+
+	graph := chart.BarChart{
+		Width:    1200,
+		Height:   500,
+		BarWidth: 35,
+		Background: chart.Style{
+			Padding: chart.Box{Top: 30, Left: 10, Right: 10, Bottom: 40},
+		},
+		YAxis: chart.YAxis{ValueFormatter: chartAmountFormatter},
+		Bars:  bars,
+	}
+
+	var buf bytes.Buffer
+	err := graph.Render(chart.PNG, &buf)
+	return buf.Bytes(), err
+}
+
+func (rg *ReportGenerator) renderCategoryTrends(start time.Time, trends []service.CategoryTrend, monthLabel func(time.Month) string) ([]byte, error) {
+	months := len(trends[0].Values)
+	xs := make([]float64, months)
+	ticks := make([]chart.Tick, months)
+	for i := range xs {
+		xs[i] = float64(i)
+		ticks[i] = chart.Tick{Value: float64(i), Label: monthLabel(start.AddDate(0, i, 0).Month())}
+	}
+
+	var series []chart.Series
+	for i, t := range trends {
+		c := chart.GetDefaultColor(i)
+		series = append(series, chart.ContinuousSeries{
+			Name:    removeEmoji(t.Name),
+			XValues: xs,
+			YValues: t.Values,
+			Style:   chart.Style{StrokeColor: c, StrokeWidth: 3, DotColor: c, DotWidth: 4},
+		})
+	}
+
+	graph := chart.Chart{
+		Width:  1000,
+		Height: 500,
+		Background: chart.Style{
+			Padding: chart.Box{Top: 20, Left: 20, Right: 20, Bottom: 10},
+		},
+		XAxis:  chart.XAxis{Ticks: ticks},
+		YAxis:  chart.YAxis{ValueFormatter: chartAmountFormatter},
+		Series: series,
+	}
+	graph.Elements = []chart.Renderable{chart.LegendLeft(&graph)}
+
+	var buf bytes.Buffer
+	err := graph.Render(chart.PNG, &buf)
+	return buf.Bytes(), err
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	CallbackCompareMonth = "compare_month"
+	CallbackCompareYear  = "compare_year"
+
+	comparisonCategoryLimit = 12
+	comparisonMoversLimit   = 3
+)
+
+func (b *Bot) showPeriodComparison(chatID int64, yearAgo bool, svc *service.FinanceService) {
+	user, err := b.repo.GetOrCreateUser(chatID, "", "", "")
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	now := svc.Now()
+	start, _ := monthPeriod(now, user.PeriodStartDay)
+
+	title := "этот месяц и прошлый"
+	prevStart, prevEnd := start.AddDate(0, -1, 0), now.AddDate(0, -1, 0)
+	if yearAgo {
+		title = "этот месяц и год назад"
+		prevStart, prevEnd = start.AddDate(-1, 0, 0), now.AddDate(-1, 0, 0)
+	}
+	if periodEnd := prevStart.AddDate(0, 1, 0); prevEnd.After(periodEnd) {
+		prevEnd = periodEnd
+	}
+
+	cmp, err := svc.ComparePeriods(start, now, prevStart, prevEnd)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("🔄 <b>Сравнение: %s</b>\n", title))
+	text.WriteString(fmt.Sprintf("<i>%s – %s и %s – %s</i>\n\n",
+		start.Format("02.01.2006"), now.Format("02.01.2006"),
+		prevStart.Format("02.01.2006"), prevEnd.Format("02.01.2006")))
+
+	text.WriteString(fmt.Sprintf("📈 <b>Доходы:</b> %s (было %s) %s\n",
+		b.formatCurrency(cmp.CurrentIncome, chatID), b.formatCurrency(cmp.PreviousIncome, chatID),
+		b.formatDelta(cmp.CurrentIncome, cmp.PreviousIncome, chatID)))
+	text.WriteString(fmt.Sprintf("📉 <b>Расходы:</b> %s (было %s) %s\n",
+		b.formatCurrency(cmp.CurrentExpense, chatID), b.formatCurrency(cmp.PreviousExpense, chatID),
+		b.formatDelta(cmp.CurrentExpense, cmp.PreviousExpense, chatID)))
+	curBalance, prevBalance := cmp.CurrentIncome-cmp.CurrentExpense, cmp.PreviousIncome-cmp.PreviousExpense
+	text.WriteString(fmt.Sprintf("💵 <b>Баланс:</b> %s (было %s)\n",
+		b.formatCurrency(curBalance, chatID), b.formatCurrency(prevBalance, chatID)))
+
+	b.writeCategoryDeltas(&text, cmp, "expense", "\n📂 <b>Расходы по категориям:</b>\n", chatID)
+	b.writeCategoryDeltas(&text, cmp, "income", "\n💼 <b>Доходы по категориям:</b>\n", chatID)
+
+	if movers := cmp.Movers("expense", true, comparisonMoversLimit); len(movers) > 0 {
+		text.WriteString("\n🔺 <b>Больше всего выросли:</b>\n")
+		for _, d := range movers {
+			text.WriteString(fmt.Sprintf("┣ %s %s\n", html.EscapeString(d.Name), b.formatDelta(d.Current, d.Previous, chatID)))
+		}
+	}
+	if movers := cmp.Movers("expense", false, comparisonMoversLimit); len(movers) > 0 {
+		text.WriteString("\n🔻 <b>Больше всего снизились:</b>\n")
+		for _, d := range movers {
+			text.WriteString(fmt.Sprintf("┣ %s %s\n", html.EscapeString(d.Name), b.formatDelta(d.Current, d.Previous, chatID)))
+		}
+	}
+
+	other, otherLabel := CallbackCompareYear, "📆 С прошлым годом"
+	if yearAgo {
+		other, otherLabel = CallbackCompareMonth, "📅 С прошлым месяцем"
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(otherLabel, other)),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", "stats_back")),
+	)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) writeCategoryDeltas(text *strings.Builder, cmp *service.PeriodComparison, typ, header string, chatID int64) {
+	count := 0
+	for _, d := range cmp.Categories {
+		if d.Type != typ {
+			continue
+		}
+		if count == 0 {
+			text.WriteString(header)
+		}
+		if count == comparisonCategoryLimit {
+			text.WriteString("┗ …\n")
+			break
+		}
+		text.WriteString(fmt.Sprintf("┣ %s: %s %s\n", html.EscapeString(d.Name), b.formatCurrency(d.Current, chatID), b.formatDelta(d.Current, d.Previous, chatID)))
+		count++
+	}
+}
+
+func (b *Bot) formatDelta(current, previous float64, chatID int64) string {
+	delta := current - previous
+	switch {
+	case math.Abs(delta) < 0.005:
+		return "= без изменений"
+	case previous == 0:
+		return fmt.Sprintf("▲ +%s (новое)", b.formatCurrency(delta, chatID))
+	case delta > 0:
+		return fmt.Sprintf("▲ +%s (+%.1f%%)", b.formatCurrency(delta, chatID), delta/previous*100)
+	default:
+		return fmt.Sprintf("▼ −%s (%.1f%%)", b.formatCurrency(-delta, chatID), delta/previous*100)
+	}
+}
+
+func pdfDelta(d service.CategoryDelta, loc reportLocale) string {
+	percent, ok := d.Percent()
+	if !ok {
+		return loc.text("cmp_new")
+	}
+	return fmt.Sprintf("%+.1f%%", percent)
+}
+
+func (rg *ReportGenerator) addComparisonSection(pdf *gofpdf.Fpdf, start, end time.Time, svc *service.FinanceService, loc reportLocale) error {
+	prevStart, prevEnd := service.PreviousPeriod(start, end)
+	comparisons := []struct {
+		title      string
+		start, end time.Time
+	}{
+		{loc.text("cmp_previous"), prevStart, prevEnd},
+		{loc.text("cmp_year"), start.AddDate(-1, 0, 0), end.AddDate(-1, 0, 0)},
+	}
+
+	for _, c := range comparisons {
+		cmp, err := svc.ComparePeriods(start, end, c.start, c.end)
+		if err != nil {
+			return err
+		}
+
+		pdf.Ln(10)
+		pdf.SetFont("DejaVuSans", "B", 14)
+		pdf.CellFormat(190, 10, c.title, "", 1, "L", false, 0, "")
+		pdf.SetFont("DejaVuSans", "", 11)
+		pdf.CellFormat(190, 6, loc.textf("cmp_base", loc.date(c.start), loc.date(c.end)), "", 1, "L", false, 0, "")
+
+		if cmp.PreviousIncome == 0 && cmp.PreviousExpense == 0 {
+			pdf.CellFormat(190, 6, loc.text("cmp_no_data"), "", 1, "L", false, 0, "")
+			continue
+		}
+
+		pdf.CellFormat(190, 6, loc.textf("cmp_income", loc.money(cmp.CurrentIncome), loc.money(cmp.PreviousIncome), loc.signedMoney(cmp.CurrentIncome-cmp.PreviousIncome)), "", 1, "L", false, 0, "")
+		pdf.CellFormat(190, 6, loc.textf("cmp_expense", loc.money(cmp.CurrentExpense), loc.money(cmp.PreviousExpense), loc.signedMoney(cmp.CurrentExpense-cmp.PreviousExpense)), "", 1, "L", false, 0, "")
+		pdf.Ln(3)
+
+		pdf.SetFont("DejaVuSans", "B", 10)
+		widths := []float64{70, 30, 30, 35, 25}
+		for i, h := range []string{loc.text("cmp_category"), loc.text("cmp_now"), loc.text("cmp_before"), loc.text("cmp_diff"), "%"} {
+			pdf.CellFormat(widths[i], 7, h, "1", 0, "C", false, 0, "")
+		}
+		pdf.Ln(-1)
+
+		pdf.SetFont("DejaVuSans", "", 10)
+		rows := 0
+		for _, d := range cmp.Categories {
+			if d.Type != "expense" || rows == comparisonCategoryLimit {
+				continue
+			}
+			pdf.CellFormat(widths[0], 6, removeEmoji(d.Name), "1", 0, "L", false, 0, "")
+			pdf.CellFormat(widths[1], 6, loc.money(d.Current), "1", 0, "R", false, 0, "")
+			pdf.CellFormat(widths[2], 6, loc.money(d.Previous), "1", 0, "R", false, 0, "")
+			pdf.CellFormat(widths[3], 6, loc.signedMoney(d.Delta()), "1", 0, "R", false, 0, "")
+			pdf.CellFormat(widths[4], 6, pdfDelta(d, loc), "1", 1, "R", false, 0, "")
+			rows++
+		}
+
+		var lines []string
+		for _, d := range cmp.Movers("expense", true, comparisonMoversLimit) {
+			lines = append(lines, loc.textf("cmp_growth", removeEmoji(d.Name), loc.signedMoney(d.Delta()), pdfDelta(d, loc)))
+		}
+		for _, d := range cmp.Movers("expense", false, comparisonMoversLimit) {
+			lines = append(lines, loc.textf("cmp_decline", removeEmoji(d.Name), loc.signedMoney(d.Delta()), pdfDelta(d, loc)))
+		}
+		if len(lines) > 0 {
+			pdf.Ln(3)
+			pdf.MultiCell(190, 6, strings.Join(lines, "\n"), "", "L", false)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,442 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/IlyaMakar/finance_bot/internal/logger"
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+	"github.com/IlyaMakar/finance_bot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CallbackCreditCards     = "credit_cards"
+	CallbackCreditCardNew   = "ccard_new"
+	CallbackCreditCardView  = "ccard_view_"
+	CallbackCreditCardPay   = "ccard_pay_"
+	CallbackCreditCardGrace = "ccard_grace_"
+	CallbackCreditCardDel   = "ccard_delete_"
+	CallbackPayDebit        = "pay_method_card"
+	CallbackPayCredit       = "pay_method_credit_"
+)
+
+var creditCardReminderDays = []int{1, 3}
+
+func (b *Bot) handleCreditCardCallback(chatID int64, messageID int, data string, from *tgbotapi.User, svc *service.FinanceService) bool {
+	switch {
+	case data == CallbackCreditCards:
+		b.deleteMessage(chatID, messageID)
+		b.showCreditCards(chatID, svc)
+
+	case data == CallbackCreditCardNew:
+		userStates[chatID] = UserState{Step: "enter_card_name"}
+		b.deleteMessage(chatID, messageID)
+		b.send(chatID, tgbotapi.NewMessage(chatID, "💳 Введите название кредитной карты:"))
+
+	case strings.HasPrefix(data, CallbackCreditCardView):
+		cardID, _ := strconv.Atoi(data[len(CallbackCreditCardView):])
+		b.deleteMessage(chatID, messageID)
+		b.showCreditCard(chatID, cardID, svc)
+
+	case strings.HasPrefix(data, CallbackCreditCardPay):
+		cardID, _ := strconv.Atoi(data[len(CallbackCreditCardPay):])
+		userStates[chatID] = UserState{Step: "enter_card_payment", TempCategoryID: cardID}
+		b.send(chatID, tgbotapi.NewMessage(chatID, "💸 Введите сумму платежа по карте:"))
+
+	case strings.HasPrefix(data, CallbackCreditCardGrace):
+		cardID, _ := strconv.Atoi(data[len(CallbackCreditCardGrace):])
+		card, err := svc.GetCreditCardByID(cardID)
+		if err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		status, err := svc.GetCreditCardStatus(card, svc.Now())
+		if err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		b.deleteMessage(chatID, messageID)
+		b.repayCreditCard(chatID, cardID, status.GraceAmount, svc)
+
+	case strings.HasPrefix(data, CallbackCreditCardDel):
+		cardID, _ := strconv.Atoi(data[len(CallbackCreditCardDel):])
+		if err := svc.DeleteCreditCard(cardID); err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		b.deleteMessage(chatID, messageID)
+		b.send(chatID, tgbotapi.NewMessage(chatID, "🗑 Карта удалена"))
+		b.showCreditCards(chatID, svc)
+
+	case data == CallbackPayDebit || strings.HasPrefix(data, CallbackPayCredit):
+		state, ok := userStates[chatID]
+		if !ok || state.Step != "choose_payment_method" {
+			b.sendError(chatID, fmt.Errorf("операция не найдена, начните заново"))
+			return true
+		}
+		cardID := 0
+		if data != CallbackPayDebit {
+			cardID, _ = strconv.Atoi(data[len(CallbackPayCredit):])
+		}
+		b.deleteMessage(chatID, messageID)
+		b.saveTransaction(chatID, from.ID, state, cardID, svc)
+
+	default:
+		return false
+	}
+	return true
+}
+
+func (b *Bot) askPaymentMethod(chatID int64, cards []repository.CreditCard) {
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("💳 Дебетовая карта / наличные", CallbackPayDebit),
+		),
+	}
+	for _, card := range cards {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🟥 "+card.Name, fmt.Sprintf("%s%d", CallbackPayCredit, card.ID)),
+		))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, "💳 Чем оплачено?")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) showCreditCards(chatID int64, svc *service.FinanceService) {
+	cards, err := svc.GetCreditCards()
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString("💳 <b>Кредитные карты</b>\n\n")
+	if len(cards) == 0 {
+		text.WriteString("Добавьте кредитную карту, чтобы следить за выпиской и не терять льготный период.")
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	now := svc.Now()
+	for i := range cards {
+		card := cards[i]
+		status, err := svc.GetCreditCardStatus(&card, now)
+		if err != nil {
+			b.sendError(chatID, err)
+			return
+		}
+
+		text.WriteString(fmt.Sprintf("<b>%s</b>\n", html.EscapeString(card.Name)))
+		text.WriteString(fmt.Sprintf("┣ Задолженность: %s из %s\n",
+			b.formatCurrency(status.Debt, chatID), b.formatCurrency(card.Limit, chatID)))
+		switch {
+		case status.GraceExpired:
+			text.WriteString(fmt.Sprintf("┗ ⚠️ Льготный период истёк, к оплате %s\n\n", b.formatCurrency(status.GraceAmount, chatID)))
+		case status.GraceAmount > 0:
+			text.WriteString(fmt.Sprintf("┗ Внести %s до %s\n\n", b.formatCurrency(status.GraceAmount, chatID), status.DueDate.Format("02.01.2006")))
+		default:
+			text.WriteString("┗ ✅ Льготный период сохранён\n\n")
+		}
+
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🟥 "+card.Name, fmt.Sprintf("%s%d", CallbackCreditCardView, card.ID)),
+		))
+	}
+
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("➕ Добавить карту", CallbackCreditCardNew),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", CallbackLoans),
+		),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) showCreditCard(chatID int64, cardID int, svc *service.FinanceService) {
+	card, err := svc.GetCreditCardByID(cardID)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	status, err := svc.GetCreditCardStatus(card, svc.Now())
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("💳 <b>%s</b>\n\n", html.EscapeString(card.Name)))
+	text.WriteString(fmt.Sprintf("┣ Лимит: %s\n", b.formatCurrency(card.Limit, chatID)))
+	text.WriteString(fmt.Sprintf("┣ Задолженность: %s\n", b.formatCurrency(status.Debt, chatID)))
+	text.WriteString(fmt.Sprintf("┣ Доступно: %s\n", b.formatCurrency(status.Available, chatID)))
+	text.WriteString(fmt.Sprintf("┣ Выписка: %d-го числа, льготный период %d дн.\n", card.StatementDay, card.GraceDays))
+	text.WriteString(fmt.Sprintf("┗ Мин. платёж: %.1f%%", card.MinPaymentPercent))
+	if card.MinPaymentFixed > 0 {
+		text.WriteString(fmt.Sprintf(", не меньше %s", b.formatCurrency(card.MinPaymentFixed, chatID)))
+	}
+	text.WriteString("\n")
+
+	text.WriteString(fmt.Sprintf("\n🧾 <b>Выписка от %s</b>\n", status.StatementDate.Format("02.01.2006")))
+	text.WriteString(fmt.Sprintf("┣ Сумма выписки: %s\n", b.formatCurrency(status.StatementBalance, chatID)))
+	text.WriteString(fmt.Sprintf("┣ Оплачено после выписки: %s\n", b.formatCurrency(status.PaidSinceStatement, chatID)))
+	if len(status.Statements) > 1 {
+		text.WriteString("┣ Неоплаченные выписки:\n")
+		for _, st := range status.Statements {
+			text.WriteString(fmt.Sprintf("┃  • от %s: %s до %s\n", st.Date.Format("02.01.2006"),
+				b.formatCurrency(st.Unpaid, chatID), st.DueDate.Format("02.01.2006")))
+		}
+	}
+	switch {
+	case status.GraceExpired:
+		text.WriteString(fmt.Sprintf("┗ ⚠️ Льготный период истёк %s. Внесите %s, чтобы остановить начисление процентов\n",
+			status.DueDate.Format("02.01.2006"), b.formatCurrency(status.GraceAmount, chatID)))
+	case status.GraceAmount > 0:
+		text.WriteString(fmt.Sprintf("┣ Без процентов: внести %s до %s\n",
+			b.formatCurrency(status.GraceAmount, chatID), status.DueDate.Format("02.01.2006")))
+		text.WriteString(fmt.Sprintf("┗ Минимальный платёж: %s\n", b.formatCurrency(status.MinPayment, chatID)))
+	default:
+		text.WriteString("┗ ✅ Выписка погашена, проценты не начисляются\n")
+	}
+
+	text.WriteString(fmt.Sprintf("\n🛒 Покупки после выписки: %s (войдут в выписку %s)",
+		b.formatCurrency(status.Unbilled, chatID), status.NextStatementDate.Format("02.01.2006")))
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if status.GraceAmount > 0 {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("✅ Внести %s", b.formatCurrency(status.GraceAmount, chatID)),
+				fmt.Sprintf("%s%d", CallbackCreditCardGrace, card.ID)),
+		))
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("💸 Внести платёж", fmt.Sprintf("%s%d", CallbackCreditCardPay, card.ID)),
+			tgbotapi.NewInlineKeyboardButtonData("🗑 Удалить", fmt.Sprintf("%s%d", CallbackCreditCardDel, card.ID)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", CallbackCreditCards),
+		),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) repayCreditCard(chatID int64, cardID int, amount float64, svc *service.FinanceService) {
+	if err := svc.RepayCreditCard(cardID, amount); err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	b.send(chatID, tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Платёж по карте %s записан", b.formatCurrency(amount, chatID))))
+	b.showCreditCard(chatID, cardID, svc)
+}
+
+func (b *Bot) handleCardName(m *tgbotapi.Message) {
+	name := strings.TrimSpace(m.Text)
+	if name == "" {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Название не может быть пустым. Попробуйте снова:"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	state.Step = "enter_card_limit"
+	state.TempComment = name
+	userStates[m.From.ID] = state
+
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "💰 Введите кредитный лимит:"))
+}
+
+func (b *Bot) handleCardLimit(m *tgbotapi.Message) {
+	limit, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(m.Text), ",", "."), 64)
+	if err != nil || limit <= 0 {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите корректную сумму (например, 150000):"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	state.Step = "enter_card_statement_day"
+	state.TempAmount = limit
+	userStates[m.From.ID] = state
+
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "🧾 Введите день формирования выписки (1–28):"))
+}
+
+func (b *Bot) handleCardStatementDay(m *tgbotapi.Message) {
+	day, err := strconv.Atoi(strings.TrimSpace(m.Text))
+	if err != nil || day < 1 || day > 28 {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите число от 1 до 28:"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	state.Step = "enter_card_grace"
+	state.TempTerm = day
+	userStates[m.From.ID] = state
+
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⏳ Сколько дней после выписки можно погасить долг без процентов? (например, 25):"))
+}
+
+func (b *Bot) handleCardGrace(m *tgbotapi.Message) {
+	days, err := strconv.Atoi(strings.TrimSpace(m.Text))
+	if err != nil || days <= 0 || days > 120 {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите число дней от 1 до 120:"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	state.Step = "enter_card_min_payment"
+	state.TempDays = days
+	userStates[m.From.ID] = state
+
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID,
+		"📉 Введите минимальный платёж: процент от выписки и, при необходимости, минимальную сумму.\nНапример: «5» или «3 300»"))
+}
+
+func (b *Bot) handleCardMinPayment(m *tgbotapi.Message, svc *service.FinanceService) {
+	fields := strings.Fields(strings.ReplaceAll(strings.TrimSuffix(strings.TrimSpace(m.Text), "%"), ",", "."))
+	var percent, fixed float64
+	var err error
+	if len(fields) == 0 || len(fields) > 2 {
+		err = fmt.Errorf("неверный формат")
+	} else {
+		percent, err = strconv.ParseFloat(strings.TrimSuffix(fields[0], "%"), 64)
+		if err == nil && len(fields) == 2 {
+			fixed, err = strconv.ParseFloat(fields[1], 64)
+		}
+	}
+	if err != nil {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите процент и, при необходимости, сумму, например «5» или «3 300»:"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	id, err := svc.CreateCreditCard(state.TempComment, state.TempAmount, state.TempTerm, state.TempDays, percent, fixed)
+	if err != nil {
+		b.sendError(m.Chat.ID, err)
+		return
+	}
+
+	delete(userStates, m.From.ID)
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "✅ Кредитная карта добавлена! Выбирайте её при добавлении расходов."))
+	b.showCreditCard(m.Chat.ID, id, svc)
+}
+
+func (b *Bot) handleCardPayment(m *tgbotapi.Message, svc *service.FinanceService) {
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(m.Text), ",", "."), 64)
+	if err != nil || amount <= 0 {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите корректную сумму (например, 15000):"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	delete(userStates, m.From.ID)
+	b.repayCreditCard(m.Chat.ID, state.TempCategoryID, amount, svc)
+}
+
+func (b *Bot) creditCardNotice(chatID int64, cardID int, svc *service.FinanceService) string {
+	card, err := svc.GetCreditCardByID(cardID)
+	if err != nil {
+		return ""
+	}
+
+	status, err := svc.GetCreditCardStatus(card, svc.Now())
+	if err != nil {
+		return ""
+	}
+
+	notice := fmt.Sprintf("\n💳 %s: доступно %s", card.Name, b.formatCurrency(status.Available, chatID))
+	if status.Available < 0 {
+		notice += " ⚠️ превышен лимит"
+	}
+	return notice
+}
+
+func (b *Bot) loadCreditCards(svc *service.FinanceService) []repository.CreditCard {
+	cards, err := svc.GetCreditCards()
+	if err != nil {
+		logger.Error("Failed to get credit cards", "error", err)
+		return nil
+	}
+	return cards
+}
+
+func (b *Bot) SendCreditCardReminders() {
+	users, err := b.repo.GetAllUsers()
+	if err != nil {
+		logger.Error("Credit card reminder error getting users", "error", err)
+		return
+	}
+
+	sent := 0
+	for i := range users {
+		user := users[i]
+		if !user.CanNotify() {
+			continue
+		}
+
+		svc := service.NewService(b.repo, &user)
+		now := svc.Now()
+		if svc.IsHouseholdGuest() {
+			continue
+		}
+		for _, card := range b.loadCreditCards(svc) {
+			status, err := svc.GetCreditCardStatus(&card, now)
+			if err != nil {
+				logger.Error("Credit card reminder status error", "card_id", card.ID, "error", err)
+				continue
+			}
+			if status.GraceAmount <= 0 || status.GraceExpired {
+				continue
+			}
+
+			daysLeft := int(status.DueDate.AddDate(0, 0, 1).Sub(now).Hours() / 24)
+			due := false
+			for _, days := range creditCardReminderDays {
+				remindFrom := status.DueDate.AddDate(0, 0, -days)
+				if daysLeft <= days && (card.LastReminderAt == nil || card.LastReminderAt.Before(remindFrom)) {
+					due = true
+					break
+				}
+			}
+			if !due {
+				continue
+			}
+
+			msg := tgbotapi.NewMessage(user.TelegramID, fmt.Sprintf(
+				"💳 <b>Льготный период по карте «%s» заканчивается %s</b>\n\n"+
+					"Чтобы не платить проценты, внесите %s до %s.\n"+
+					"Минимальный платёж: %s",
+				html.EscapeString(card.Name), daysLeftLabel(daysLeft),
+				b.formatCurrency(status.GraceAmount, user.TelegramID), status.DueDate.Format("02.01.2006"),
+				b.formatCurrency(status.MinPayment, user.TelegramID)))
+			msg.ParseMode = "HTML"
+			msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+				tgbotapi.NewInlineKeyboardRow(
+					tgbotapi.NewInlineKeyboardButtonData("💳 Открыть карту", fmt.Sprintf("%s%d", CallbackCreditCardView, card.ID)),
+				),
+			)
+			b.SendMessage(msg)
+
+			if err := svc.MarkCreditCardReminded(card.ID, now); err != nil {
+				logger.Error("Failed to mark credit card reminded", "card_id", card.ID, "error", err)
+			}
+			sent++
+		}
+	}
+
+	logger.Info("Credit card reminders sent", "count", sent)
+}
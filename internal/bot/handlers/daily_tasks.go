@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/logger"
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+	"github.com/IlyaMakar/finance_bot/internal/scheduler"
+)
+
+const dailyTaskHour = 12
+
+type dailyTask struct {
+	name string
+	run  func()
+}
+
+func (b *Bot) dailyTasks() []dailyTask {
+	return []dailyTask{
+		{"saving_rules", b.RunScheduledSavingRules},
+		{"interest_accrual", b.AccrueSavingsInterest},
+		{"savings_reminders", b.SendSavingsReminders},
+		{"debt_reminders", b.SendDebtReminders},
+		{"credit_card_reminders", b.SendCreditCardReminders},
+		{"net_worth_snapshots", b.TakeNetWorthSnapshots},
+		{"anomaly_alerts", b.SendAnomalyAlerts},
+	}
+}
+
+func (b *Bot) dailyPlanner(loc *time.Location, testMode bool) scheduler.Handler {
+	return func(job repository.Job) (string, error) {
+		now := time.Now()
+		local := now.In(loc)
+
+		key := local.Format("2006-01-02")
+		if testMode {
+			key = local.Format("2006-01-02T15:04")
+		} else if local.Hour() < dailyTaskHour {
+			return "skipped: before task hour", nil
+		}
+
+		planned := 0
+		for _, task := range b.dailyTasks() {
+			added, err := b.jobs.Enqueue(JobDailyTask, task.name+":"+key, task.name, now)
+			if err != nil {
+				logger.Error("Daily task enqueue error", "task", task.name, "error", err)
+				continue
+			}
+			if added {
+				planned++
+			}
+		}
+		return fmt.Sprintf("planned %d daily tasks", planned), nil
+	}
+}
+
+func (b *Bot) runDailyTask(job repository.Job) (string, error) {
+	for _, task := range b.dailyTasks() {
+		if task.name == job.Payload {
+			logger.Debug("Running daily task", "task", task.name)
+			task.run()
+			return "done", nil
+		}
+	}
+	return "", fmt.Errorf("unknown daily task %q", job.Payload)
+}
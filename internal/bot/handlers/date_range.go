@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CallbackStatsCustom = "stats_custom"
+	CallbackRangeMonth  = "range_month_"
+	CallbackRangeDay    = "range_day_"
+	CallbackRangeNoop   = "range_noop"
+)
+
+var monthNames = [...]string{
+	"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь",
+	"Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь",
+}
+
+var weekdayShortNames = [...]string{"Пн", "Вт", "Ср", "Чт", "Пт", "Сб", "Вс"}
+
+func (b *Bot) handleDateRangeCallback(chatID int64, messageID int, data string, svc *service.FinanceService) bool {
+	switch {
+	case data == CallbackStatsCustom:
+		userStates[chatID] = UserState{Step: "enter_range_start"}
+		b.deleteMessage(chatID, messageID)
+
+		msg := tgbotapi.NewMessage(chatID, "🗓 <b>Произвольный период</b>\n\n"+
+			"Выберите дату начала в календаре или введите её в формате ДД.ММ.ГГГГ.\n"+
+			"Можно сразу весь период: <code>01.09.2026 - 15.09.2026</code>")
+		msg.ParseMode = tgbotapi.ModeHTML
+		msg.ReplyMarkup = calendarKeyboard(svc.Now())
+		b.send(chatID, msg)
+
+	case data == CallbackRangeNoop:
+
+	case strings.HasPrefix(data, CallbackRangeMonth):
+		month, err := time.ParseInLocation("2006-01", data[len(CallbackRangeMonth):], svc.Location())
+		if err != nil {
+			return true
+		}
+		b.send(chatID, tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, calendarKeyboard(month)))
+
+	case strings.HasPrefix(data, CallbackRangeDay):
+		day, err := time.ParseInLocation("2006-01-02", data[len(CallbackRangeDay):], svc.Location())
+		if err != nil {
+			return true
+		}
+
+		state := userStates[chatID]
+		if state.Step != "enter_range_end" {
+			userStates[chatID] = UserState{Step: "enter_range_end", TempDate: day}
+			edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, fmt.Sprintf(
+				"🗓 Начало: <b>%s</b>\n\nТеперь выберите или введите дату окончания:", day.Format("02.01.2006")),
+				calendarKeyboard(day))
+			edit.ParseMode = tgbotapi.ModeHTML
+			b.send(chatID, edit)
+			return true
+		}
+
+		delete(userStates, chatID)
+		b.deleteMessage(chatID, messageID)
+		b.showDateRangeReport(chatID, state.TempDate, day, svc)
+
+	default:
+		return false
+	}
+	return true
+}
+
+func calendarKeyboard(month time.Time) tgbotapi.InlineKeyboardMarkup {
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	prev, next := first.AddDate(0, -1, 0), first.AddDate(0, 1, 0)
+
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("◀️", CallbackRangeMonth+prev.Format("2006-01")),
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%s %d", monthNames[first.Month()-1], first.Year()), CallbackRangeNoop),
+			tgbotapi.NewInlineKeyboardButtonData("▶️", CallbackRangeMonth+next.Format("2006-01")),
+		),
+	}
+
+	var header []tgbotapi.InlineKeyboardButton
+	for _, d := range weekdayShortNames {
+		header = append(header, tgbotapi.NewInlineKeyboardButtonData(d, CallbackRangeNoop))
+	}
+	rows = append(rows, header)
+
+	offset := (int(first.Weekday()) + 6) % 7
+	var week []tgbotapi.InlineKeyboardButton
+	for i := 0; i < offset; i++ {
+		week = append(week, tgbotapi.NewInlineKeyboardButtonData(" ", CallbackRangeNoop))
+	}
+	for day := first; day.Before(next); day = day.AddDate(0, 0, 1) {
+		week = append(week, tgbotapi.NewInlineKeyboardButtonData(
+			fmt.Sprintf("%d", day.Day()), CallbackRangeDay+day.Format("2006-01-02")))
+		if len(week) == 7 {
+			rows = append(rows, week)
+			week = nil
+		}
+	}
+	if len(week) > 0 {
+		for len(week) < 7 {
+			week = append(week, tgbotapi.NewInlineKeyboardButtonData(" ", CallbackRangeNoop))
+		}
+		rows = append(rows, week)
+	}
+
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", "stats_back")))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func (b *Bot) handleDateRangeInput(m *tgbotapi.Message, svc *service.FinanceService) {
+	state := userStates[m.From.ID]
+	text := strings.TrimSpace(m.Text)
+
+	if parts := strings.FieldsFunc(text, func(r rune) bool { return r == '-' || r == '–' || r == '—' }); len(parts) == 2 {
+		first, err1 := parseUserDate(parts[0], svc.Location())
+		last, err2 := parseUserDate(parts[1], svc.Location())
+		if err1 != nil || err2 != nil {
+			b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Неверный формат. Пример: 01.09.2026 - 15.09.2026"))
+			return
+		}
+		delete(userStates, m.From.ID)
+		b.showDateRangeReport(m.Chat.ID, first, last, svc)
+		return
+	}
+
+	day, err := parseUserDate(text, svc.Location())
+	if err != nil {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Неверный формат даты. Введите ДД.ММ.ГГГГ:"))
+		return
+	}
+
+	if state.Step == "enter_range_start" {
+		userStates[m.From.ID] = UserState{Step: "enter_range_end", TempDate: day}
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf(
+			"🗓 Начало: <b>%s</b>\n\nТеперь выберите или введите дату окончания:", day.Format("02.01.2006")))
+		msg.ParseMode = tgbotapi.ModeHTML
+		msg.ReplyMarkup = calendarKeyboard(day)
+		b.send(m.Chat.ID, msg)
+		return
+	}
+
+	delete(userStates, m.From.ID)
+	b.showDateRangeReport(m.Chat.ID, state.TempDate, day, svc)
+}
+
+func rangeLabel(first, last time.Time) string {
+	if first.Equal(last) {
+		return first.Format("02.01.2006")
+	}
+	return fmt.Sprintf("период %s – %s", first.Format("02.01.2006"), last.Format("02.01.2006"))
+}
+
+func (b *Bot) showDateRangeReport(chatID int64, first, last time.Time, svc *service.FinanceService) {
+	if last.Before(first) {
+		first, last = last, first
+	}
+	b.generatePeriodReport(chatID, svc, first, last.AddDate(0, 0, 1), rangeLabel(first, last))
+}
@@ -0,0 +1,564 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/logger"
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+	"github.com/IlyaMakar/finance_bot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CallbackDebts         = "debts"
+	CallbackDebtsClosed   = "debts_closed"
+	CallbackDebtNew       = "debt_new_"
+	CallbackDebtView      = "debt_view_"
+	CallbackDebtRepay     = "debt_repay_"
+	CallbackDebtRepayFull = "debt_full_"
+	CallbackDebtDelete    = "debt_delete_"
+	CallbackDebtLink      = "debt_link_"
+	CallbackDebtSkipDue   = "debt_skip_due"
+
+	debtReminderDaysBefore = 3
+)
+
+var currencyAliases = map[string]string{
+	"₽":    CurrencyRUB,
+	"р":    CurrencyRUB,
+	"руб":  CurrencyRUB,
+	"rub":  CurrencyRUB,
+	"$":    CurrencyUSD,
+	"usd":  CurrencyUSD,
+	"€":    CurrencyEUR,
+	"eur":  CurrencyEUR,
+	"евро": CurrencyEUR,
+}
+
+func (b *Bot) handleDebtCallback(chatID int64, messageID int, data string, svc *service.FinanceService) bool {
+	switch {
+	case data == CallbackDebts:
+		b.deleteMessage(chatID, messageID)
+		b.showDebts(chatID, svc)
+
+	case data == CallbackDebtsClosed:
+		b.deleteMessage(chatID, messageID)
+		b.showClosedDebts(chatID, svc)
+
+	case data == CallbackDebtSkipDue:
+		editMsg := tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, tgbotapi.InlineKeyboardMarkup{})
+		b.bot.Send(editMsg)
+		b.finishCreateDebt(chatID, nil, svc)
+
+	case strings.HasPrefix(data, CallbackDebtNew):
+		direction := data[len(CallbackDebtNew):]
+		userStates[chatID] = UserState{Step: "enter_debt_name", TempType: direction}
+
+		text := "👤 Кому вы дали в долг? Введите имя:"
+		if direction == repository.DebtBorrowed {
+			text = "👤 У кого вы взяли в долг? Введите имя:"
+		}
+		b.deleteMessage(chatID, messageID)
+		b.send(chatID, tgbotapi.NewMessage(chatID, text))
+
+	case strings.HasPrefix(data, CallbackDebtView):
+		debtID, _ := strconv.Atoi(data[len(CallbackDebtView):])
+		b.deleteMessage(chatID, messageID)
+		b.showDebt(chatID, debtID, svc)
+
+	case strings.HasPrefix(data, CallbackDebtRepayFull):
+		debtID, _ := strconv.Atoi(data[len(CallbackDebtRepayFull):])
+		debt, err := svc.GetDebtByID(debtID)
+		if err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		b.deleteMessage(chatID, messageID)
+		b.askDebtLink(chatID, debt, debt.Remaining(), svc)
+
+	case strings.HasPrefix(data, CallbackDebtRepay):
+		debtID, _ := strconv.Atoi(data[len(CallbackDebtRepay):])
+		userStates[chatID] = UserState{Step: "enter_debt_repay", TempCategoryID: debtID}
+		b.send(chatID, tgbotapi.NewMessage(chatID, "💵 Введите сумму погашения:"))
+
+	case strings.HasPrefix(data, CallbackDebtLink):
+		state := userStates[chatID]
+		delete(userStates, chatID)
+		b.deleteMessage(chatID, messageID)
+		b.repayDebt(chatID, state.TempCategoryID, state.TempAmount, data[len(CallbackDebtLink):] == "yes", svc)
+
+	case strings.HasPrefix(data, CallbackDebtDelete):
+		debtID, _ := strconv.Atoi(data[len(CallbackDebtDelete):])
+		if err := svc.DeleteDebt(debtID); err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		b.deleteMessage(chatID, messageID)
+		b.send(chatID, tgbotapi.NewMessage(chatID, "🗑 Долг удалён"))
+		b.showDebts(chatID, svc)
+
+	default:
+		return false
+	}
+	return true
+}
+
+func debtDirectionIcon(direction string) string {
+	if direction == repository.DebtBorrowed {
+		return "🔻"
+	}
+	return "🔺"
+}
+
+func (b *Bot) showDebts(chatID int64, svc *service.FinanceService) {
+	balances, err := svc.GetDebtBalances()
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	debts, err := svc.GetDebts(false)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString("🤝 <b>Долги</b>\n\n")
+
+	if len(balances) == 0 {
+		text.WriteString("У вас нет открытых долгов.\n")
+	} else {
+		totals := make(map[string]float64)
+		for _, bal := range balances {
+			totals[bal.Currency] += bal.Net
+			switch {
+			case bal.Net > 0:
+				text.WriteString(fmt.Sprintf("🔺 %s должен(на) вам %s\n", html.EscapeString(bal.Counterparty), formatMoney(bal.Net, bal.Currency)))
+			case bal.Net < 0:
+				text.WriteString(fmt.Sprintf("🔻 Вы должны %s: %s\n", html.EscapeString(bal.Counterparty), formatMoney(-bal.Net, bal.Currency)))
+			default:
+				text.WriteString(fmt.Sprintf("⚖️ %s: в расчёте\n", html.EscapeString(bal.Counterparty)))
+			}
+		}
+
+		text.WriteString("\n<b>Итого:</b>\n")
+		for currency, total := range totals {
+			sign := ""
+			if total > 0 {
+				sign = "+"
+			}
+			text.WriteString(fmt.Sprintf("┗ %s%s\n", sign, formatMoney(total, currency)))
+		}
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	now := svc.Now()
+	for _, d := range debts {
+		label := fmt.Sprintf("%s %s · %s", debtDirectionIcon(d.Direction), d.Counterparty, formatMoney(d.Remaining(), d.Currency))
+		if d.DueDate != nil {
+			label += " · до " + d.DueDate.Format("02.01")
+			if debtOverdue(&d, now) {
+				label += " ❗"
+			}
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, fmt.Sprintf("%s%d", CallbackDebtView, d.ID)),
+		))
+	}
+
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("➕ Я дал в долг", CallbackDebtNew+repository.DebtLent),
+			tgbotapi.NewInlineKeyboardButtonData("➕ Я взял в долг", CallbackDebtNew+repository.DebtBorrowed),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📜 Закрытые долги", CallbackDebtsClosed),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("◀️ Главное меню", "main_menu"),
+		),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) showClosedDebts(chatID int64, svc *service.FinanceService) {
+	debts, err := svc.GetDebts(true)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString("📜 <b>Закрытые долги</b>\n\n")
+
+	count := 0
+	for _, d := range debts {
+		if d.ClosedAt == nil {
+			continue
+		}
+		count++
+		text.WriteString(fmt.Sprintf("%s %s — %s, закрыт %s\n",
+			debtDirectionIcon(d.Direction), html.EscapeString(d.Counterparty),
+			formatMoney(d.Amount, d.Currency), d.ClosedAt.Format("02.01.2006")))
+	}
+	if count == 0 {
+		text.WriteString("Пока нет закрытых долгов.")
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", CallbackDebts),
+		),
+	)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) showDebt(chatID int64, debtID int, svc *service.FinanceService) {
+	debt, err := svc.GetDebtByID(debtID)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	payments, err := svc.GetDebtPayments(debtID)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	title := fmt.Sprintf("🔺 <b>%s должен(на) вам</b>", html.EscapeString(debt.Counterparty))
+	if debt.Direction == repository.DebtBorrowed {
+		title = fmt.Sprintf("🔻 <b>Вы должны: %s</b>", html.EscapeString(debt.Counterparty))
+	}
+
+	var text strings.Builder
+	text.WriteString(title + "\n\n")
+	text.WriteString(fmt.Sprintf("┣ Сумма: %s\n", formatMoney(debt.Amount, debt.Currency)))
+	text.WriteString(fmt.Sprintf("┣ Погашено: %s\n", formatMoney(debt.Paid, debt.Currency)))
+	text.WriteString(fmt.Sprintf("┣ Остаток: %s\n", formatMoney(debt.Remaining(), debt.Currency)))
+	text.WriteString(fmt.Sprintf("┣ Создан: %s\n", debt.CreatedAt.Format("02.01.2006")))
+	if debt.DueDate != nil {
+		text.WriteString(fmt.Sprintf("┣ Вернуть до: %s", debt.DueDate.Format("02.01.2006")))
+		if debtOverdue(debt, svc.Now()) {
+			text.WriteString(" ❗ просрочен")
+		}
+		text.WriteString("\n")
+	}
+	if debt.Comment != "" {
+		text.WriteString(fmt.Sprintf("┗ Комментарий: %s\n", html.EscapeString(debt.Comment)))
+	}
+
+	if len(payments) > 0 {
+		text.WriteString("\n<b>Погашения:</b>\n")
+		for _, p := range payments {
+			linked := ""
+			if p.TransactionID != nil {
+				linked = " 🧾"
+			}
+			text.WriteString(fmt.Sprintf("• %s — %s%s\n", p.Date.Format("02.01.2006"), formatMoney(p.Amount, debt.Currency), linked))
+		}
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if debt.ClosedAt == nil {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("💵 Частично", fmt.Sprintf("%s%d", CallbackDebtRepay, debt.ID)),
+			tgbotapi.NewInlineKeyboardButtonData("✅ Погасить полностью", fmt.Sprintf("%s%d", CallbackDebtRepayFull, debt.ID)),
+		))
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🗑 Удалить", fmt.Sprintf("%s%d", CallbackDebtDelete, debt.ID)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", CallbackDebts),
+		),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.send(chatID, msg)
+}
+
+func debtOverdue(debt *repository.Debt, now time.Time) bool {
+	return debt.DueDate != nil && debt.ClosedAt == nil && now.After(debt.DueDate.AddDate(0, 0, 1))
+}
+
+func parseDebtAmount(text string) (float64, string, error) {
+	fields := strings.Fields(strings.ReplaceAll(text, ",", "."))
+	if len(fields) == 0 || len(fields) > 2 {
+		return 0, "", fmt.Errorf("неверный формат")
+	}
+
+	raw := fields[0]
+	currency := ""
+	if len(fields) == 2 {
+		currency = fields[1]
+	} else {
+		for alias := range currencyAliases {
+			if strings.HasSuffix(raw, alias) && alias != "р" {
+				currency = alias
+				raw = strings.TrimSuffix(raw, alias)
+				break
+			}
+		}
+	}
+
+	amount, err := strconv.ParseFloat(raw, 64)
+	if err != nil || amount <= 0 {
+		return 0, "", fmt.Errorf("неверная сумма")
+	}
+
+	if currency != "" {
+		if code, ok := currencyAliases[strings.ToLower(currency)]; ok {
+			currency = code
+		} else {
+			currency = strings.ToUpper(currency)
+		}
+	}
+	return amount, currency, nil
+}
+
+func (b *Bot) handleDebtName(m *tgbotapi.Message) {
+	name := strings.TrimSpace(m.Text)
+	if name == "" {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Имя не может быть пустым. Попробуйте снова:"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	state.Step = "enter_debt_amount"
+	state.TempCategoryName = name
+	userStates[m.From.ID] = state
+
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "💰 Введите сумму долга (например, 5000 или 100 USD):"))
+}
+
+func (b *Bot) handleDebtAmount(m *tgbotapi.Message, svc *service.FinanceService) {
+	amount, currency, err := parseDebtAmount(m.Text)
+	if err != nil {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите корректную сумму (например, 5000 или 100 USD):"))
+		return
+	}
+
+	if currency == "" {
+		currency, _ = svc.GetCurrency()
+	}
+
+	state := userStates[m.From.ID]
+	state.Step = "enter_debt_due"
+	state.TempAmount = amount
+	state.TempCurrency = currency
+	userStates[m.From.ID] = state
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, "📅 До какого числа нужно вернуть долг? Введите дату (ДД.ММ.ГГГГ):")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Без срока", CallbackDebtSkipDue),
+		),
+	)
+	b.send(m.Chat.ID, msg)
+}
+
+func (b *Bot) handleDebtDue(m *tgbotapi.Message, svc *service.FinanceService) {
+	if strings.ToLower(strings.TrimSpace(m.Text)) == "без срока" {
+		b.finishCreateDebt(m.Chat.ID, nil, svc)
+		return
+	}
+
+	date, err := parseUserDate(m.Text, svc.Location())
+	if err != nil {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите дату в формате ДД.ММ.ГГГГ или нажмите «Без срока»:"))
+		return
+	}
+
+	b.finishCreateDebt(m.Chat.ID, &date, svc)
+}
+
+func (b *Bot) finishCreateDebt(chatID int64, due *time.Time, svc *service.FinanceService) {
+	state, ok := userStates[chatID]
+	if !ok || state.TempCategoryName == "" {
+		b.sendError(chatID, fmt.Errorf("данные долга не найдены, начните заново"))
+		return
+	}
+
+	id, err := svc.CreateDebt(state.TempCategoryName, state.TempType, state.TempAmount, state.TempCurrency, due, "")
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	delete(userStates, chatID)
+	b.send(chatID, tgbotapi.NewMessage(chatID, "✅ Долг записан!"))
+	b.showDebt(chatID, id, svc)
+}
+
+func (b *Bot) handleDebtRepayAmount(m *tgbotapi.Message, svc *service.FinanceService) {
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(m.Text), ",", "."), 64)
+	if err != nil || amount <= 0 {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите корректную сумму (например, 1500):"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	debt, err := svc.GetDebtByID(state.TempCategoryID)
+	if err != nil {
+		delete(userStates, m.From.ID)
+		b.sendError(m.Chat.ID, err)
+		return
+	}
+
+	if amount > debt.Remaining()+0.005 {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf(
+			"⚠️ Сумма больше остатка (%s). Введите другую сумму:", formatMoney(debt.Remaining(), debt.Currency))))
+		return
+	}
+
+	b.askDebtLink(m.Chat.ID, debt, amount, svc)
+}
+
+func (b *Bot) askDebtLink(chatID int64, debt *repository.Debt, amount float64, svc *service.FinanceService) {
+	userCurrency, _ := svc.GetCurrency()
+	if debt.Currency != userCurrency {
+		delete(userStates, chatID)
+		b.repayDebt(chatID, debt.ID, amount, false, svc)
+		return
+	}
+
+	userStates[chatID] = UserState{Step: "confirm_debt_link", TempCategoryID: debt.ID, TempAmount: amount}
+
+	question := "Записать возврат как доход?"
+	if debt.Direction == repository.DebtBorrowed {
+		question = "Записать погашение как расход?"
+	}
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("💵 Погашение %s\n\n%s", formatMoney(amount, debt.Currency), question))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Да", CallbackDebtLink+"yes"),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Нет", CallbackDebtLink+"no"),
+		),
+	)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) repayDebt(chatID int64, debtID int, amount float64, withTransaction bool, svc *service.FinanceService) {
+	debt, err := svc.RepayDebt(debtID, amount, withTransaction)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	text := fmt.Sprintf("✅ Погашение %s записано", formatMoney(amount, debt.Currency))
+	if withTransaction {
+		text += " и добавлено в операции"
+	}
+	if debt.ClosedAt != nil {
+		text += fmt.Sprintf("\n\n🎉 Долг с %s полностью закрыт!", debt.Counterparty)
+	}
+
+	b.send(chatID, tgbotapi.NewMessage(chatID, text))
+	b.showDebt(chatID, debtID, svc)
+}
+
+func (b *Bot) SendDebtReminders() {
+	users, err := b.repo.GetAllUsers()
+	if err != nil {
+		logger.Error("Debt reminder error getting users", "error", err)
+		return
+	}
+
+	sent := 0
+	for i := range users {
+		user := users[i]
+		if !user.CanNotify() {
+			continue
+		}
+
+		svc := service.NewService(b.repo, &user)
+		now := svc.Now()
+		if svc.IsHouseholdGuest() {
+			continue
+		}
+		debts, err := svc.GetDebts(false)
+		if err != nil {
+			logger.Error("Debt reminder error getting debts", "user_id", user.ID, "error", err)
+			continue
+		}
+
+		for j := range debts {
+			debt := debts[j]
+			if debt.DueDate == nil {
+				continue
+			}
+
+			dueEnd := debt.DueDate.AddDate(0, 0, 1)
+			remindFrom := debt.DueDate.AddDate(0, 0, -debtReminderDaysBefore)
+
+			var text string
+			switch {
+			case now.After(dueEnd):
+				if debt.LastReminderAt != nil && !debt.LastReminderAt.Before(dueEnd) {
+					continue
+				}
+				text = "❗ <b>Долг просрочен</b>\n\n"
+			case now.After(remindFrom):
+				if debt.LastReminderAt != nil && !debt.LastReminderAt.Before(remindFrom) {
+					continue
+				}
+				days := int(math.Ceil(dueEnd.Sub(now).Hours()/24)) - 1
+				text = fmt.Sprintf("⏰ <b>Срок возврата долга %s</b>\n\n", daysLeftLabel(days))
+			default:
+				continue
+			}
+
+			if debt.Direction == repository.DebtLent {
+				text += fmt.Sprintf("%s должен(на) вернуть вам %s до %s.",
+					html.EscapeString(debt.Counterparty), formatMoney(debt.Remaining(), debt.Currency), debt.DueDate.Format("02.01.2006"))
+			} else {
+				text += fmt.Sprintf("Вам нужно вернуть %s %s до %s.",
+					html.EscapeString(debt.Counterparty), formatMoney(debt.Remaining(), debt.Currency), debt.DueDate.Format("02.01.2006"))
+			}
+
+			msg := tgbotapi.NewMessage(user.TelegramID, text)
+			msg.ParseMode = "HTML"
+			msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+				tgbotapi.NewInlineKeyboardRow(
+					tgbotapi.NewInlineKeyboardButtonData("🤝 Открыть долг", fmt.Sprintf("%s%d", CallbackDebtView, debt.ID)),
+				),
+			)
+			b.SendMessage(msg)
+
+			if err := svc.MarkDebtReminded(debt.ID, now); err != nil {
+				logger.Error("Failed to mark debt reminded", "debt_id", debt.ID, "error", err)
+			}
+			sent++
+		}
+	}
+
+	logger.Info("Debt reminders sent", "count", sent)
+}
+
+func daysLeftLabel(days int) string {
+	switch days {
+	case 0:
+		return "сегодня"
+	case 1:
+		return "завтра"
+	default:
+		return fmt.Sprintf("через %d дн.", days)
+	}
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/logger"
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+	"github.com/IlyaMakar/finance_bot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CallbackSavingDeposit        = "saving_deposit_"
+	CallbackSavingRate           = "saving_rate_"
+	CallbackSavingMaturity       = "saving_maturity_"
+	CallbackSavingCapitalization = "saving_cap_"
+)
+
+var capitalizationLabels = map[string]string{
+	repository.CapitalizationMonthly:   "ежемесячно",
+	repository.CapitalizationQuarterly: "ежеквартально",
+	repository.CapitalizationMaturity:  "в конце срока",
+}
+
+func (b *Bot) handleDepositCallback(chatID int64, messageID int, data string, svc *service.FinanceService) bool {
+	switch {
+	case strings.HasPrefix(data, CallbackSavingDeposit):
+		savingID, _ := strconv.Atoi(data[len(CallbackSavingDeposit):])
+		b.deleteMessage(chatID, messageID)
+		b.showSavingDeposit(chatID, savingID, svc)
+
+	case strings.HasPrefix(data, CallbackSavingRate):
+		savingID, _ := strconv.Atoi(data[len(CallbackSavingRate):])
+		state := userStates[chatID]
+		state.Step = "enter_saving_rate"
+		state.TempCategoryID = savingID
+		userStates[chatID] = state
+		b.send(chatID, tgbotapi.NewMessage(chatID, "🏦 Введите годовую процентную ставку (например, 16.5). Чтобы отключить проценты, введите 0:"))
+
+	case strings.HasPrefix(data, CallbackSavingMaturity):
+		savingID, _ := strconv.Atoi(data[len(CallbackSavingMaturity):])
+		state := userStates[chatID]
+		state.Step = "enter_saving_maturity"
+		state.TempCategoryID = savingID
+		userStates[chatID] = state
+		b.send(chatID, tgbotapi.NewMessage(chatID, "📅 Введите дату окончания вклада (ДД.ММ.ГГГГ):"))
+
+	case strings.HasPrefix(data, CallbackSavingCapitalization):
+		parts := strings.Split(data[len(CallbackSavingCapitalization):], "_")
+		if len(parts) != 2 {
+			b.sendError(chatID, fmt.Errorf("неверный формат капитализации"))
+			return true
+		}
+		savingID, _ := strconv.Atoi(parts[1])
+		saving, err := svc.GetSavingByID(savingID)
+		if err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		if err := svc.SetSavingInterest(savingID, saving.InterestRate, parts[0], saving.MaturityDate); err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		b.deleteMessage(chatID, messageID)
+		b.showSavingDeposit(chatID, savingID, svc)
+
+	default:
+		return false
+	}
+	return true
+}
+
+func (b *Bot) formatDepositInfo(saving *repository.Saving, svc *service.FinanceService, chatID int64) string {
+	if saving.InterestRate <= 0 {
+		return ""
+	}
+
+	text := fmt.Sprintf("\n🏦 Ставка: %.2f%% годовых, капитализация %s",
+		saving.InterestRate, capitalizationLabels[saving.Capitalization])
+	if saving.MaturityDate != nil {
+		text += fmt.Sprintf(", до %s", saving.MaturityDate.Format("02.01.2006"))
+	}
+
+	if projection := svc.ProjectDeposit(saving, svc.Now()); projection != nil {
+		text += fmt.Sprintf("\n📈 Прогноз на %s: %s (+%s)",
+			projection.Date.Format("02.01.2006"),
+			b.formatCurrency(projection.Value, chatID),
+			b.formatCurrency(projection.Interest, chatID))
+	}
+	return text
+}
+
+func (b *Bot) showSavingDeposit(chatID int64, savingID int, svc *service.FinanceService) {
+	saving, err := svc.GetSavingByID(savingID)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("🏦 <b>Вклад «%s»</b>\n\n", html.EscapeString(saving.Name)))
+	text.WriteString(fmt.Sprintf("Сумма: %s\n", b.formatCurrency(saving.Amount, chatID)))
+
+	if saving.InterestRate <= 0 {
+		text.WriteString("\nПроценты не начисляются. Укажите ставку, если это банковский вклад или накопительный счёт.")
+	} else {
+		text.WriteString(fmt.Sprintf("Ставка: %.2f%% годовых\n", saving.InterestRate))
+		text.WriteString(fmt.Sprintf("Капитализация: %s\n", capitalizationLabels[saving.Capitalization]))
+		if saving.MaturityDate != nil {
+			text.WriteString(fmt.Sprintf("Окончание: %s\n", saving.MaturityDate.Format("02.01.2006")))
+		}
+		if saving.LastAccrualAt != nil {
+			text.WriteString(fmt.Sprintf("Последнее начисление: %s\n", saving.LastAccrualAt.Format("02.01.2006")))
+		}
+
+		if projection := svc.ProjectDeposit(saving, svc.Now()); projection != nil {
+			label := "через год"
+			if saving.MaturityDate != nil {
+				label = "к окончанию"
+			}
+			text.WriteString(fmt.Sprintf("\n📈 <b>Прогноз %s (%s)</b>\nСумма: %s\nПроценты: +%s",
+				label, projection.Date.Format("02.01.2006"),
+				b.formatCurrency(projection.Value, chatID),
+				b.formatCurrency(projection.Interest, chatID)))
+		}
+	}
+
+	capButton := func(capitalization string) tgbotapi.InlineKeyboardButton {
+		label := capitalizationLabels[capitalization]
+		if saving.Capitalization == capitalization {
+			label += " ✅"
+		}
+		return tgbotapi.NewInlineKeyboardButtonData(label, fmt.Sprintf("%s%s_%d", CallbackSavingCapitalization, capitalization, savingID))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✏️ Ставка", fmt.Sprintf("%s%d", CallbackSavingRate, savingID)),
+			tgbotapi.NewInlineKeyboardButtonData("📅 Окончание", fmt.Sprintf("%s%d", CallbackSavingMaturity, savingID)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			capButton(repository.CapitalizationMonthly),
+			capButton(repository.CapitalizationQuarterly),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			capButton(repository.CapitalizationMaturity),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", fmt.Sprintf("%s%d", CallbackEditSaving, savingID)),
+		),
+	)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) handleSavingRate(m *tgbotapi.Message, svc *service.FinanceService) {
+	rate, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSuffix(strings.TrimSpace(m.Text), "%"), ",", "."), 64)
+	if err != nil || rate < 0 || rate > 100 {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите ставку от 0 до 100:"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	saving, err := svc.GetSavingByID(state.TempCategoryID)
+	if err != nil {
+		b.sendError(m.Chat.ID, err)
+		return
+	}
+
+	capitalization := saving.Capitalization
+	if capitalization == repository.CapitalizationMaturity && saving.MaturityDate == nil {
+		capitalization = repository.CapitalizationMonthly
+	}
+
+	if err := svc.SetSavingInterest(saving.ID, rate, capitalization, saving.MaturityDate); err != nil {
+		b.sendError(m.Chat.ID, err)
+		return
+	}
+
+	delete(userStates, m.From.ID)
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✅ Ставка установлена: %.2f%%", rate)))
+	b.showSavingDeposit(m.Chat.ID, saving.ID, svc)
+}
+
+func (b *Bot) handleSavingMaturity(m *tgbotapi.Message, svc *service.FinanceService) {
+	date, err := parseUserDate(m.Text, svc.Location())
+	if err != nil || !date.After(svc.Now()) {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите будущую дату в формате ДД.ММ.ГГГГ:"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	saving, err := svc.GetSavingByID(state.TempCategoryID)
+	if err != nil {
+		b.sendError(m.Chat.ID, err)
+		return
+	}
+
+	if err := svc.SetSavingInterest(saving.ID, saving.InterestRate, saving.Capitalization, &date); err != nil {
+		b.sendError(m.Chat.ID, err)
+		return
+	}
+
+	delete(userStates, m.From.ID)
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✅ Дата окончания вклада: %s", date.Format("02.01.2006"))))
+	b.showSavingDeposit(m.Chat.ID, saving.ID, svc)
+}
+
+func (b *Bot) AccrueSavingsInterest() {
+	users, err := b.repo.GetAllUsers()
+	if err != nil {
+		logger.Error("Interest accrual error getting users", "error", err)
+		return
+	}
+
+	total := 0
+	now := time.Now()
+	for i := range users {
+		user := users[i]
+		svc := service.NewService(b.repo, &user)
+		if svc.IsHouseholdGuest() {
+			continue
+		}
+		accruals, err := svc.AccrueInterest(now)
+		if err != nil {
+			logger.Error("Interest accrual failed", "user_id", user.ID, "error", err)
+		}
+
+		for _, a := range accruals {
+			total++
+			if !user.CanNotify() {
+				continue
+			}
+			msg := tgbotapi.NewMessage(user.TelegramID, fmt.Sprintf(
+				"🏦 <b>Начислены проценты</b>\n\nВклад «%s»: +%s\n💰 Новый баланс: %s",
+				html.EscapeString(a.Saving.Name),
+				b.formatCurrency(a.Interest, user.TelegramID),
+				b.formatCurrency(a.Saving.Amount, user.TelegramID)))
+			msg.ParseMode = "HTML"
+			b.SendMessage(msg)
+		}
+	}
+
+	logger.Info("Interest accrual completed", "accruals", total)
+}
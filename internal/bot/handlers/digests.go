@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/logger"
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+	"github.com/IlyaMakar/finance_bot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CallbackDigestSettings = "digest_settings"
+	CallbackDigestFreq     = "digest_freq_"
+	CallbackDigestHour     = "digest_hour_"
+	CallbackDigestPDF      = "digest_pdf"
+	CallbackDigestPreview  = "digest_preview"
+
+	digestTopCategories = 3
+	digestSavingsLimit  = 5
+)
+
+var digestFrequencyLabels = map[string]string{
+	repository.DigestOff:     "выключены",
+	repository.DigestWeekly:  "по понедельникам",
+	repository.DigestMonthly: "в начале периода",
+	repository.DigestBoth:    "еженедельно и в начале периода",
+}
+
+func (b *Bot) handleDigestCallback(chatID int64, messageID int, data string, svc *service.FinanceService) bool {
+	switch {
+	case data == CallbackDigestSettings:
+		b.deleteMessage(chatID, messageID)
+		b.showDigestSettings(chatID, svc)
+
+	case strings.HasPrefix(data, CallbackDigestFreq):
+		if err := svc.SetDigestFrequency(data[len(CallbackDigestFreq):]); err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		b.deleteMessage(chatID, messageID)
+		b.showDigestSettings(chatID, svc)
+
+	case strings.HasPrefix(data, CallbackDigestHour):
+		hour, _ := strconv.Atoi(data[len(CallbackDigestHour):])
+		if err := svc.SetDigestHour(hour); err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		b.deleteMessage(chatID, messageID)
+		b.showDigestSettings(chatID, svc)
+
+	case data == CallbackDigestPDF:
+		if _, err := svc.ToggleDigestPDF(); err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		b.deleteMessage(chatID, messageID)
+		b.showDigestSettings(chatID, svc)
+
+	case data == CallbackDigestPreview:
+		start, end := service.WeeklyDigestPeriod(svc.Now())
+		if err := b.sendDigest(chatID, svc, "🗞 <b>Дайджест за неделю</b>", start, end, start.AddDate(0, 0, -7), start, false); err != nil {
+			logger.Error("Digest preview error", "chat_id", chatID, "error", err)
+			b.sendError(chatID, fmt.Errorf("не удалось собрать дайджест"))
+		}
+
+	default:
+		return false
+	}
+	return true
+}
+
+func (b *Bot) showDigestSettings(chatID int64, svc *service.FinanceService) {
+	settings, err := svc.GetDigestSettings()
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	pdfStatus := "нет"
+	if settings.AttachPDF {
+		pdfStatus = "да"
+	}
+
+	text := fmt.Sprintf("🗞 <b>Дайджесты</b>\n\n"+
+		"Короткая сводка: итоги, топ категорий, бюджет, копилки и сравнение с прошлым периодом.\n\n"+
+		"Частота: <b>%s</b>\nВремя: <b>%02d:00</b> (%s)\nPDF-отчёт во вложении: <b>%s</b>\n\n"+
+		"<i>Дайджесты приходят, только если включены уведомления.</i>",
+		digestFrequencyLabels[settings.Frequency], settings.Hour, timezoneLabel(svc.Location()), pdfStatus)
+
+	mark := func(ok bool) string {
+		if ok {
+			return "✅ "
+		}
+		return ""
+	}
+
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(mark(settings.Frequency == repository.DigestWeekly)+"Неделя", CallbackDigestFreq+repository.DigestWeekly),
+			tgbotapi.NewInlineKeyboardButtonData(mark(settings.Frequency == repository.DigestMonthly)+"Период", CallbackDigestFreq+repository.DigestMonthly),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(mark(settings.Frequency == repository.DigestBoth)+"Оба", CallbackDigestFreq+repository.DigestBoth),
+			tgbotapi.NewInlineKeyboardButtonData(mark(settings.Frequency == repository.DigestOff)+"Выключить", CallbackDigestFreq+repository.DigestOff),
+		),
+	}
+
+	var hours []tgbotapi.InlineKeyboardButton
+	for _, h := range service.DigestHours {
+		hours = append(hours, tgbotapi.NewInlineKeyboardButtonData(
+			fmt.Sprintf("%s%02d:00", mark(settings.Hour == h), h), CallbackDigestHour+strconv.Itoa(h)))
+		if len(hours) == 3 {
+			rows = append(rows, hours)
+			hours = nil
+		}
+	}
+	if len(hours) > 0 {
+		rows = append(rows, hours)
+	}
+
+	pdfLabel := "📎 Прикладывать PDF"
+	if settings.AttachPDF {
+		pdfLabel = "📎 Не прикладывать PDF"
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(pdfLabel, CallbackDigestPDF)),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("👀 Прислать сейчас", CallbackDigestPreview)),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("◀️ В меню", "settings_back")),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) digestPlanner(job repository.Job) (string, error) {
+	users, err := b.repo.GetAllUsers()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	planned := 0
+	for i := range users {
+		user := users[i]
+		if !user.CanNotify() {
+			continue
+		}
+
+		svc := service.NewService(b.repo, &user)
+		local := now.In(svc.Location())
+		today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+		settings, err := svc.GetDigestSettings()
+		if err != nil {
+			logger.Error("Digest settings error", "user_id", user.TelegramID, "error", err)
+			continue
+		}
+		if settings.Frequency == repository.DigestOff || local.Hour() < settings.Hour {
+			continue
+		}
+
+		var kinds []string
+		if settings.Weekly() && local.Weekday() == time.Monday && !sentSince(settings.LastWeeklySent, today) {
+			kinds = append(kinds, repository.DigestWeekly)
+		}
+		if periodStart, _ := monthPeriod(today, user.PeriodStartDay); settings.Monthly() && periodStart.Equal(today) && !sentSince(settings.LastMonthlySent, today) {
+			kinds = append(kinds, repository.DigestMonthly)
+		}
+
+		for _, kind := range kinds {
+			key := fmt.Sprintf("%d:%s:%s", user.ID, kind, today.Format("2006-01-02"))
+			added, err := b.jobs.Enqueue(JobDigest, key, key, now)
+			if err != nil {
+				logger.Error("Digest enqueue error", "user_id", user.TelegramID, "error", err)
+				continue
+			}
+			if added {
+				planned++
+			}
+		}
+	}
+	return fmt.Sprintf("planned %d digests", planned), nil
+}
+
+func (b *Bot) runDigest(job repository.Job) (string, error) {
+	parts := strings.SplitN(job.Payload, ":", 3)
+	if len(parts) != 3 {
+		return "", fmt.Errorf("bad digest payload %q", job.Payload)
+	}
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return "", fmt.Errorf("bad digest payload %q", job.Payload)
+	}
+	kind := parts[1]
+
+	user, err := b.repo.GetUserByID(userID)
+	if err != nil {
+		return "", err
+	}
+	if user == nil || !user.CanNotify() {
+		return "skipped: notifications off or chat unreachable", nil
+	}
+
+	svc := service.NewService(b.repo, user)
+	today, err := time.ParseInLocation("2006-01-02", parts[2], svc.Location())
+	if err != nil {
+		return "", fmt.Errorf("bad digest payload %q", job.Payload)
+	}
+	settings, err := svc.GetDigestSettings()
+	if err != nil {
+		return "", err
+	}
+
+	switch kind {
+	case repository.DigestWeekly:
+		if !settings.Weekly() || sentSince(settings.LastWeeklySent, today) {
+			return "skipped: disabled or already sent", nil
+		}
+		start, end := service.WeeklyDigestPeriod(today)
+		if err := b.sendDigest(user.TelegramID, svc, "🗞 <b>Дайджест за неделю</b>", start, end, start.AddDate(0, 0, -7), start, settings.AttachPDF); err != nil {
+			return "", err
+		}
+	case repository.DigestMonthly:
+		if !settings.Monthly() || sentSince(settings.LastMonthlySent, today) {
+			return "skipped: disabled or already sent", nil
+		}
+		start := today.AddDate(0, -1, 0)
+		if err := b.sendDigest(user.TelegramID, svc, "🗞 <b>Итоги периода</b>", start, today, start.AddDate(0, -1, 0), start, settings.AttachPDF); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("bad digest payload %q", job.Payload)
+	}
+
+	if err := svc.MarkDigestSent(kind, time.Now()); err != nil {
+		logger.Error("Digest mark error", "user_id", user.TelegramID, "error", err)
+	}
+	return "sent", nil
+}
+
+func sentSince(last *time.Time, since time.Time) bool {
+	return last != nil && !last.Before(since)
+}
+
+func (b *Bot) sendDigest(chatID int64, svc *service.FinanceService, title string, start, end, prevStart, prevEnd time.Time, attachPDF bool) error {
+	digest, err := svc.BuildDigest(start, end, prevStart, prevEnd, digestTopCategories)
+	if err != nil {
+		return fmt.Errorf("build digest: %w", err)
+	}
+	cmp := digest.Comparison
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("%s\n<i>%s</i>\n\n", title, rangeLabel(start, end.AddDate(0, 0, -1))))
+	text.WriteString(fmt.Sprintf("📈 Доходы: %s %s\n", b.formatCurrency(cmp.CurrentIncome, chatID),
+		b.formatDelta(cmp.CurrentIncome, cmp.PreviousIncome, chatID)))
+	text.WriteString(fmt.Sprintf("📉 Расходы: %s %s\n", b.formatCurrency(cmp.CurrentExpense, chatID),
+		b.formatDelta(cmp.CurrentExpense, cmp.PreviousExpense, chatID)))
+	text.WriteString(fmt.Sprintf("💵 Баланс: %s\n", b.formatCurrency(cmp.CurrentIncome-cmp.CurrentExpense, chatID)))
+
+	if len(digest.TopExpenses) > 0 {
+		text.WriteString("\n🏆 <b>Топ расходов:</b>\n")
+		for _, d := range digest.TopExpenses {
+			text.WriteString(fmt.Sprintf("┣ %s: %s (%.0f%%) %s\n", html.EscapeString(d.Name), b.formatCurrency(d.Current, chatID),
+				d.Current/cmp.CurrentExpense*100, b.formatDelta(d.Current, d.Previous, chatID)))
+		}
+	}
+
+	text.WriteString("\n💼 <b>Бюджет:</b> ")
+	switch {
+	case cmp.CurrentIncome == 0 && cmp.CurrentExpense == 0:
+		text.WriteString("операций не было\n")
+	case cmp.CurrentIncome == 0:
+		text.WriteString("доходов не было, всё покрыто накоплениями\n")
+	case cmp.CurrentExpense > cmp.CurrentIncome:
+		text.WriteString(fmt.Sprintf("⚠️ расходы превысили доходы на %s\n", b.formatCurrency(cmp.CurrentExpense-cmp.CurrentIncome, chatID)))
+	default:
+		text.WriteString(fmt.Sprintf("✅ потрачено %.0f%% доходов, осталось %s\n",
+			cmp.CurrentExpense/cmp.CurrentIncome*100, b.formatCurrency(cmp.CurrentIncome-cmp.CurrentExpense, chatID)))
+	}
+
+	if len(digest.Savings) > 0 {
+		text.WriteString("\n💰 <b>Копилки:</b>\n")
+		for i, s := range digest.Savings {
+			if i == digestSavingsLimit {
+				text.WriteString("┗ …\n")
+				break
+			}
+			text.WriteString(fmt.Sprintf("┣ %s: %s\n", html.EscapeString(s.Name), b.renderProgressBar(s.Amount / *s.Goal * 100, 10)))
+		}
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📤 Выгрузить отчет", fmt.Sprintf("export_report_%s_%s",
+				start.Format("2006-01-02"), end.Format("2006-01-02"))),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⚙️ Настройки дайджеста", CallbackDigestSettings),
+		),
+	)
+	if err := b.deliver(chatID, msg); err != nil {
+		return err
+	}
+
+	if !attachPDF {
+		return nil
+	}
+	pdfData, err := b.reportGen.GeneratePDFReport(chatID, start, end, svc, LangRU)
+	if err != nil {
+		logger.Error("Digest PDF error", "chat_id", chatID, "error", err)
+		return nil
+	}
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  reportLocale{lang: LangRU}.textf("file_name", start.Format("02.01.2006"), end.AddDate(0, 0, -1).Format("02.01.2006")),
+		Bytes: pdfData,
+	})
+	b.send(chatID, doc)
+	return nil
+}
@@ -0,0 +1,466 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/IlyaMakar/finance_bot/internal/logger"
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+	"github.com/IlyaMakar/finance_bot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CallbackGroupUndo    = "grp_undo_"
+	CallbackGroupSettle  = "grp_settle_"
+	CallbackGroupBalance = "grp_balance"
+
+	groupExpensesLimit = 10
+	textMentionPrefix  = "@#"
+)
+
+const groupHelpText = `👥 <b>Общие расходы</b>
+
+Пишите расходы прямо в чат — я посчитаю, кто кому сколько должен.
+
+<b>Поровну</b> (вы и упомянутые):
+<code>1200 такси @anna @oleg</code>
+
+<b>Точные суммы</b> (остаток — на вас):
+<code>3000 ужин @anna=1200 @oleg=800</code>
+
+<b>По долям</b> (у вас одна доля):
+<code>9000 отель @anna*2 @oleg*1</code>
+
+Без упоминаний участников расход записывается только через /add или с упоминанием бота и делится на всех, кого я уже знаю в этом чате:
+<code>/add 2400 продукты</code>
+
+<b>Команды:</b>
+/add 2400 продукты — записать расход
+/balance — балансы и кто кому переводит
+/settle @oleg 500 — записать ваш перевод участнику
+/expenses — последние расходы
+/currency EUR — валюта чата`
+
+type groupMention struct {
+	token  string
+	amount float64
+	shares float64
+}
+
+func (b *Bot) handleGroupMessage(m *tgbotapi.Message) {
+	if m.From == nil || m.From.IsBot {
+		return
+	}
+
+	g, err := service.NewGroupService(b.repo, m.Chat.ID, m.Chat.Title)
+	if err != nil {
+		logger.Error("Failed to load group chat", "chat_id", m.Chat.ID, "error", err)
+		return
+	}
+
+	for _, u := range m.NewChatMembers {
+		if u.ID == b.bot.Self.ID {
+			b.sendGroupHelp(m.Chat.ID)
+			return
+		}
+	}
+
+	sender, err := g.EnsureMember(m.From.ID, m.From.UserName, m.From.FirstName)
+	if err != nil {
+		logger.Error("Failed to register group member", "chat_id", m.Chat.ID, "user_id", m.From.ID, "error", err)
+		return
+	}
+
+	text, mentioned := replaceTextMentions(m)
+
+	if m.IsCommand() {
+		args := ""
+		if fields := strings.SplitN(text, " ", 2); len(fields) == 2 {
+			args = strings.TrimSpace(fields[1])
+		}
+
+		switch m.Command() {
+		case "start", "help", "split":
+			b.sendGroupHelp(m.Chat.ID)
+		case "add":
+			b.addGroupExpense(m, g, sender, args, mentioned)
+		case "balance":
+			text, markup := b.groupBalanceView(g)
+			msg := tgbotapi.NewMessage(m.Chat.ID, text)
+			msg.ParseMode = tgbotapi.ModeHTML
+			msg.ReplyMarkup = markup
+			b.send(m.Chat.ID, msg)
+		case "settle":
+			b.settleGroupDebt(m, g, sender, args, mentioned)
+		case "expenses":
+			b.showGroupExpenses(m.Chat.ID, g)
+		case "currency":
+			if err := g.SetCurrency(args); err != nil {
+				b.sendError(m.Chat.ID, err)
+				return
+			}
+			b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("💱 Валюта чата: %s", g.Group().Currency)))
+		}
+		return
+	}
+
+	text, addressed := b.stripBotMention(text)
+	if r := []rune(text); len(r) == 0 || !unicode.IsDigit(r[0]) {
+		return
+	}
+	if addressed || strings.Contains(text, "@") {
+		b.addGroupExpense(m, g, sender, text, mentioned)
+	}
+}
+
+func (b *Bot) stripBotMention(text string) (string, bool) {
+	mention := "@" + b.bot.Self.UserName
+	fields := strings.Fields(text)
+	kept := fields[:0]
+	for _, f := range fields {
+		if b.bot.Self.UserName != "" && strings.EqualFold(f, mention) {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return strings.Join(kept, " "), len(kept) < len(fields)
+}
+
+func (b *Bot) sendGroupHelp(chatID int64) {
+	msg := tgbotapi.NewMessage(chatID, groupHelpText)
+	msg.ParseMode = tgbotapi.ModeHTML
+	b.send(chatID, msg)
+}
+
+func replaceTextMentions(m *tgbotapi.Message) (string, map[string]*tgbotapi.User) {
+	mentioned := make(map[string]*tgbotapi.User)
+	units := utf16.Encode([]rune(m.Text))
+
+	var out []uint16
+	last := 0
+	for _, e := range m.Entities {
+		if e.Type != "text_mention" || e.User == nil || e.Offset < last || e.Offset+e.Length > len(units) {
+			continue
+		}
+		token := fmt.Sprintf("%s%d", textMentionPrefix, len(mentioned))
+		mentioned[token] = e.User
+
+		out = append(out, units[last:e.Offset]...)
+		out = append(out, utf16.Encode([]rune(token))...)
+		last = e.Offset + e.Length
+	}
+	out = append(out, units[last:]...)
+
+	return string(utf16.Decode(out)), mentioned
+}
+
+func parseGroupExpense(text string) (float64, string, []groupMention, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return 0, "", nil, fmt.Errorf("укажите сумму, например: 1200 такси @anna @oleg")
+	}
+
+	amount, _, err := parseDebtAmount(fields[0])
+	if err != nil {
+		return 0, "", nil, fmt.Errorf("не удалось распознать сумму «%s»", fields[0])
+	}
+
+	var description []string
+	var mentions []groupMention
+	for _, f := range fields[1:] {
+		if !strings.HasPrefix(f, "@") {
+			description = append(description, f)
+			continue
+		}
+
+		mention := groupMention{token: f}
+		if i := strings.IndexAny(f, "=*"); i > 0 {
+			mention.token = f[:i]
+			value, err := strconv.ParseFloat(strings.ReplaceAll(f[i+1:], ",", "."), 64)
+			if err != nil || value <= 0 {
+				return 0, "", nil, fmt.Errorf("неверная доля у %s", mention.token)
+			}
+			if f[i] == '=' {
+				mention.amount = value
+			} else {
+				mention.shares = value
+			}
+		}
+		mentions = append(mentions, mention)
+	}
+
+	return amount, strings.Join(description, " "), mentions, nil
+}
+
+func (b *Bot) resolveGroupMention(g *service.GroupService, token string, mentioned map[string]*tgbotapi.User) (*repository.GroupMember, error) {
+	if u, ok := mentioned[token]; ok {
+		return g.EnsureMember(u.ID, u.UserName, u.FirstName)
+	}
+	return g.MemberByUsername(token)
+}
+
+func (b *Bot) addGroupExpense(m *tgbotapi.Message, g *service.GroupService, sender *repository.GroupMember, text string, mentioned map[string]*tgbotapi.User) {
+	amount, description, mentions, err := parseGroupExpense(text)
+	if err != nil {
+		b.sendError(m.Chat.ID, err)
+		return
+	}
+
+	senderPart := service.GroupParticipant{MemberID: sender.ID}
+	var parts []service.GroupParticipant
+	for _, mention := range mentions {
+		if strings.EqualFold(strings.TrimPrefix(mention.token, "@"), b.bot.Self.UserName) {
+			continue
+		}
+		member, err := b.resolveGroupMention(g, mention.token, mentioned)
+		if err != nil {
+			b.sendError(m.Chat.ID, err)
+			return
+		}
+
+		part := service.GroupParticipant{MemberID: member.ID, Amount: mention.amount, Shares: mention.shares}
+		if member.ID == sender.ID {
+			senderPart = part
+			continue
+		}
+		parts = append(parts, part)
+	}
+
+	if len(mentions) == 0 {
+		members, err := g.GetMembers()
+		if err != nil {
+			b.sendError(m.Chat.ID, err)
+			return
+		}
+		for _, member := range members {
+			if member.ID != sender.ID {
+				parts = append(parts, service.GroupParticipant{MemberID: member.ID})
+			}
+		}
+	}
+
+	if len(parts) == 0 {
+		b.sendError(m.Chat.ID, fmt.Errorf("упомяните участников через @, например: 1200 такси @anna @oleg"))
+		return
+	}
+	parts = append([]service.GroupParticipant{senderPart}, parts...)
+
+	id, shares, err := g.AddExpense(sender.ID, amount, description, parts, m.From.ID)
+	if err != nil {
+		b.sendError(m.Chat.ID, err)
+		return
+	}
+
+	names, err := groupMemberNames(g)
+	if err != nil {
+		b.sendError(m.Chat.ID, err)
+		return
+	}
+
+	currency := g.Group().Currency
+	var out strings.Builder
+	title := description
+	if title == "" {
+		title = "Расход"
+	}
+	out.WriteString(fmt.Sprintf("🧾 <b>%s</b> — %s\n", html.EscapeString(title), formatMoney(amount, currency)))
+	out.WriteString(fmt.Sprintf("💳 Оплатил(а): %s\n\n", html.EscapeString(sender.DisplayName())))
+	for _, s := range shares {
+		out.WriteString(fmt.Sprintf("┣ %s: %s\n", html.EscapeString(names[s.MemberID]), formatMoney(s.Amount, currency)))
+	}
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, out.String())
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyToMessageID = m.MessageID
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("↩️ Отменить", fmt.Sprintf("%s%d", CallbackGroupUndo, id)),
+			tgbotapi.NewInlineKeyboardButtonData("⚖️ Балансы", CallbackGroupBalance),
+		),
+	)
+	b.send(m.Chat.ID, msg)
+}
+
+func (b *Bot) settleGroupDebt(m *tgbotapi.Message, g *service.GroupService, sender *repository.GroupMember, args string, mentioned map[string]*tgbotapi.User) {
+	fields := strings.Fields(args)
+	if len(fields) != 2 || !strings.HasPrefix(fields[0], "@") {
+		b.sendError(m.Chat.ID, fmt.Errorf("формат: /settle @oleg 500"))
+		return
+	}
+
+	amount, _, err := parseDebtAmount(fields[1])
+	if err != nil {
+		b.sendError(m.Chat.ID, err)
+		return
+	}
+	to, err := b.resolveGroupMention(g, fields[0], mentioned)
+	if err != nil {
+		b.sendError(m.Chat.ID, err)
+		return
+	}
+	if err := g.AddSettlement(sender.ID, to.ID, amount); err != nil {
+		b.sendError(m.Chat.ID, err)
+		return
+	}
+
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✅ %s → %s: %s",
+		sender.DisplayName(), to.DisplayName(), formatMoney(amount, g.Group().Currency))))
+}
+
+func groupMemberNames(g *service.GroupService) (map[int]string, error) {
+	members, err := g.GetMembers()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int]string, len(members))
+	for _, m := range members {
+		names[m.ID] = m.DisplayName()
+	}
+	return names, nil
+}
+
+func (b *Bot) groupBalanceView(g *service.GroupService) (string, tgbotapi.InlineKeyboardMarkup) {
+	balances, err := g.GetBalances()
+	if err != nil {
+		return fmt.Sprintf("⚠️ Ошибка: %s", err.Error()), tgbotapi.NewInlineKeyboardMarkup()
+	}
+	if len(balances) == 0 {
+		return "⚖️ Все в расчёте 🎉", tgbotapi.NewInlineKeyboardMarkup()
+	}
+
+	currency := g.Group().Currency
+	var text strings.Builder
+	text.WriteString("⚖️ <b>Балансы</b>\n\n")
+	for _, bal := range balances {
+		icon := "🟢"
+		if bal.Balance < 0 {
+			icon = "🔴"
+		}
+		text.WriteString(fmt.Sprintf("%s %s: %+.2f\n", icon, html.EscapeString(bal.Member.DisplayName()), bal.Balance))
+	}
+
+	transfers := service.MinimalTransfers(balances)
+	text.WriteString("\n💸 <b>Кто кому переводит:</b>\n")
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, t := range transfers {
+		text.WriteString(fmt.Sprintf("┣ %s → %s: %s\n",
+			html.EscapeString(t.From.DisplayName()), html.EscapeString(t.To.DisplayName()), formatMoney(t.Amount, currency)))
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+			fmt.Sprintf("✅ %s → %s", t.From.DisplayName(), t.To.DisplayName()),
+			fmt.Sprintf("%s%d_%d_%d", CallbackGroupSettle, t.From.ID, t.To.ID, int64(math.Round(t.Amount*100))),
+		)))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("🔄 Обновить", CallbackGroupBalance)))
+
+	return text.String(), tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func (b *Bot) showGroupExpenses(chatID int64, g *service.GroupService) {
+	expenses, err := g.GetExpenses(groupExpensesLimit)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+	if len(expenses) == 0 {
+		b.send(chatID, tgbotapi.NewMessage(chatID, "🧾 Расходов пока нет. Напишите, например: 1200 такси @anna @oleg"))
+		return
+	}
+
+	names, err := groupMemberNames(g)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString("🧾 <b>Последние расходы</b>\n\n")
+	for _, e := range expenses {
+		title := e.Description
+		if title == "" {
+			title = "Расход"
+		}
+		text.WriteString(fmt.Sprintf("%s — <b>%s</b> %s (%s)\n",
+			e.CreatedAt.Format("02.01"), html.EscapeString(title),
+			formatMoney(e.Amount, g.Group().Currency), html.EscapeString(names[e.PayerID])))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = tgbotapi.ModeHTML
+	b.send(chatID, msg)
+}
+
+func (b *Bot) handleGroupCallback(q *tgbotapi.CallbackQuery) {
+	chatID := q.Message.Chat.ID
+	data := q.Data
+
+	g, err := service.NewGroupService(b.repo, chatID, q.Message.Chat.Title)
+	if err != nil {
+		logger.Error("Failed to load group chat", "chat_id", chatID, "error", err)
+		return
+	}
+	requester, err := g.EnsureMember(q.From.ID, q.From.UserName, q.From.FirstName)
+	if err != nil {
+		logger.Error("Failed to register group member", "chat_id", chatID, "user_id", q.From.ID, "error", err)
+		return
+	}
+
+	alert := func(text string) {
+		_, _ = b.bot.Request(tgbotapi.NewCallbackWithAlert(q.ID, text))
+	}
+
+	switch {
+	case data == CallbackGroupBalance:
+		_, _ = b.bot.Request(tgbotapi.NewCallback(q.ID, ""))
+		b.refreshGroupBalance(chatID, q.Message.MessageID, g)
+
+	case strings.HasPrefix(data, CallbackGroupUndo):
+		id, _ := strconv.Atoi(data[len(CallbackGroupUndo):])
+		if _, err := g.DeleteExpense(id, q.From.ID); err != nil {
+			alert(err.Error())
+			return
+		}
+		_, _ = b.bot.Request(tgbotapi.NewCallback(q.ID, "Расход отменён"))
+		edit := tgbotapi.NewEditMessageText(chatID, q.Message.MessageID, "↩️ Расход отменён")
+		b.send(chatID, edit)
+
+	case strings.HasPrefix(data, CallbackGroupSettle):
+		parts := strings.Split(data[len(CallbackGroupSettle):], "_")
+		if len(parts) != 3 {
+			alert("Неверные данные")
+			return
+		}
+		from, _ := strconv.Atoi(parts[0])
+		to, _ := strconv.Atoi(parts[1])
+		cents, _ := strconv.ParseInt(parts[2], 10, 64)
+
+		if requester.ID != from && requester.ID != to {
+			alert("Подтвердить перевод может только отправитель или получатель")
+			return
+		}
+		amount, err := g.SettleTransfer(from, to, float64(cents)/100)
+		if err != nil {
+			alert(err.Error())
+			b.refreshGroupBalance(chatID, q.Message.MessageID, g)
+			return
+		}
+		_, _ = b.bot.Request(tgbotapi.NewCallback(q.ID, fmt.Sprintf("Перевод %s записан", formatMoney(amount, g.Group().Currency))))
+		b.refreshGroupBalance(chatID, q.Message.MessageID, g)
+
+	default:
+		_, _ = b.bot.Request(tgbotapi.NewCallback(q.ID, ""))
+	}
+}
+
+func (b *Bot) refreshGroupBalance(chatID int64, messageID int, g *service.GroupService) {
+	text, markup := b.groupBalanceView(g)
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, text, markup)
+	edit.ParseMode = tgbotapi.ModeHTML
+	b.send(chatID, edit)
+}
@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/logger"
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+	"github.com/IlyaMakar/finance_bot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	CallbackHousehold           = "household"
+	CallbackHouseholdCreate     = "household_create"
+	CallbackHouseholdJoin       = "household_join"
+	CallbackHouseholdInvite     = "household_invite"
+	CallbackHouseholdRegenerate = "household_regen"
+	CallbackHouseholdLeave      = "household_leave"
+	CallbackHouseholdDissolve   = "household_dissolve"
+	CallbackHouseholdDissolveOK = "household_dissolve_yes"
+	CallbackHouseholdRemove     = "household_remove_"
+	CallbackHouseholdScope      = "household_scope_"
+	CallbackHouseholdReport     = "household_report_"
+
+	householdInvitePrefix = "join_"
+)
+
+func (b *Bot) handleHouseholdCallback(chatID int64, messageID int, data string, svc *service.FinanceService) bool {
+	switch {
+	case data == CallbackHousehold:
+		b.deleteMessage(chatID, messageID)
+		b.showHousehold(chatID, svc)
+
+	case data == CallbackHouseholdCreate:
+		userStates[chatID] = UserState{Step: "enter_household_name"}
+		b.deleteMessage(chatID, messageID)
+		b.send(chatID, tgbotapi.NewMessage(chatID, "🏠 Введите название семьи, например «Семья Ивановых»:"))
+
+	case data == CallbackHouseholdJoin:
+		userStates[chatID] = UserState{Step: "enter_household_code"}
+		b.deleteMessage(chatID, messageID)
+		b.send(chatID, tgbotapi.NewMessage(chatID, "🔑 Введите код приглашения:"))
+
+	case data == CallbackHouseholdInvite:
+		b.sendHouseholdInvite(chatID, svc)
+
+	case data == CallbackHouseholdRegenerate:
+		if _, err := svc.RegenerateInviteCode(); err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		b.send(chatID, tgbotapi.NewMessage(chatID, "🔄 Код обновлён, старое приглашение больше не действует"))
+		b.sendHouseholdInvite(chatID, svc)
+
+	case data == CallbackHouseholdLeave:
+		household := svc.Household()
+		if err := svc.LeaveHousehold(); err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		b.deleteMessage(chatID, messageID)
+		b.send(chatID, tgbotapi.NewMessage(chatID, fmt.Sprintf("👋 Вы вышли из семьи «%s». Теперь вы ведёте личный бюджет.", household.Name)))
+		b.notifyHouseholdOwner(household, fmt.Sprintf("👋 %s вышел(а) из семьи", b.memberName(chatID)))
+
+	case data == CallbackHouseholdDissolve:
+		msg := tgbotapi.NewMessage(chatID, "⚠️ Распустить семью? Участники потеряют доступ к общему бюджету, ваши данные останутся у вас.")
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("✅ Да, распустить", CallbackHouseholdDissolveOK),
+				tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", CallbackHousehold),
+			),
+		)
+		b.deleteMessage(chatID, messageID)
+		b.send(chatID, msg)
+
+	case data == CallbackHouseholdDissolveOK:
+		household := svc.Household()
+		members, err := svc.GetHouseholdMembers()
+		if err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		if err := svc.DissolveHousehold(); err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		for _, m := range members {
+			if m.Role == repository.HouseholdRoleMember {
+				b.send(m.TelegramID, tgbotapi.NewMessage(m.TelegramID,
+					fmt.Sprintf("🏠 Семья «%s» распущена владельцем. Теперь вы ведёте личный бюджет.", household.Name)))
+			}
+		}
+		b.deleteMessage(chatID, messageID)
+		b.send(chatID, tgbotapi.NewMessage(chatID, "🗑 Семья распущена"))
+		b.showSettingsMenu(chatID)
+
+	case strings.HasPrefix(data, CallbackHouseholdRemove):
+		userID, _ := strconv.Atoi(data[len(CallbackHouseholdRemove):])
+		members, err := svc.GetHouseholdMembers()
+		if err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		if err := svc.RemoveHouseholdMember(userID); err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		for _, m := range members {
+			if m.UserID == userID {
+				b.send(m.TelegramID, tgbotapi.NewMessage(m.TelegramID,
+					fmt.Sprintf("🏠 Владелец исключил вас из семьи «%s». Теперь вы ведёте личный бюджет.", svc.Household().Name)))
+			}
+		}
+		b.deleteMessage(chatID, messageID)
+		b.showHousehold(chatID, svc)
+
+	case strings.HasPrefix(data, CallbackHouseholdScope):
+		if err := svc.SetReportScope(data[len(CallbackHouseholdScope):]); err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		b.deleteMessage(chatID, messageID)
+		b.showHousehold(chatID, svc)
+
+	case strings.HasPrefix(data, CallbackHouseholdReport):
+		parts := strings.Split(data[len(CallbackHouseholdReport):], "_")
+		if len(parts) != 2 {
+			b.sendError(chatID, fmt.Errorf("неверный формат даты"))
+			return true
+		}
+		start, err1 := time.ParseInLocation("2006-01-02", parts[0], svc.Location())
+		last, err2 := time.ParseInLocation("2006-01-02", parts[1], svc.Location())
+		if err1 != nil || err2 != nil {
+			b.sendError(chatID, fmt.Errorf("ошибка парсинга даты"))
+			return true
+		}
+
+		scope := repository.ReportScopePersonal
+		if svc.PersonalScope() {
+			scope = repository.ReportScopeHousehold
+		}
+		if err := svc.SetReportScope(scope); err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+
+		b.deleteMessage(chatID, messageID)
+		b.generatePeriodReport(chatID, svc, start, last.AddDate(0, 0, 1), rangeLabel(start, last))
+
+	default:
+		return false
+	}
+	return true
+}
+
+func (b *Bot) showHousehold(chatID int64, svc *service.FinanceService) {
+	household := svc.Household()
+	if household == nil {
+		msg := tgbotapi.NewMessage(chatID, "👨‍👩‍👧 <b>Семейный бюджет</b>\n\n"+
+			"Ведите общий бюджет вместе с близкими: общие категории, операции, копилки и счета. "+
+			"В отчётах видно, кто внёс каждую операцию.\n\n"+
+			"Создайте семью и отправьте приглашение или введите код, который вам прислали.")
+		msg.ParseMode = tgbotapi.ModeHTML
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("➕ Создать семью", CallbackHouseholdCreate)),
+			tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("🔑 Ввести код", CallbackHouseholdJoin)),
+			tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", "show_settings")),
+		)
+		b.send(chatID, msg)
+		return
+	}
+
+	members, err := svc.GetHouseholdMembers()
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("👨‍👩‍👧 <b>%s</b>\n\n", html.EscapeString(household.Name)))
+	text.WriteString("<b>Участники:</b>\n")
+	for _, m := range members {
+		role := "участник"
+		if m.Role == repository.HouseholdRoleOwner {
+			role = "владелец"
+		}
+		text.WriteString(fmt.Sprintf("┣ %s — %s, с %s\n", html.EscapeString(m.DisplayName()), role, m.JoinedAt.Format("02.01.2006")))
+	}
+
+	scopeLabel := "👨‍👩‍👧 вся семья"
+	nextScope, nextLabel := repository.ReportScopePersonal, "👤 Отчёты: только мои"
+	if svc.PersonalScope() {
+		scopeLabel = "👤 только мои операции"
+		nextScope, nextLabel = repository.ReportScopeHousehold, "👨‍👩‍👧 Отчёты: вся семья"
+	}
+	text.WriteString(fmt.Sprintf("\n📊 Отчёты: %s", scopeLabel))
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if svc.IsHouseholdOwner() {
+		rows = append(rows,
+			tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("📨 Пригласить", CallbackHouseholdInvite)),
+		)
+		for _, m := range members {
+			if m.Role == repository.HouseholdRoleMember {
+				rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+					"❌ Исключить "+m.DisplayName(), fmt.Sprintf("%s%d", CallbackHouseholdRemove, m.UserID))))
+			}
+		}
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(nextLabel, CallbackHouseholdScope+nextScope)))
+	if svc.IsHouseholdOwner() {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("🗑 Распустить семью", CallbackHouseholdDissolve)))
+	} else {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("🚪 Выйти из семьи", CallbackHouseholdLeave)))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", "show_settings")))
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) sendHouseholdInvite(chatID int64, svc *service.FinanceService) {
+	household := svc.Household()
+	if !svc.IsHouseholdOwner() {
+		b.sendError(chatID, fmt.Errorf("приглашать может только владелец семьи"))
+		return
+	}
+
+	link := fmt.Sprintf("https://t.me/%s?start=%s%s", b.bot.Self.UserName, householdInvitePrefix, household.InviteCode)
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf(
+		"📨 <b>Приглашение в «%s»</b>\n\nОтправьте ссылку тому, с кем хотите вести бюджет:\n%s\n\nИли код для ввода вручную: <code>%s</code>",
+		html.EscapeString(household.Name), link, household.InviteCode))
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.DisableWebPagePreview = true
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔄 Новый код", CallbackHouseholdRegenerate),
+			tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", CallbackHousehold),
+		),
+	)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) handleHouseholdName(m *tgbotapi.Message, svc *service.FinanceService) {
+	if err := svc.CreateHousehold(m.Text); err != nil {
+		b.sendError(m.Chat.ID, err)
+		return
+	}
+	delete(userStates, m.From.ID)
+
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "✅ Семья создана! Пригласите участников — им будут доступны ваши категории, операции, копилки и счета."))
+	b.sendHouseholdInvite(m.Chat.ID, svc)
+}
+
+func (b *Bot) handleHouseholdCode(m *tgbotapi.Message, svc *service.FinanceService) {
+	delete(userStates, m.From.ID)
+	b.joinHousehold(m.Chat.ID, m.Text, svc)
+}
+
+func (b *Bot) joinHousehold(chatID int64, code string, svc *service.FinanceService) {
+	household, err := svc.JoinHousehold(code)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	logger.Info("User joined household", "chat_id", chatID, "household_id", household.ID)
+	b.sendMainMenu(chatID, fmt.Sprintf(
+		"🎉 Вы присоединились к семье «%s»!\n\nТеперь категории, операции, копилки и счета общие. Ваш личный бюджет сохранён и вернётся, если вы выйдете из семьи.",
+		household.Name))
+	b.notifyHouseholdOwner(household, fmt.Sprintf("🎉 %s присоединился(ась) к семье «%s»", b.memberName(chatID), household.Name))
+}
+
+func (b *Bot) notifyHouseholdOwner(household *repository.Household, text string) {
+	members, err := b.repo.GetHouseholdMembers(household.ID)
+	if err != nil {
+		logger.Error("Failed to load household members", "household_id", household.ID, "error", err)
+		return
+	}
+	for _, m := range members {
+		if m.Role == repository.HouseholdRoleOwner {
+			b.send(m.TelegramID, tgbotapi.NewMessage(m.TelegramID, text))
+		}
+	}
+}
+
+func (b *Bot) memberName(chatID int64) string {
+	user, err := b.repo.GetOrCreateUser(chatID, "", "", "")
+	if err != nil {
+		return "Участник"
+	}
+	m := repository.HouseholdMember{UserID: user.ID, Username: user.Username, FirstName: user.FirstName}
+	return m.DisplayName()
+}
+
+func householdMemberNames(svc *service.FinanceService) map[int]string {
+	members, err := svc.GetHouseholdMembers()
+	if err != nil || len(members) < 2 {
+		return nil
+	}
+
+	names := make(map[int]string, len(members))
+	for _, m := range members {
+		names[m.UserID] = m.DisplayName()
+	}
+	return names
+}
+
+func (b *Bot) writeHouseholdReportSection(text *strings.Builder, chatID int64, svc *service.FinanceService, start, end time.Time) {
+	household := svc.Household()
+	if household == nil {
+		return
+	}
+
+	if svc.PersonalScope() {
+		text.WriteString(fmt.Sprintf("\n\n👤 Только ваши операции в семье «%s»", html.EscapeString(household.Name)))
+		return
+	}
+
+	totals, err := svc.GetHouseholdMemberTotals(start, end)
+	if err != nil {
+		logger.Error("Failed to load household totals", "chat_id", chatID, "error", err)
+		return
+	}
+	if len(totals) < 2 {
+		return
+	}
+
+	text.WriteString("\n\n👨‍👩‍👧 <b>По участникам:</b>\n")
+	for _, t := range totals {
+		text.WriteString(fmt.Sprintf("┣ %s: доходы %s, расходы %s\n",
+			html.EscapeString(t.Member.DisplayName()),
+			b.formatCurrency(t.Income, chatID),
+			b.formatCurrency(t.Expense, chatID)))
+	}
+}
+
+func householdReportButton(svc *service.FinanceService, start, end time.Time) []tgbotapi.InlineKeyboardButton {
+	if svc.Household() == nil {
+		return nil
+	}
+
+	label := "👤 Только мои"
+	if svc.PersonalScope() {
+		label = "👨‍👩‍👧 Вся семья"
+	}
+	last := end.Add(-time.Nanosecond)
+	return tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(label,
+		fmt.Sprintf("%s%s_%s", CallbackHouseholdReport, start.Format("2006-01-02"), last.Format("2006-01-02"))))
+}
+
+func (rg *ReportGenerator) addHouseholdSection(pdf *gofpdf.Fpdf, start, end time.Time, svc *service.FinanceService, loc reportLocale) error {
+	household := svc.Household()
+	if household == nil {
+		return nil
+	}
+
+	pdf.Ln(10)
+	pdf.SetFont("DejaVuSans", "B", 14)
+	pdf.CellFormat(190, 10, loc.textf("household", removeEmoji(household.Name)), "", 1, "L", false, 0, "")
+	pdf.SetFont("DejaVuSans", "", 11)
+
+	if svc.PersonalScope() {
+		pdf.CellFormat(190, 6, loc.text("household_personal"), "", 1, "L", false, 0, "")
+		return nil
+	}
+
+	totals, err := svc.GetHouseholdMemberTotals(start, end)
+	if err != nil {
+		return err
+	}
+	for _, t := range totals {
+		pdf.CellFormat(190, 6, loc.textf("household_member",
+			removeEmoji(t.Member.DisplayName()), loc.money(t.Income), loc.money(t.Expense), t.Count), "", 1, "L", false, 0, "")
+	}
+	return nil
+}
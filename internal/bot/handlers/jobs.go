@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+	"github.com/IlyaMakar/finance_bot/internal/scheduler"
+)
+
+const (
+	JobReminderPlanner = "reminder_planner"
+	JobReminder        = "reminder"
+	JobUpdateNotice    = "update_notice"
+	JobBillPlanner     = "bill_planner"
+	JobBillReminder    = "bill_reminder"
+	JobBroadcast       = "broadcast"
+	JobDailyPlanner    = "daily_planner"
+	JobDailyTask       = "daily_task"
+	JobDigestPlanner   = "digest_planner"
+	JobDigest          = "digest"
+	JobYearPlanner     = "year_review_planner"
+	JobYearReview      = "year_review"
+)
+
+func (b *Bot) RegisterJobs(jobs *scheduler.Scheduler, testMode bool) error {
+	loc, err := time.LoadLocation(repository.DefaultTimezone)
+	if err != nil {
+		return err
+	}
+
+	b.jobs = jobs
+	jobs.Register(JobReminderPlanner, b.reminderPlanner(testMode))
+	jobs.Register(JobReminder, b.runReminder(testMode))
+	jobs.Register(JobUpdateNotice, b.runUpdateNotice)
+	jobs.Register(JobBillPlanner, b.billPlanner)
+	jobs.Register(JobBillReminder, b.runBillReminder)
+	jobs.Register(JobBroadcast, b.runBroadcast)
+	jobs.Register(JobDailyPlanner, b.dailyPlanner(loc, testMode))
+	jobs.Register(JobDailyTask, b.runDailyTask)
+	jobs.Register(JobDigestPlanner, b.digestPlanner)
+	jobs.Register(JobDigest, b.runDigest)
+	jobs.Register(JobYearPlanner, b.yearReviewPlanner)
+	jobs.Register(JobYearReview, b.runYearReview)
+
+	plannerInterval := time.Hour
+	if testMode {
+		plannerInterval = time.Minute
+	}
+	if err := jobs.Every(JobReminderPlanner, time.Minute); err != nil {
+		return err
+	}
+	if err := jobs.Every(JobBillPlanner, time.Hour); err != nil {
+		return err
+	}
+	if err := jobs.Every(JobDailyPlanner, plannerInterval); err != nil {
+		return err
+	}
+	if err := jobs.Every(JobDigestPlanner, plannerInterval); err != nil {
+		return err
+	}
+	return jobs.Every(JobYearPlanner, plannerInterval)
+}
@@ -0,0 +1,518 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+	"github.com/IlyaMakar/finance_bot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	CallbackLoans          = "loans"
+	CallbackLoanNew        = "loan_new"
+	CallbackLoanType       = "loan_type_"
+	CallbackLoanIssueToday = "loan_issue_today"
+	CallbackLoanView       = "loan_view_"
+	CallbackLoanPay        = "loan_pay_"
+	CallbackLoanEarly      = "loan_early_"
+	CallbackLoanMode       = "loan_mode_"
+	CallbackLoanSchedule   = "loan_schedule_"
+	CallbackLoanDelete     = "loan_delete_"
+
+	loanScheduleRows = 24
+)
+
+var loanTypeLabels = map[string]string{
+	repository.LoanAnnuity:        "аннуитетный",
+	repository.LoanDifferentiated: "дифференцированный",
+}
+
+func (b *Bot) handleLoanCallback(chatID int64, messageID int, data string, svc *service.FinanceService) bool {
+	switch {
+	case data == CallbackLoans:
+		b.deleteMessage(chatID, messageID)
+		b.showLoans(chatID, svc)
+
+	case data == CallbackLoanNew:
+		userStates[chatID] = UserState{Step: "enter_loan_name"}
+		b.deleteMessage(chatID, messageID)
+		b.send(chatID, tgbotapi.NewMessage(chatID, "🏦 Введите название кредита (например, Ипотека):"))
+
+	case data == CallbackLoanIssueToday:
+		editMsg := tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, tgbotapi.InlineKeyboardMarkup{})
+		b.bot.Send(editMsg)
+		now := svc.Now()
+		b.finishCreateLoan(chatID, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()), svc)
+
+	case strings.HasPrefix(data, CallbackLoanType):
+		state := userStates[chatID]
+		state.TempType = data[len(CallbackLoanType):]
+		state.Step = "enter_loan_issue"
+		userStates[chatID] = state
+
+		b.deleteMessage(chatID, messageID)
+		msg := tgbotapi.NewMessage(chatID, "📅 Введите дату выдачи кредита (ДД.ММ.ГГГГ). Первый платёж — через месяц после выдачи:")
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("Сегодня", CallbackLoanIssueToday),
+			),
+		)
+		b.send(chatID, msg)
+
+	case strings.HasPrefix(data, CallbackLoanView):
+		loanID, _ := strconv.Atoi(data[len(CallbackLoanView):])
+		b.deleteMessage(chatID, messageID)
+		b.showLoan(chatID, loanID, svc)
+
+	case strings.HasPrefix(data, CallbackLoanPay):
+		loanID, _ := strconv.Atoi(data[len(CallbackLoanPay):])
+		b.deleteMessage(chatID, messageID)
+		b.payLoanInstallment(chatID, loanID, svc)
+
+	case strings.HasPrefix(data, CallbackLoanEarly):
+		loanID, _ := strconv.Atoi(data[len(CallbackLoanEarly):])
+		userStates[chatID] = UserState{Step: "enter_loan_early", TempCategoryID: loanID}
+		b.send(chatID, tgbotapi.NewMessage(chatID, "⚡ Введите сумму досрочного погашения:"))
+
+	case strings.HasPrefix(data, CallbackLoanMode):
+		parts := strings.Split(data[len(CallbackLoanMode):], "_")
+		if len(parts) != 2 {
+			b.sendError(chatID, fmt.Errorf("неверный формат досрочного погашения"))
+			return true
+		}
+		loanID, _ := strconv.Atoi(parts[1])
+		state := userStates[chatID]
+		delete(userStates, chatID)
+		b.deleteMessage(chatID, messageID)
+		b.earlyRepayLoan(chatID, loanID, state.TempAmount, parts[0], svc)
+
+	case strings.HasPrefix(data, CallbackLoanSchedule):
+		loanID, _ := strconv.Atoi(data[len(CallbackLoanSchedule):])
+		b.deleteMessage(chatID, messageID)
+		b.showLoanSchedule(chatID, loanID, svc)
+
+	case strings.HasPrefix(data, CallbackLoanDelete):
+		loanID, _ := strconv.Atoi(data[len(CallbackLoanDelete):])
+		if err := svc.DeleteLoan(loanID); err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		b.deleteMessage(chatID, messageID)
+		b.send(chatID, tgbotapi.NewMessage(chatID, "🗑 Кредит удалён"))
+		b.showLoans(chatID, svc)
+
+	default:
+		return false
+	}
+	return true
+}
+
+func (b *Bot) showLoans(chatID int64, svc *service.FinanceService) {
+	loans, err := svc.GetLoans()
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString("🏦 <b>Кредиты</b>\n\n")
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if len(loans) == 0 {
+		text.WriteString("У вас нет активных кредитов. Добавьте ипотеку или кредит, чтобы видеть график платежей и остаток долга.")
+	}
+
+	var totalBalance float64
+	for i := range loans {
+		loan := loans[i]
+		state, err := svc.GetLoanState(&loan)
+		if err != nil {
+			b.sendError(chatID, err)
+			return
+		}
+		totalBalance += state.Balance
+
+		text.WriteString(fmt.Sprintf("<b>%s</b>\n", html.EscapeString(loan.Name)))
+		text.WriteString(fmt.Sprintf("┣ Остаток долга: %s\n", b.formatCurrency(state.Balance, chatID)))
+		if !state.Closed {
+			text.WriteString(fmt.Sprintf("┗ Следующий платёж: %s — %s\n\n",
+				state.NextDate.Format("02.01.2006"), b.formatCurrency(state.Payment, chatID)))
+		}
+
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🏦 "+loan.Name, fmt.Sprintf("%s%d", CallbackLoanView, loan.ID)),
+		))
+	}
+
+	if len(loans) > 1 {
+		text.WriteString(fmt.Sprintf("💼 Всего задолженность: %s", b.formatCurrency(totalBalance, chatID)))
+	}
+
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("➕ Новый кредит", CallbackLoanNew),
+			tgbotapi.NewInlineKeyboardButtonData("💳 Кредитные карты", CallbackCreditCards),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("◀️ Главное меню", "main_menu"),
+		),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) showLoan(chatID int64, loanID int, svc *service.FinanceService) {
+	loan, err := svc.GetLoanByID(loanID)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	state, err := svc.GetLoanState(loan)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("🏦 <b>%s</b>\n\n", html.EscapeString(loan.Name)))
+	text.WriteString(fmt.Sprintf("┣ Сумма кредита: %s\n", b.formatCurrency(loan.Principal, chatID)))
+	text.WriteString(fmt.Sprintf("┣ Ставка: %.2f%% годовых\n", loan.Rate))
+	text.WriteString(fmt.Sprintf("┣ Срок: %d мес., платёж %s\n", loan.TermMonths, loanTypeLabels[loan.ScheduleType]))
+	text.WriteString(fmt.Sprintf("┣ Выдан: %s\n", loan.IssueDate.Format("02.01.2006")))
+	text.WriteString(fmt.Sprintf("┣ Выплачено долга: %s\n", b.formatCurrency(state.PaidPrincipal, chatID)))
+	text.WriteString(fmt.Sprintf("┣ Выплачено процентов: %s\n", b.formatCurrency(state.PaidInterest, chatID)))
+	text.WriteString(fmt.Sprintf("┗ Остаток долга: %s\n", b.formatCurrency(state.Balance, chatID)))
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if state.Closed {
+		text.WriteString("\n🎉 Кредит полностью погашен!")
+	} else {
+		text.WriteString(fmt.Sprintf("\n📅 <b>Следующий платёж %s</b>\n", state.NextDate.Format("02.01.2006")))
+		text.WriteString(fmt.Sprintf("┣ Всего: %s\n", b.formatCurrency(state.Payment, chatID)))
+		text.WriteString(fmt.Sprintf("┣ Основной долг: %s\n", b.formatCurrency(state.NextPrincipal, chatID)))
+		text.WriteString(fmt.Sprintf("┗ Проценты: %s\n", b.formatCurrency(state.NextInterest, chatID)))
+		text.WriteString(fmt.Sprintf("\n⏳ Осталось платежей: %d (до %s)\n", state.MonthsLeft, state.EndDate.Format("01.2006")))
+		text.WriteString(fmt.Sprintf("💸 Проценты до конца срока: %s", b.formatCurrency(state.RemainingInterest, chatID)))
+
+		rows = append(rows,
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("💳 Внести платёж", fmt.Sprintf("%s%d", CallbackLoanPay, loan.ID)),
+				tgbotapi.NewInlineKeyboardButtonData("⚡ Досрочно", fmt.Sprintf("%s%d", CallbackLoanEarly, loan.ID)),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("📋 График платежей", fmt.Sprintf("%s%d", CallbackLoanSchedule, loan.ID)),
+			),
+		)
+	}
+
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🗑 Удалить", fmt.Sprintf("%s%d", CallbackLoanDelete, loan.ID)),
+			tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", CallbackLoans),
+		),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) showLoanSchedule(chatID int64, loanID int, svc *service.FinanceService) {
+	loan, err := svc.GetLoanByID(loanID)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	schedule, err := svc.GetLoanSchedule(loan)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("📋 <b>График платежей «%s»</b>\n\n", html.EscapeString(loan.Name)))
+	text.WriteString("<pre>")
+	text.WriteString(fmt.Sprintf("%-4s %-8s %10s %10s %12s\n", "№", "Дата", "Долг", "Проценты", "Остаток"))
+	for i, row := range schedule {
+		if i == loanScheduleRows {
+			break
+		}
+		text.WriteString(fmt.Sprintf("%-4d %-8s %10.2f %10.2f %12.2f\n",
+			row.Number, row.Date.Format("01.2006"), row.Principal, row.Interest, row.Balance))
+	}
+	text.WriteString("</pre>")
+
+	if len(schedule) > loanScheduleRows {
+		text.WriteString(fmt.Sprintf("\n… и ещё %d платежей до %s", len(schedule)-loanScheduleRows, schedule[len(schedule)-1].Date.Format("01.2006")))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", fmt.Sprintf("%s%d", CallbackLoanView, loan.ID)),
+		),
+	)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) payLoanInstallment(chatID int64, loanID int, svc *service.FinanceService) {
+	payment, state, err := svc.PayLoanInstallment(loanID)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	text := fmt.Sprintf("✅ Платёж внесён\n\n┣ Основной долг: %s\n┗ Проценты: %s",
+		b.formatCurrency(payment.Principal, chatID), b.formatCurrency(payment.Interest, chatID))
+	if payment.PrincipalTransactionID != nil || payment.TransactionID != nil {
+		text += "\n\nПлатёж записан в операции: основной долг — переводом в «🏦 Погашение кредита» (не входит в расходы), проценты — расходом в «🏦 Проценты по кредиту»."
+	}
+	if state.Closed {
+		text += "\n\n🎉 Кредит полностью погашен!"
+	}
+
+	b.send(chatID, tgbotapi.NewMessage(chatID, text))
+	b.showLoan(chatID, loanID, svc)
+}
+
+func (b *Bot) handleLoanName(m *tgbotapi.Message) {
+	name := strings.TrimSpace(m.Text)
+	if name == "" {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Название не может быть пустым. Попробуйте снова:"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	state.Step = "enter_loan_amount"
+	state.TempComment = name
+	userStates[m.From.ID] = state
+
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "💰 Введите сумму кредита:"))
+}
+
+func (b *Bot) handleLoanAmount(m *tgbotapi.Message) {
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(m.Text), ",", "."), 64)
+	if err != nil || amount <= 0 {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите корректную сумму (например, 3500000):"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	state.Step = "enter_loan_rate"
+	state.TempAmount = amount
+	userStates[m.From.ID] = state
+
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "📈 Введите годовую ставку в процентах (например, 11.5):"))
+}
+
+func (b *Bot) handleLoanRate(m *tgbotapi.Message) {
+	rate, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSuffix(strings.TrimSpace(m.Text), "%"), ",", "."), 64)
+	if err != nil || rate < 0 || rate > 100 {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите ставку от 0 до 100:"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	state.Step = "enter_loan_term"
+	state.TempRate = rate
+	userStates[m.From.ID] = state
+
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⏳ Введите срок кредита в месяцах (например, 240):"))
+}
+
+func (b *Bot) handleLoanTerm(m *tgbotapi.Message) {
+	term, err := strconv.Atoi(strings.TrimSpace(m.Text))
+	if err != nil || term <= 0 {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите срок целым числом месяцев:"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	state.Step = ""
+	state.TempTerm = term
+	userStates[m.From.ID] = state
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, "📊 Выберите тип платежей:\n\n"+
+		"• Аннуитетный — одинаковый платёж каждый месяц\n"+
+		"• Дифференцированный — платёж уменьшается к концу срока")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Аннуитетный", CallbackLoanType+repository.LoanAnnuity),
+			tgbotapi.NewInlineKeyboardButtonData("Дифференцированный", CallbackLoanType+repository.LoanDifferentiated),
+		),
+	)
+	b.send(m.Chat.ID, msg)
+}
+
+func (b *Bot) handleLoanIssueDate(m *tgbotapi.Message, svc *service.FinanceService) {
+	date, err := parseUserDate(m.Text, svc.Location())
+	if err != nil {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите дату в формате ДД.ММ.ГГГГ:"))
+		return
+	}
+
+	b.finishCreateLoan(m.Chat.ID, date, svc)
+}
+
+func (b *Bot) finishCreateLoan(chatID int64, issueDate time.Time, svc *service.FinanceService) {
+	state, ok := userStates[chatID]
+	if !ok || state.TempComment == "" {
+		b.sendError(chatID, fmt.Errorf("данные кредита не найдены, начните заново"))
+		return
+	}
+
+	id, err := svc.CreateLoan(state.TempComment, state.TempAmount, state.TempRate, state.TempTerm, state.TempType, issueDate)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	delete(userStates, chatID)
+	b.send(chatID, tgbotapi.NewMessage(chatID, "✅ Кредит добавлен!"))
+	b.showLoan(chatID, id, svc)
+}
+
+func (b *Bot) handleLoanEarlyAmount(m *tgbotapi.Message, svc *service.FinanceService) {
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(m.Text), ",", "."), 64)
+	if err != nil || amount <= 0 {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите корректную сумму (например, 100000):"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	loan, err := svc.GetLoanByID(state.TempCategoryID)
+	if err != nil {
+		delete(userStates, m.From.ID)
+		b.sendError(m.Chat.ID, err)
+		return
+	}
+
+	byTerm, err := svc.PreviewEarlyRepayment(loan, amount, repository.EarlyReduceTerm)
+	if err != nil {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ "+err.Error()+". Введите другую сумму:"))
+		return
+	}
+	byPayment, err := svc.PreviewEarlyRepayment(loan, amount, repository.EarlyReducePayment)
+	if err != nil {
+		b.sendError(m.Chat.ID, err)
+		return
+	}
+
+	state.TempAmount = amount
+	state.Step = ""
+	userStates[m.From.ID] = state
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("⚡ <b>Досрочное погашение %s</b>\n\n", b.formatCurrency(amount, m.Chat.ID)))
+	if byTerm.Closed {
+		text.WriteString("Этой суммы хватит, чтобы полностью закрыть кредит 🎉")
+	} else {
+		text.WriteString(fmt.Sprintf("📉 <b>Сократить срок:</b> платёж %s, осталось %d мес. (до %s), проценты %s\n\n",
+			b.formatCurrency(byTerm.Payment, m.Chat.ID), byTerm.MonthsLeft, byTerm.EndDate.Format("01.2006"),
+			b.formatCurrency(byTerm.RemainingInterest, m.Chat.ID)))
+		text.WriteString(fmt.Sprintf("💳 <b>Уменьшить платёж:</b> платёж %s, осталось %d мес., проценты %s",
+			b.formatCurrency(byPayment.Payment, m.Chat.ID), byPayment.MonthsLeft,
+			b.formatCurrency(byPayment.RemainingInterest, m.Chat.ID)))
+	}
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, text.String())
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📉 Сократить срок", fmt.Sprintf("%s%s_%d", CallbackLoanMode, repository.EarlyReduceTerm, loan.ID)),
+			tgbotapi.NewInlineKeyboardButtonData("💳 Уменьшить платёж", fmt.Sprintf("%s%s_%d", CallbackLoanMode, repository.EarlyReducePayment, loan.ID)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", fmt.Sprintf("%s%d", CallbackLoanView, loan.ID)),
+		),
+	)
+	b.send(m.Chat.ID, msg)
+}
+
+func (b *Bot) earlyRepayLoan(chatID int64, loanID int, amount float64, mode string, svc *service.FinanceService) {
+	state, err := svc.EarlyRepayLoan(loanID, amount, mode)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	text := fmt.Sprintf("✅ Досрочное погашение %s записано переводом в «🏦 Погашение кредита» (не входит в расходы)", b.formatCurrency(amount, chatID))
+	switch {
+	case state.Closed:
+		text += "\n\n🎉 Кредит полностью погашен!"
+	case mode == repository.EarlyReduceTerm:
+		text += fmt.Sprintf("\n\nНовый срок: %d мес. (до %s)", state.MonthsLeft, state.EndDate.Format("01.2006"))
+	default:
+		text += fmt.Sprintf("\n\nНовый платёж: %s", b.formatCurrency(state.Payment, chatID))
+	}
+
+	b.send(chatID, tgbotapi.NewMessage(chatID, text))
+	b.showLoan(chatID, loanID, svc)
+}
+
+func (rg *ReportGenerator) addLoansSection(pdf *gofpdf.Fpdf, start, end time.Time, svc *service.FinanceService, loc reportLocale) error {
+	loans, err := svc.GetLoans()
+	if err != nil {
+		return err
+	}
+	if len(loans) == 0 {
+		return nil
+	}
+
+	pdf.Ln(10)
+	pdf.SetFont("DejaVuSans", "B", 14)
+	pdf.CellFormat(190, 10, loc.text("loans"), "", 1, "L", false, 0, "")
+	pdf.SetFont("DejaVuSans", "", 11)
+
+	for i := range loans {
+		loan := loans[i]
+		state, err := svc.GetLoanState(&loan)
+		if err != nil {
+			return err
+		}
+		payments, err := svc.GetLoanPayments(loan.ID)
+		if err != nil {
+			return err
+		}
+
+		var periodPrincipal, periodInterest float64
+		for _, p := range payments {
+			if p.Date.Before(start) || p.Date.After(end) {
+				continue
+			}
+			periodPrincipal += p.Principal
+			periodInterest += p.Interest
+		}
+
+		pdf.SetFont("DejaVuSans", "B", 12)
+		pdf.CellFormat(190, 8, removeEmoji(loan.Name), "", 1, "L", false, 0, "")
+		pdf.SetFont("DejaVuSans", "", 11)
+		loanType := loc.text("loan_annuity")
+		if loan.ScheduleType == repository.LoanDifferentiated {
+			loanType = loc.text("loan_differential")
+		}
+		pdf.CellFormat(190, 6, loc.textf("loan_principal", loc.money(loan.Principal), loan.Rate, loanType), "", 1, "L", false, 0, "")
+		pdf.CellFormat(190, 6, loc.textf("loan_balance", loc.money(state.Balance)), "", 1, "L", false, 0, "")
+		pdf.CellFormat(190, 6, loc.textf("loan_paid", loc.money(periodPrincipal), loc.money(periodInterest)), "", 1, "L", false, 0, "")
+		if !state.Closed {
+			pdf.CellFormat(190, 6, loc.textf("loan_next", loc.date(state.NextDate), loc.money(state.Payment)), "", 1, "L", false, 0, "")
+			pdf.CellFormat(190, 6, loc.textf("loan_left", state.MonthsLeft, loc.money(state.RemainingInterest)), "", 1, "L", false, 0, "")
+		}
+		pdf.Ln(3)
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"fmt"
+	"html"
 	"log"
 	"math"
 	"sort"
@@ -96,15 +97,24 @@ func (b *Bot) showSavingActions(chatID int64, savingID int, svc *service.Finance
 		progress := b.renderProgressBar(saving.Progress(), 10)
 		formattedGoal := b.formatCurrency(*saving.Goal, chatID)
 		msgText += fmt.Sprintf("\nЦель: %s (%s)", formattedGoal, progress)
+
+		plan, err := svc.GetSavingPlan(saving, svc.Now())
+		if err != nil {
+			log.Printf("Ошибка расчёта плана копилки: %v", err)
+		} else if plan != nil {
+			msgText += b.formatSavingPlan(plan, saving, chatID)
+		}
 	}
 
+	msgText += b.formatDepositInfo(saving, svc, chatID)
+
 	if saving.Comment != "" {
 		msgText += fmt.Sprintf("\nКомментарий: %s", saving.Comment)
 	}
 
 	msg := tgbotapi.NewMessage(chatID, msgText)
 	msg.ParseMode = "HTML"
-	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+	rows := [][]tgbotapi.InlineKeyboardButton{
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("➕ Пополнить", fmt.Sprintf("saving_add_%d", savingID)),
 			tgbotapi.NewInlineKeyboardButtonData("➖ Снять", fmt.Sprintf("saving_withdraw_%d", savingID)),
@@ -113,10 +123,29 @@ func (b *Bot) showSavingActions(chatID int64, savingID int, svc *service.Finance
 			tgbotapi.NewInlineKeyboardButtonData("✏️ Переименовать", fmt.Sprintf("saving_rename_%d", savingID)),
 			tgbotapi.NewInlineKeyboardButtonData("🗑 Удалить", fmt.Sprintf("saving_delete_%d", savingID)),
 		),
+	}
+
+	if saving.Goal != nil {
+		paceLabel := "🔁 Темп: по месяцам"
+		if saving.ContributionPeriod == service.ContributionWeekly {
+			paceLabel = "🔁 Темп: по неделям"
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📅 Срок цели", fmt.Sprintf("%s%d", CallbackSavingDeadline, savingID)),
+			tgbotapi.NewInlineKeyboardButtonData(paceLabel, fmt.Sprintf("%s%d", CallbackSavingPace, savingID)),
+		))
+	}
+
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🤖 Автопополнение", fmt.Sprintf("%s%d", CallbackSavingRules, savingID)),
+			tgbotapi.NewInlineKeyboardButtonData("🏦 Вклад", fmt.Sprintf("%s%d", CallbackSavingDeposit, savingID)),
+		),
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", "manage_savings"),
 		),
 	)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
 
 	b.send(chatID, msg)
 }
@@ -259,9 +288,11 @@ func (b *Bot) showSettingsMenu(chatID int64) {
 
 	keyboard := [][]tgbotapi.InlineKeyboardButton{
 		{tgbotapi.NewInlineKeyboardButtonData("🔔 Уведомления", "notification_settings")},
+		{tgbotapi.NewInlineKeyboardButtonData("🗞 Дайджесты", CallbackDigestSettings)},
 		{tgbotapi.NewInlineKeyboardButtonData("📝 Категории", "manage_categories")},
 		{tgbotapi.NewInlineKeyboardButtonData("📅 Период отчётов", CallbackSetPeriodStart)},
 		{tgbotapi.NewInlineKeyboardButtonData("💱 Валюта", CallbackCurrencySettings)},
+		{tgbotapi.NewInlineKeyboardButtonData("👨‍👩‍👧 Семейный бюджет", CallbackHousehold)},
 
 		{tgbotapi.NewInlineKeyboardButtonData("📝 Обратная связь", CallbackFeedback)},
 		{tgbotapi.NewInlineKeyboardButtonData("🆘 Поддержка", "support")},
@@ -287,13 +318,23 @@ func (b *Bot) showNotificationSettings(chatID int64) {
 		return
 	}
 
+	anomalies, err := svc.GetAnomalyAlertsEnabled()
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
 	status := "🔕 Отключены"
 	if enabled {
 		status = "🔔 Включены"
 	}
+	anomalyStatus, anomalyButton := "выключены", "📈 Включить оповещения о тратах"
+	if anomalies {
+		anomalyStatus, anomalyButton = "включены", "📉 Отключить оповещения о тратах"
+	}
 
 	msg := tgbotapi.NewMessage(chatID,
-		fmt.Sprintf("🔔 <b>Уведомления</b>\n\nТекущий статус: %s\n\nВыбери действие:", status))
+		fmt.Sprintf("🔔 <b>Уведомления</b>\n\nТекущий статус: %s\nОповещения о необычных тратах: %s\n\nВыбери действие:", status, anomalyStatus))
 	msg.ParseMode = "HTML"
 	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
 		[]tgbotapi.InlineKeyboardButton{
@@ -302,6 +343,12 @@ func (b *Bot) showNotificationSettings(chatID int64) {
 		[]tgbotapi.InlineKeyboardButton{
 			tgbotapi.NewInlineKeyboardButtonData("🔕 Отключить", "disable_notifications"),
 		},
+		[]tgbotapi.InlineKeyboardButton{
+			tgbotapi.NewInlineKeyboardButtonData("⏰ Время, дни и часовой пояс", CallbackReminderSettings),
+		},
+		[]tgbotapi.InlineKeyboardButton{
+			tgbotapi.NewInlineKeyboardButtonData(anomalyButton, CallbackAnomalyToggle),
+		},
 		[]tgbotapi.InlineKeyboardButton{
 			tgbotapi.NewInlineKeyboardButtonData("◀️ В меню", "settings_back"),
 		},
@@ -322,8 +369,8 @@ func (b *Bot) showCategoryManagement(chatID int64, svc *service.FinanceService)
 	}
 
 	var rows [][]tgbotapi.InlineKeyboardButton
-	for _, cat := range categories {
-		btnText := fmt.Sprintf("%s (%s)", cat.Name, cat.Type)
+	for _, cat := range categoryTree(categories) {
+		btnText := fmt.Sprintf("%s (%s)", categoryLabel(cat), cat.Type)
 		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData(btnText, CallbackEditCategory+strconv.Itoa(cat.ID)),
 		))
@@ -383,24 +430,19 @@ func (b *Bot) showCurrencyMenu(chatID int64) {
 func (b *Bot) formatCurrency(amount float64, chatID int64) string {
 	user, err := b.repo.GetOrCreateUser(chatID, "", "", "")
 	if err != nil {
-		return fmt.Sprintf("%.2f ₽", amount)
+		return formatMoney(amount, CurrencyRUB)
 	}
 
-	currency, err := b.repo.GetUserCurrency(user.ID)
+	currency, err := service.NewService(b.repo, user).GetCurrency()
 	if err != nil {
-		return fmt.Sprintf("%.2f ₽", amount)
+		return formatMoney(amount, CurrencyRUB)
 	}
 
-	switch currency {
-	case CurrencyRUB:
-		return fmt.Sprintf("%.2f ₽", amount)
-	case CurrencyUSD:
-		return fmt.Sprintf("$%.2f", amount)
-	case CurrencyEUR:
-		return fmt.Sprintf("€%.2f", amount)
-	default:
-		return fmt.Sprintf("%.2f %s", amount, currency)
-	}
+	return formatMoney(amount, currency)
+}
+
+func formatMoney(amount float64, currency string) string {
+	return formatAmount(amount, currency, LangRU)
 }
 
 func (b *Bot) getCurrencyCheckmark(current, selected string) string {
@@ -417,6 +459,11 @@ func (b *Bot) handleSetCurrency(chatID int64, currency string) {
 		return
 	}
 
+	if service.NewService(b.repo, user).IsHouseholdGuest() {
+		b.sendError(chatID, fmt.Errorf("валюту общего бюджета меняет владелец семьи"))
+		return
+	}
+
 	if err := b.repo.SetUserCurrency(user.ID, currency); err != nil {
 		b.sendError(chatID, err)
 		return
@@ -440,23 +487,60 @@ func (b *Bot) showCategoryActions(chatID int64, categoryID int, svc *service.Fin
 		return
 	}
 
-	msgText := fmt.Sprintf("📝 <b>Категория:</b> %s\n<b>Тип:</b> %s\n\nЧто сделать?", category.Name, category.Type)
+	var msgText strings.Builder
+	msgText.WriteString(fmt.Sprintf("📝 <b>Категория:</b> %s\n<b>Тип:</b> %s\n", category.Name, category.Type))
+	if category.ParentID != nil {
+		if parent, err := svc.GetCategoryByID(*category.ParentID); err == nil {
+			msgText.WriteString(fmt.Sprintf("<b>Родитель:</b> %s\n", parent.Name))
+		}
+	}
+	children, err := svc.GetSubcategories(categoryID)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+	if len(children) > 0 {
+		msgText.WriteString("<b>Подкатегории:</b>\n")
+		for _, c := range children {
+			msgText.WriteString(fmt.Sprintf("┣ %s\n", c.Name))
+		}
+	}
+	msgText.WriteString("\nЧто сделать?")
 
-	msg := tgbotapi.NewMessage(chatID, msgText)
-	msg.ParseMode = "HTML"
-	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+	rows := [][]tgbotapi.InlineKeyboardButton{
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("✏️ Переименовать", CallbackRenameCategory+strconv.Itoa(categoryID)),
 			tgbotapi.NewInlineKeyboardButtonData("🗑️ Удалить", CallbackDeleteCategory+strconv.Itoa(categoryID)),
 		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", "manage_categories"),
-		),
+	}
+	moveRow := tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("📂 Переместить", CallbackMoveCategory+strconv.Itoa(categoryID)),
 	)
+	if category.ParentID == nil {
+		moveRow = append(moveRow, tgbotapi.NewInlineKeyboardButtonData("➕ Подкатегория", CallbackNewSubcategory+strconv.Itoa(categoryID)))
+	}
+	rows = append(rows, moveRow, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", "manage_categories"),
+	))
+
+	msg := tgbotapi.NewMessage(chatID, msgText.String())
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
 	b.send(chatID, msg)
 }
 
 func (b *Bot) showReportPeriodMenu(chatID int64) {
+	startDay := 1
+	now := time.Now()
+	if user, err := b.repo.GetOrCreateUser(chatID, "", "", ""); err == nil {
+		startDay = user.PeriodStartDay
+		now = now.In(user.Location())
+	}
+	yearStart, _ := yearPeriod(now, startDay)
+	if now.Before(yearStart.AddDate(0, 1, 0)) {
+		yearStart = yearStart.AddDate(-1, 0, 0)
+	}
+
 	msg := tgbotapi.NewMessage(chatID, "📊 <b>Выбери период для статистики:</b>")
 	msg.ParseMode = "HTML"
 	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
@@ -468,6 +552,22 @@ func (b *Bot) showReportPeriodMenu(chatID int64) {
 			tgbotapi.NewInlineKeyboardButtonData("📈 Месяц", "stats_month"),
 			tgbotapi.NewInlineKeyboardButtonData("🎯 Год", "stats_year"),
 		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🗓 Произвольный период", CallbackStatsCustom),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔄 Сравнение периодов", CallbackCompareMonth),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📈 График: год", CallbackChartYear),
+			tgbotapi.NewInlineKeyboardButtonData("📈 График: тренды", CallbackChartTrend),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🧭 Паттерны трат", CallbackPatterns),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			yearReviewButton(yearStart),
+		),
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", "main_menu"),
 		),
@@ -516,7 +616,7 @@ func (b *Bot) showSavingsStats(chatID int64, svc *service.FinanceService) {
 }
 
 func (b *Bot) showTransactionHistory(chatID int64, svc *service.FinanceService) {
-	end := time.Now()
+	end := svc.Now()
 	start := end.AddDate(0, -1, 0)
 
 	transactions, err := svc.GetTransactionsForPeriod(start, end)
@@ -525,16 +625,22 @@ func (b *Bot) showTransactionHistory(chatID int64, svc *service.FinanceService)
 		return
 	}
 
+	authors := householdMemberNames(svc)
+
 	var msgText strings.Builder
 	msgText.WriteString("📜 <b>История операций</b>\n\n")
 
 	for i, t := range transactions {
-		formattedDate := t.Date.Format("02.01.2006")
+		formattedDate := t.Date.In(svc.Location()).Format("02.01.2006")
 		formattedAmount := b.formatCurrency(math.Abs(t.Amount), chatID)
 
 		operationIcon := "📈"
 		operationType := "Доход"
-		if t.Amount < 0 {
+		switch {
+		case t.Transfer:
+			operationIcon = "🔁"
+			operationType = "Перевод"
+		case t.Amount < 0:
 			operationIcon = "📉"
 			operationType = "Расход"
 		}
@@ -549,6 +655,9 @@ func (b *Bot) showTransactionHistory(chatID int64, svc *service.FinanceService)
 		if t.Comment != "" {
 			msgText.WriteString(fmt.Sprintf("┣ Комментарий: %s\n", t.Comment))
 		}
+		if author, ok := authors[t.CreatedBy]; ok {
+			msgText.WriteString(fmt.Sprintf("┣ Внёс(ла): %s\n", html.EscapeString(author)))
+		}
 		msgText.WriteString("\n")
 	}
 
@@ -575,10 +684,10 @@ func (b *Bot) createCategoryKeyboard(chatID int64, typ string, prefix string) tg
 	}
 
 	var rows [][]tgbotapi.InlineKeyboardButton
-	for _, cat := range categories {
+	for _, cat := range categoryTree(categories) {
 		if cat.Type == typ {
 			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData(cat.Name, prefix+"_"+strconv.Itoa(cat.ID)),
+				tgbotapi.NewInlineKeyboardButtonData(categoryLabel(cat), prefix+"_"+strconv.Itoa(cat.ID)),
 			))
 		}
 	}
@@ -609,12 +718,17 @@ func (b *Bot) showSavings(chatID int64, svc *service.FinanceService) {
 			if s.Goal != nil {
 				progress = b.renderProgressBar(s.Progress(), 10)
 				formattedGoal := b.formatCurrency(*s.Goal, chatID)
+				deadline := ""
+				if s.TargetDate != nil {
+					deadline = fmt.Sprintf("┣ Срок: *%s*\n", s.TargetDate.Format("02.01.2006"))
+				}
 				msgText.WriteString(fmt.Sprintf(
 					"🔹 *%s*\n"+
 						"┣ Накоплено: *%s*\n"+
 						"┣ Цель: *%s*\n"+
+						"%s"+
 						"┗ Прогресс: %s\n\n",
-					s.Name, formattedAmount, formattedGoal, progress,
+					s.Name, formattedAmount, formattedGoal, deadline, progress,
 				))
 			} else {
 				msgText.WriteString(fmt.Sprintf(
@@ -649,14 +763,14 @@ func (b *Bot) showSavings(chatID int64, svc *service.FinanceService) {
 }
 
 func (b *Bot) showDailyReport(chatID int64, svc *service.FinanceService) {
-	now := time.Now()
+	now := svc.Now()
 	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 	end := start.AddDate(0, 0, 1)
 	b.generatePeriodReport(chatID, svc, start, end, "день")
 }
 
 func (b *Bot) showWeeklyReport(chatID int64, svc *service.FinanceService) {
-	now := time.Now()
+	now := svc.Now()
 	start := now.AddDate(0, 0, -6)
 	end := now
 	b.generatePeriodReport(chatID, svc, start, end, "неделю")
@@ -668,18 +782,19 @@ func (b *Bot) showMonthlyReport(chatID int64, svc *service.FinanceService) {
 		b.sendError(chatID, err)
 		return
 	}
-	now := time.Now()
-	startDay := user.PeriodStartDay
-	var start, end time.Time
+	start, end := monthPeriod(svc.Now(), user.PeriodStartDay)
+	b.generatePeriodReport(chatID, svc, start, end, "месяц")
+}
+
+func monthPeriod(now time.Time, startDay int) (time.Time, time.Time) {
 	if startDay <= now.Day() {
-		start = time.Date(now.Year(), now.Month(), startDay, 0, 0, 0, 0, now.Location())
-		end = start.AddDate(0, 1, 0)
-	} else {
-		prevMonth := now.AddDate(0, -1, 0)
-		start = time.Date(prevMonth.Year(), prevMonth.Month(), startDay, 0, 0, 0, 0, now.Location())
-		end = time.Date(now.Year(), now.Month(), startDay, 0, 0, 0, 0, now.Location())
+		start := time.Date(now.Year(), now.Month(), startDay, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(0, 1, 0)
 	}
-	b.generatePeriodReport(chatID, svc, start, end, "месяц")
+	prevMonth := now.AddDate(0, -1, 0)
+	start := time.Date(prevMonth.Year(), prevMonth.Month(), startDay, 0, 0, 0, 0, now.Location())
+	end := time.Date(now.Year(), now.Month(), startDay, 0, 0, 0, 0, now.Location())
+	return start, end
 }
 
 func (b *Bot) showYearlyReport(chatID int64, svc *service.FinanceService) {
@@ -688,46 +803,29 @@ func (b *Bot) showYearlyReport(chatID int64, svc *service.FinanceService) {
 		b.sendError(chatID, err)
 		return
 	}
-	now := time.Now()
-	startDay := user.PeriodStartDay
-	var start, end time.Time
-	if now.Month() > 1 || (now.Month() == 1 && now.Day() >= startDay) {
-		start = time.Date(now.Year(), 1, startDay, 0, 0, 0, 0, now.Location())
-		end = start.AddDate(1, 0, 0)
-	} else {
-		start = time.Date(now.Year()-1, 1, startDay, 0, 0, 0, 0, now.Location())
-		end = time.Date(now.Year(), 1, startDay, 0, 0, 0, 0, now.Location())
-	}
+	start, end := yearPeriod(svc.Now(), user.PeriodStartDay)
 	b.generatePeriodReport(chatID, svc, start, end, "год")
 }
 
+func yearPeriod(now time.Time, startDay int) (time.Time, time.Time) {
+	if now.Month() > 1 || now.Day() >= startDay {
+		start := time.Date(now.Year(), 1, startDay, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(1, 0, 0)
+	}
+	start := time.Date(now.Year()-1, 1, startDay, 0, 0, 0, 0, now.Location())
+	return start, start.AddDate(1, 0, 0)
+}
+
 func (b *Bot) generatePeriodReport(chatID int64, svc *service.FinanceService, start, end time.Time, periodName string) {
-	trans, err := svc.GetTransactionsForPeriod(start, end)
+	sums, err := svc.GetCategorySums(start, end)
 	if err != nil {
-		log.Printf("Ошибка получения транзакций для отчета: %v", err)
+		log.Printf("Ошибка получения сумм для отчета: %v", err)
 		b.sendError(chatID, err)
 		return
 	}
 
-	var totalIncome, totalExpense float64
-	incomeDetails := make(map[string]float64)
-	expenseDetails := make(map[string]float64)
-
-	for _, t := range trans {
-		catName := t.CategoryName
-		if catName == "" {
-			catName = "Неизвестно"
-		}
-
-		if t.Amount > 0 {
-			totalIncome += t.Amount
-			incomeDetails[catName] += t.Amount
-		} else {
-			amount := math.Abs(t.Amount)
-			totalExpense += amount
-			expenseDetails[catName] += amount
-		}
-	}
+	incomeDetails, expenseDetails := service.RootCategoryTotals(sums)
+	totalIncome, totalExpense := sum(incomeDetails), sum(expenseDetails)
 
 	msgText := strings.Builder{}
 	msgText.WriteString(fmt.Sprintf("📊 <b>Статистика за %s</b>\n\n", periodName))
@@ -769,6 +867,9 @@ func (b *Bot) generatePeriodReport(chatID int64, svc *service.FinanceService, st
 	formattedBalance := b.formatCurrency(totalIncome-totalExpense, chatID)
 	msgText.WriteString(fmt.Sprintf("\n💵 <b>Баланс:</b> %s", formattedBalance))
 
+	b.writeHouseholdReportSection(&msgText, chatID, svc, start, end)
+	b.writeAnomalyReportSection(&msgText, chatID, svc, start, end)
+
 	finalMsg := msgText.String()
 	if len(finalMsg) > 4096 {
 		log.Printf("Длина сообщения статистики превышает лимит Telegram (4096 символов)")
@@ -776,16 +877,23 @@ func (b *Bot) generatePeriodReport(chatID int64, svc *service.FinanceService, st
 		return
 	}
 
-	msg := tgbotapi.NewMessage(chatID, finalMsg)
-	msg.ParseMode = "HTML"
-	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+	rows := [][]tgbotapi.InlineKeyboardButton{
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", "stats_back"),
 			tgbotapi.NewInlineKeyboardButtonData("📤 Выгрузить отчет", fmt.Sprintf("export_report_%s_%s",
 				start.Format("2006-01-02"),
 				end.Format("2006-01-02"))),
 		),
-	)
+	}
+	rows = append(rows, chartButtons(start, end))
+	rows = append(rows, drillDownButtons(sums, start, end)...)
+	if row := householdReportButton(svc, start, end); row != nil {
+		rows = append(rows, row)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, finalMsg)
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
 
 	if _, err := b.bot.Send(msg); err != nil {
 		log.Printf("Ошибка отправки сообщения статистики: %v", err)
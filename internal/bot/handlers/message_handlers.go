@@ -5,7 +5,9 @@ import (
 	"math"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/IlyaMakar/finance_bot/internal/repository"
 	"github.com/IlyaMakar/finance_bot/internal/service"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
@@ -21,9 +23,16 @@ func (b *Bot) handleMessage(m *tgbotapi.Message) {
 		b.sendError(m.Chat.ID, err)
 		return
 	}
+	b.markReachable(user)
 
 	svc := service.NewService(b.repo, user)
 
+	if strings.HasPrefix(m.Text, "/start "+householdInvitePrefix) {
+		b.initBasicCategories(user)
+		b.joinHousehold(m.Chat.ID, strings.TrimPrefix(m.Text, "/start "+householdInvitePrefix), svc)
+		return
+	}
+
 	switch m.Text {
 	case "/start":
 		b.initBasicCategories(user)
@@ -90,10 +99,78 @@ func (b *Bot) handleUserInput(m *tgbotapi.Message, svc *service.FinanceService)
 		b.handleSavingAmount(m, svc)
 	case "new_cat":
 		b.handleNewCategory(m, svc)
+	case "new_subcat":
+		b.handleNewSubcategory(m, svc)
 	case "create_saving_name":
 		b.handleCreateSavingName(m)
 	case "create_saving_goal":
 		b.handleCreateSavingGoal(m)
+	case "create_saving_deadline":
+		b.handleCreateSavingDeadline(m, svc)
+	case "enter_saving_deadline":
+		b.handleSavingDeadline(m, svc)
+	case "enter_rule_percent":
+		b.handleRulePercent(m, svc)
+	case "enter_rule_schedule_amount":
+		b.handleRuleScheduleAmount(m)
+	case "enter_rule_schedule_days":
+		b.handleRuleScheduleDays(m, svc)
+	case "enter_saving_rate":
+		b.handleSavingRate(m, svc)
+	case "enter_saving_maturity":
+		b.handleSavingMaturity(m, svc)
+	case "enter_debt_name":
+		b.handleDebtName(m)
+	case "enter_debt_amount":
+		b.handleDebtAmount(m, svc)
+	case "enter_debt_due":
+		b.handleDebtDue(m, svc)
+	case "enter_debt_repay":
+		b.handleDebtRepayAmount(m, svc)
+	case "enter_loan_name":
+		b.handleLoanName(m)
+	case "enter_loan_amount":
+		b.handleLoanAmount(m)
+	case "enter_loan_rate":
+		b.handleLoanRate(m)
+	case "enter_loan_term":
+		b.handleLoanTerm(m)
+	case "enter_loan_issue":
+		b.handleLoanIssueDate(m, svc)
+	case "enter_loan_early":
+		b.handleLoanEarlyAmount(m, svc)
+	case "enter_asset_name":
+		b.handleAssetName(m)
+	case "enter_asset_value", "enter_asset_revalue":
+		b.handleAssetValue(m, svc)
+	case "enter_card_name":
+		b.handleCardName(m)
+	case "enter_card_limit":
+		b.handleCardLimit(m)
+	case "enter_card_statement_day":
+		b.handleCardStatementDay(m)
+	case "enter_card_grace":
+		b.handleCardGrace(m)
+	case "enter_card_min_payment":
+		b.handleCardMinPayment(m, svc)
+	case "enter_range_start", "enter_range_end":
+		b.handleDateRangeInput(m, svc)
+	case "enter_household_name":
+		b.handleHouseholdName(m, svc)
+	case "enter_household_code":
+		b.handleHouseholdCode(m, svc)
+	case "enter_bill_name":
+		b.handleBillName(m)
+	case "enter_bill_amount":
+		b.handleBillAmount(m)
+	case "enter_bill_due_day":
+		b.handleBillDueDay(m, svc)
+	case "enter_card_payment":
+		b.handleCardPayment(m, svc)
+	case "enter_reminder_time":
+		b.handleReminderTimeInput(m, svc)
+	case "enter_timezone":
+		b.handleTimezoneInput(m, svc)
 	case "rename_saving":
 		state := userStates[m.From.ID]
 		newName := strings.TrimSpace(m.Text)
@@ -300,7 +377,7 @@ func (b *Bot) handleEditTransaction(chatID int64, transactionID int, svc *servic
 			"📂 Категория: %s\n"+
 			"💬 Комментарий: %s\n\n"+
 			"Выберите что изменить:",
-		trans.Date.Format("02.01.2006"),
+		trans.Date.In(svc.Location()).Format("02.01.2006"),
 		formattedAmount,
 		categoryName,
 		trans.Comment,
@@ -380,16 +457,36 @@ func (b *Bot) handleComment(m *tgbotapi.Message, svc *service.FinanceService) {
 	editMsg := tgbotapi.NewEditMessageReplyMarkup(m.Chat.ID, m.MessageID, tgbotapi.InlineKeyboardMarkup{})
 	b.bot.Send(editMsg)
 
+	if state.TempType == "expense" {
+		if cards := b.loadCreditCards(svc); len(cards) > 0 {
+			state.Step = "choose_payment_method"
+			userStates[m.From.ID] = state
+			b.askPaymentMethod(m.Chat.ID, cards)
+			return
+		}
+	}
+
+	b.saveTransaction(m.Chat.ID, m.From.ID, state, 0, svc)
+}
+
+func (b *Bot) saveTransaction(chatID, userID int64, state UserState, cardID int, svc *service.FinanceService) {
 	amount := state.TempAmount
 	if state.TempType == "expense" {
 		amount = -amount
 	}
 
-	_, err := svc.AddTransaction(amount, state.TempCategoryID, "card", state.TempComment)
+	var transactionID int
+	var err error
+	if cardID > 0 {
+		transactionID, err = svc.AddCreditCardTransaction(amount, state.TempCategoryID, cardID, state.TempComment)
+	} else {
+		transactionID, err = svc.AddTransaction(amount, state.TempCategoryID, repository.PaymentMethodCard, state.TempComment)
+	}
 	if err != nil {
-		b.sendError(m.Chat.ID, err)
+		b.sendError(chatID, err)
 		return
 	}
+	svc.ApplySavingRules(transactionID, amount)
 
 	category, err := svc.GetCategoryByID(state.TempCategoryID)
 	categoryName := "Неизвестно"
@@ -403,13 +500,21 @@ func (b *Bot) handleComment(m *tgbotapi.Message, svc *service.FinanceService) {
 		amount = -amount
 	}
 
-	formattedAmount := b.formatCurrency(amount, m.Chat.ID)
+	formattedAmount := b.formatCurrency(amount, chatID)
 
-	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID,
-		fmt.Sprintf("✅ %s: %s, %s", operationType, categoryName, formattedAmount)))
+	text := fmt.Sprintf("✅ %s: %s, %s", operationType, categoryName, formattedAmount)
+	if cardID > 0 {
+		text += b.creditCardNotice(chatID, cardID, svc)
+	}
+	b.send(chatID, tgbotapi.NewMessage(chatID, text))
 
-	delete(userStates, m.From.ID)
-	b.sendMainMenu(m.Chat.ID, "🎉 Операция добавлена! Что дальше?")
+	if moves, err := svc.GetAutoMovesForTransaction(transactionID); err == nil {
+		b.notifyAutoMoves(chatID, moves)
+	}
+	b.notifyTransactionAnomaly(chatID, transactionID, svc)
+
+	delete(userStates, userID)
+	b.sendMainMenu(chatID, "🎉 Операция добавлена! Что дальше?")
 }
 
 func (b *Bot) handleSavingAmount(m *tgbotapi.Message, svc *service.FinanceService) {
@@ -440,6 +545,10 @@ func (b *Bot) handleSavingAmount(m *tgbotapi.Message, svc *service.FinanceServic
 	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID,
 		fmt.Sprintf("✅ Копилка '%s' пополнена на %s!\n💰 Новый баланс: %s", saving.Name, formattedAmount, formattedNewAmount)))
 
+	if saving.Goal != nil && saving.Amount < *saving.Goal && newAmount >= *saving.Goal {
+		b.celebrateSavingGoal(m.Chat.ID, saving)
+	}
+
 	delete(userStates, m.From.ID)
 	b.showSavings(m.Chat.ID, svc)
 }
@@ -478,18 +587,49 @@ func (b *Bot) handleCreateSavingName(m *tgbotapi.Message) {
 
 func (b *Bot) handleCreateSavingGoal(m *tgbotapi.Message) {
 	s := userStates[m.From.ID]
-	var goal *float64
 	if strings.ToLower(m.Text) == "пропустить" {
-		goal = nil
-	} else {
-		value, err := strconv.ParseFloat(m.Text, 64)
-		if err != nil || value < 0 {
-			b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите корректное число для цели или «Пропустить»:"))
-			return
-		}
-		goal = &value
+		b.finishCreateSaving(m, s.TempComment, nil, nil)
+		return
+	}
+
+	value, err := strconv.ParseFloat(m.Text, 64)
+	if err != nil || value < 0 {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите корректное число для цели или «Пропустить»:"))
+		return
 	}
 
+	s.TempAmount = value
+	s.Step = "create_saving_deadline"
+	userStates[m.From.ID] = s
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, "📅 К какой дате хотите накопить? Введите дату в формате ДД.ММ.ГГГГ:")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Пропустить", "skip_saving_deadline"),
+		),
+	)
+	b.send(m.Chat.ID, msg)
+}
+
+func (b *Bot) handleCreateSavingDeadline(m *tgbotapi.Message, svc *service.FinanceService) {
+	s := userStates[m.From.ID]
+	goal := s.TempAmount
+
+	if strings.ToLower(m.Text) == "пропустить" {
+		b.finishCreateSaving(m, s.TempComment, &goal, nil)
+		return
+	}
+
+	date, err := parseUserDate(m.Text, svc.Location())
+	if err != nil || !date.After(svc.Now()) {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите будущую дату в формате ДД.ММ.ГГГГ или «Пропустить»:"))
+		return
+	}
+
+	b.finishCreateSaving(m, s.TempComment, &goal, &date)
+}
+
+func (b *Bot) finishCreateSaving(m *tgbotapi.Message, name string, goal *float64, targetDate *time.Time) {
 	user, err := b.repo.GetOrCreateUser(
 		m.From.ID,
 		m.From.UserName,
@@ -503,7 +643,7 @@ func (b *Bot) handleCreateSavingGoal(m *tgbotapi.Message) {
 
 	svc := service.NewService(b.repo, user)
 
-	if err := svc.CreateSaving(s.TempComment, goal); err != nil {
+	if err := svc.CreateSaving(name, goal, targetDate); err != nil {
 		b.sendError(m.Chat.ID, err)
 		return
 	}
@@ -518,3 +658,22 @@ func (b *Bot) handleCreateSavingGoal(m *tgbotapi.Message) {
 
 	b.showSavings(m.Chat.ID, svc)
 }
+
+func (b *Bot) handleSavingDeadline(m *tgbotapi.Message, svc *service.FinanceService) {
+	state := userStates[m.From.ID]
+
+	date, err := parseUserDate(m.Text, svc.Location())
+	if err != nil {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите дату в формате ДД.ММ.ГГГГ:"))
+		return
+	}
+
+	if err := svc.SetSavingTargetDate(state.TempCategoryID, &date); err != nil {
+		b.sendError(m.Chat.ID, err)
+		return
+	}
+
+	delete(userStates, m.From.ID)
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✅ Срок цели установлен: %s", date.Format("02.01.2006"))))
+	b.showSavingActions(m.Chat.ID, state.TempCategoryID, svc)
+}
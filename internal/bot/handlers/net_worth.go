@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/IlyaMakar/finance_bot/internal/logger"
+	"github.com/IlyaMakar/finance_bot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+)
+
+const (
+	CallbackNetWorth      = "networth"
+	CallbackNetWorthChart = "networth_chart"
+	CallbackAssets        = "assets"
+	CallbackAssetNew      = "asset_new"
+	CallbackAssetView     = "asset_view_"
+	CallbackAssetValue    = "asset_value_"
+	CallbackAssetDelete   = "asset_delete_"
+
+	netWorthHistoryMonths = 12
+)
+
+var netWorthIcons = map[string]string{
+	service.NetWorthAccount:    "💳",
+	service.NetWorthSaving:     "💰",
+	service.NetWorthDebt:       "🤝",
+	service.NetWorthLoan:       "🏦",
+	service.NetWorthCreditCard: "🟥",
+	service.NetWorthAsset:      "🏠",
+}
+
+func (b *Bot) handleNetWorthCallback(chatID int64, messageID int, data string, svc *service.FinanceService) bool {
+	switch {
+	case data == CallbackNetWorth:
+		b.deleteMessage(chatID, messageID)
+		b.showNetWorth(chatID, svc)
+
+	case data == CallbackNetWorthChart:
+		b.sendNetWorthChart(chatID, svc)
+
+	case data == CallbackAssets:
+		b.deleteMessage(chatID, messageID)
+		b.showAssets(chatID, svc)
+
+	case data == CallbackAssetNew:
+		userStates[chatID] = UserState{Step: "enter_asset_name"}
+		b.deleteMessage(chatID, messageID)
+		b.send(chatID, tgbotapi.NewMessage(chatID, "🏠 Введите название имущества (например, Автомобиль или Квартира):"))
+
+	case strings.HasPrefix(data, CallbackAssetView):
+		assetID, _ := strconv.Atoi(data[len(CallbackAssetView):])
+		b.deleteMessage(chatID, messageID)
+		b.showAsset(chatID, assetID, svc)
+
+	case strings.HasPrefix(data, CallbackAssetValue):
+		assetID, _ := strconv.Atoi(data[len(CallbackAssetValue):])
+		userStates[chatID] = UserState{Step: "enter_asset_revalue", TempCategoryID: assetID}
+		b.send(chatID, tgbotapi.NewMessage(chatID, "💵 Введите новую оценку стоимости:"))
+
+	case strings.HasPrefix(data, CallbackAssetDelete):
+		assetID, _ := strconv.Atoi(data[len(CallbackAssetDelete):])
+		if err := svc.DeleteAsset(assetID); err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		b.deleteMessage(chatID, messageID)
+		b.send(chatID, tgbotapi.NewMessage(chatID, "🗑 Имущество удалено"))
+		b.showAssets(chatID, svc)
+
+	default:
+		return false
+	}
+	return true
+}
+
+func (b *Bot) showNetWorth(chatID int64, svc *service.FinanceService) {
+	nw, err := svc.TakeNetWorthSnapshot(svc.Now())
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString("🏛 <b>Капитал</b>\n\n")
+
+	text.WriteString(fmt.Sprintf("<b>Активы: %s</b>\n", b.formatCurrency(nw.TotalAssets, chatID)))
+	if len(nw.Assets) == 0 {
+		text.WriteString("┗ нет\n")
+	}
+	for _, item := range nw.Assets {
+		text.WriteString(fmt.Sprintf("┣ %s %s: %s\n", netWorthIcons[item.Kind], html.EscapeString(item.Name), b.formatCurrency(item.Amount, chatID)))
+	}
+
+	text.WriteString(fmt.Sprintf("\n<b>Обязательства: %s</b>\n", b.formatCurrency(nw.TotalLiabilities, chatID)))
+	if len(nw.Liabilities) == 0 {
+		text.WriteString("┗ нет\n")
+	}
+	for _, item := range nw.Liabilities {
+		text.WriteString(fmt.Sprintf("┣ %s %s: %s\n", netWorthIcons[item.Kind], html.EscapeString(item.Name), b.formatCurrency(item.Amount, chatID)))
+	}
+
+	text.WriteString(fmt.Sprintf("\n💎 <b>Чистый капитал: %s</b>\n", b.formatCurrency(nw.Net(), chatID)))
+
+	history, err := svc.GetNetWorthHistory(svc.Now(), 2)
+	if err == nil && len(history) == 2 {
+		text.WriteString(fmt.Sprintf("За месяц: %s\n", b.formatDelta(history[1].Net(), history[0].Net(), chatID)))
+	}
+	if nw.SkippedDebts > 0 {
+		text.WriteString(fmt.Sprintf("\n<i>Долги в другой валюте не учтены: %d</i>\n", nw.SkippedDebts))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🏠 Имущество", CallbackAssets),
+			tgbotapi.NewInlineKeyboardButtonData("📈 Динамика", CallbackNetWorthChart),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("◀️ Главное меню", "main_menu"),
+		),
+	)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) showAssets(chatID int64, svc *service.FinanceService) {
+	assets, err := svc.GetAssets()
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	text := "🏠 <b>Имущество</b>\n\nДобавьте то, что не учитывается в операциях: автомобиль, квартиру, технику. Оценку можно обновлять в любой момент."
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, a := range assets {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%s · %s", a.Name, b.formatCurrency(a.Value, chatID)),
+				fmt.Sprintf("%s%d", CallbackAssetView, a.ID)),
+		))
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("➕ Добавить", CallbackAssetNew)),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", CallbackNetWorth)),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) showAsset(chatID int64, assetID int, svc *service.FinanceService) {
+	asset, err := svc.GetAssetByID(assetID)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🏠 <b>%s</b>\n\nОценка: %s\nОбновлено: %s",
+		asset.Name, b.formatCurrency(asset.Value, chatID), asset.UpdatedAt.Local().Format("02.01.2006")))
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✏️ Переоценить", fmt.Sprintf("%s%d", CallbackAssetValue, asset.ID)),
+			tgbotapi.NewInlineKeyboardButtonData("🗑 Удалить", fmt.Sprintf("%s%d", CallbackAssetDelete, asset.ID)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", CallbackAssets),
+		),
+	)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) handleAssetName(m *tgbotapi.Message) {
+	name := strings.TrimSpace(m.Text)
+	if name == "" {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Название не может быть пустым. Попробуйте снова:"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	state.Step = "enter_asset_value"
+	state.TempComment = name
+	userStates[m.From.ID] = state
+
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "💵 Во сколько вы оцениваете это имущество?"))
+}
+
+func (b *Bot) handleAssetValue(m *tgbotapi.Message, svc *service.FinanceService) {
+	value, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(m.Text), ",", "."), 64)
+	if err != nil || value < 0 {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите корректную сумму (например, 1500000):"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	delete(userStates, m.From.ID)
+
+	if state.Step == "enter_asset_revalue" {
+		err = svc.UpdateAssetValue(state.TempCategoryID, value)
+	} else {
+		_, err = svc.CreateAsset(state.TempComment, value)
+	}
+	if err != nil {
+		b.sendError(m.Chat.ID, err)
+		return
+	}
+
+	if _, err := svc.TakeNetWorthSnapshot(svc.Now()); err != nil {
+		logger.Error("Net worth snapshot error", "chat_id", m.Chat.ID, "error", err)
+	}
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "✅ Оценка сохранена"))
+	b.showAssets(m.Chat.ID, svc)
+}
+
+func (b *Bot) sendNetWorthChart(chatID int64, svc *service.FinanceService) {
+	history, err := svc.GetNetWorthHistory(svc.Now(), netWorthHistoryMonths)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+	if len(history) < 2 {
+		b.send(chatID, tgbotapi.NewMessage(chatID, "😔 Пока мало данных: снимки капитала сохраняются раз в месяц. Загляните позже!"))
+		return
+	}
+
+	var values []chart.Value
+	var caption strings.Builder
+	caption.WriteString("📈 <b>Динамика капитала</b>\n")
+	for _, s := range history {
+		values = append(values, chart.Value{Label: s.Month.Format("01.2006"), Value: s.Net()})
+		caption.WriteString(fmt.Sprintf("%s %s: %s\n", monthShortName(s.Month.Month()), s.Month.Format("2006"),
+			b.formatCurrency(s.Net(), chatID)))
+	}
+
+	img, err := b.reportGen.generateLineChart(values, drawing.ColorFromHex("70AD47"))
+	b.sendChart(chatID, img, err, caption.String())
+}
+
+func (b *Bot) TakeNetWorthSnapshots() {
+	users, err := b.repo.GetAllUsers()
+	if err != nil {
+		logger.Error("Net worth error getting users", "error", err)
+		return
+	}
+
+	for i := range users {
+		svc := service.NewService(b.repo, &users[i])
+		if _, err := svc.TakeNetWorthSnapshot(svc.Now()); err != nil {
+			logger.Error("Net worth snapshot error", "user_id", users[i].TelegramID, "error", err)
+		}
+	}
+}
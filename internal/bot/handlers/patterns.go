@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+)
+
+const (
+	CallbackPatterns        = "patterns"
+	CallbackPatternWeekday  = "pattern_weekday"
+	CallbackPatternHour     = "pattern_hour"
+	CallbackPatternMonthDay = "pattern_monthday"
+	CallbackPatternCalendar = "pattern_calendar"
+
+	patternMonths       = 12
+	patternTopMonthDays = 3
+	calendarCell        = 14
+	calendarGap         = 3
+)
+
+var (
+	weekdayDativeNames = [...]string{
+		"понедельникам", "вторникам", "средам", "четвергам", "пятницам", "субботам", "воскресеньям",
+	}
+	calendarColors = [...]string{"EBEDF0", "FDE0C5", "FAC08F", "F59E4C", "E8590C"}
+)
+
+func (b *Bot) handlePatternCallback(chatID int64, data string, svc *service.FinanceService) bool {
+	switch data {
+	case CallbackPatterns, CallbackPatternWeekday, CallbackPatternHour, CallbackPatternMonthDay, CallbackPatternCalendar:
+	default:
+		return false
+	}
+
+	start, end := patternPeriod(svc.Now())
+	patterns, err := svc.GetSpendingPatterns(start, end)
+	if err != nil {
+		b.sendError(chatID, err)
+		return true
+	}
+	if patterns.Total == 0 {
+		b.send(chatID, tgbotapi.NewMessage(chatID, "😔 За последние 12 месяцев нет расходов."))
+		return true
+	}
+
+	loc := reportLocale{lang: LangRU}
+	period := rangeLabel(start, end.AddDate(0, 0, -1))
+	switch data {
+	case CallbackPatterns:
+		b.showSpendingPatterns(chatID, patterns, period)
+	case CallbackPatternWeekday:
+		img, err := b.reportGen.renderWeekdayPattern(patterns, loc)
+		b.sendChart(chatID, img, err, "📅 <b>Расходы по дням недели</b>\n"+period)
+	case CallbackPatternHour:
+		img, err := b.reportGen.renderHourPattern(patterns)
+		b.sendChart(chatID, img, err, "🕐 <b>Расходы по времени суток</b>\n"+period)
+	case CallbackPatternMonthDay:
+		img, err := b.reportGen.renderMonthDayPattern(patterns)
+		b.sendChart(chatID, img, err, "📆 <b>Расходы по числам месяца</b>\n"+period)
+	case CallbackPatternCalendar:
+		img, err := b.reportGen.renderSpendingCalendar(start, end, patterns.Daily, loc)
+		b.sendChart(chatID, img, err, fmt.Sprintf("🗓 <b>Календарь трат</b>\n%s\nДней с тратами: %d", period, len(patterns.Daily)))
+	}
+	return true
+}
+
+func patternPeriod(now time.Time) (time.Time, time.Time) {
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, 1-patternMonths, 0)
+	return start, start.AddDate(0, patternMonths, 0)
+}
+
+func (b *Bot) showSpendingPatterns(chatID int64, p *service.SpendingPatterns, period string) {
+	var text strings.Builder
+	text.WriteString("🧭 <b>Паттерны трат</b>\n")
+	text.WriteString(period + "\n\n")
+
+	day := service.PeakIndex(p.Weekday[:])
+	text.WriteString(fmt.Sprintf("📅 Больше всего вы тратите по <b>%s</b> — %.0f%% расходов\n",
+		weekdayDativeNames[day], p.Weekday[day]/p.Total*100))
+
+	hour := service.PeakIndex(p.Hour[:])
+	text.WriteString(fmt.Sprintf("🕐 Пик трат — с %02d:00 до %02d:00, %.0f%% расходов\n",
+		hour, (hour+1)%24, p.Hour[hour]/p.Total*100))
+
+	days := topMonthDays(p.MonthDay[:], patternTopMonthDays)
+	var labels []string
+	var share float64
+	for _, d := range days {
+		labels = append(labels, strconv.Itoa(d+1))
+		share += p.MonthDay[d] / p.Total * 100
+	}
+	text.WriteString(fmt.Sprintf("📆 Самые затратные числа месяца: <b>%s</b> — %.0f%% расходов\n",
+		strings.Join(labels, ", "), share))
+	if share >= 30 {
+		text.WriteString("💸 Траты сильно концентрируются в эти дни — возможно, это дни зарплаты или крупных платежей.\n")
+	}
+
+	text.WriteString(fmt.Sprintf("\n🗓 Дней с тратами: %d", len(p.Daily)))
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📅 Дни недели", CallbackPatternWeekday),
+			tgbotapi.NewInlineKeyboardButtonData("🕐 Время суток", CallbackPatternHour),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📆 Числа месяца", CallbackPatternMonthDay),
+			tgbotapi.NewInlineKeyboardButtonData("🗓 Календарь", CallbackPatternCalendar),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", "stats_back"),
+		),
+	)
+	b.send(chatID, msg)
+}
+
+func topMonthDays(values []float64, limit int) []int {
+	days := make([]int, 0, len(values))
+	for i, v := range values {
+		if v > 0 {
+			days = append(days, i)
+		}
+	}
+	sort.SliceStable(days, func(i, j int) bool { return values[days[i]] > values[days[j]] })
+	if len(days) > limit {
+		days = days[:limit]
+	}
+	sort.Ints(days)
+	return days
+}
+
+func (rg *ReportGenerator) renderWeekdayPattern(p *service.SpendingPatterns, loc reportLocale) ([]byte, error) {
+	labels := make([]string, len(p.Weekday))
+	for i := range labels {
+		labels[i] = loc.weekdayShort(i)
+	}
+	return rg.renderPatternBars(p.Weekday[:], labels)
+}
+
+func (rg *ReportGenerator) renderHourPattern(p *service.SpendingPatterns) ([]byte, error) {
+	labels := make([]string, len(p.Hour))
+	for i := range labels {
+		labels[i] = strconv.Itoa(i)
+	}
+	return rg.renderPatternBars(p.Hour[:], labels)
+}
+
+func (rg *ReportGenerator) renderMonthDayPattern(p *service.SpendingPatterns) ([]byte, error) {
+	labels := make([]string, len(p.MonthDay))
+	for i := range labels {
+		labels[i] = strconv.Itoa(i + 1)
+	}
+	return rg.renderPatternBars(p.MonthDay[:], labels)
+}
+
+func (rg *ReportGenerator) renderPatternBars(values []float64, labels []string) ([]byte, error) {
+	peak := service.PeakIndex(values)
+	barStyle := chart.Style{FillColor: drawing.ColorFromHex("F4B183"), StrokeColor: drawing.ColorFromHex("F4B183")}
+	peakStyle := chart.Style{FillColor: drawing.ColorFromHex("E8590C"), StrokeColor: drawing.ColorFromHex("E8590C")}
+
+	bars := make([]chart.Value, len(values))
+	for i, v := range values {
+		bars[i] = chart.Value{Value: v, Label: labels[i], Style: barStyle}
+		if i == peak {
+			bars[i].Style = peakStyle
+		}
+	}
+
+	slot := 880 / len(values)
+	graph := chart.BarChart{
+		Width:      1000,
+		Height:     450,
+		BarWidth:   slot * 7 / 10,
+		BarSpacing: slot - slot*7/10,
+		Background: chart.Style{
+			Padding: chart.Box{Top: 30, Left: 10, Right: 10, Bottom: 40},
+		},
+		YAxis: chart.YAxis{
+			ValueFormatter: chartAmountFormatter,
+			Range:          &chart.ContinuousRange{Min: 0, Max: values[peak]},
+		},
+		Bars: bars,
+	}
+
+	var buf bytes.Buffer
+	err := graph.Render(chart.PNG, &buf)
+	return buf.Bytes(), err
+}
+
+func (rg *ReportGenerator) renderSpendingCalendar(start, end time.Time, daily map[string]float64, loc reportLocale) ([]byte, error) {
+	first := start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+	weeks := int(end.Sub(first).Hours()/24+6) / 7
+	step := calendarCell + calendarGap
+	left, top := 40, 30
+
+	width := max(left+weeks*step+20, 300)
+	height := top + 7*step + 40
+	r, err := chart.PNG(width, height)
+	if err != nil {
+		return nil, err
+	}
+	font, err := chart.GetDefaultFont()
+	if err != nil {
+		return nil, err
+	}
+
+	fillRect(r, 0, 0, width, height, drawing.ColorWhite)
+	r.SetFont(font)
+	r.SetFontSize(10)
+	r.SetFontColor(drawing.ColorFromHex("555555"))
+
+	for _, i := range []int{0, 2, 4} {
+		r.Text(loc.weekdayShort(i), 5, top+i*step+calendarCell-2)
+	}
+
+	levels := calendarThresholds(daily)
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		offset := int(day.Sub(first).Hours()/24 + 0.5)
+		col, row := offset/7, offset%7
+		x, y := left+col*step, top+row*step
+
+		if day.Day() == 1 && col < weeks-1 {
+			r.Text(loc.monthShort(day.Month()), x, top-10)
+		}
+
+		level := 0
+		if v := daily[day.Format("2006-01-02")]; v > 0 {
+			level = 1
+			for _, t := range levels {
+				if v > t {
+					level++
+				}
+			}
+		}
+		fillRect(r, x, y, calendarCell, calendarCell, drawing.ColorFromHex(calendarColors[level]))
+	}
+
+	legendY := top + 7*step + 15
+	lessWidth := r.MeasureText(loc.text("pt_less")).Width()
+	x := width - 20 - len(calendarColors)*step - r.MeasureText(loc.text("pt_more")).Width() - lessWidth - 16
+	r.Text(loc.text("pt_less"), x, legendY+calendarCell-3)
+	x += lessWidth + 8
+	for _, c := range calendarColors {
+		fillRect(r, x, legendY, calendarCell, calendarCell, drawing.ColorFromHex(c))
+		x += step
+	}
+	r.Text(loc.text("pt_more"), x+5, legendY+calendarCell-3)
+
+	var buf bytes.Buffer
+	err = r.Save(&buf)
+	return buf.Bytes(), err
+}
+
+func calendarThresholds(daily map[string]float64) []float64 {
+	values := make([]float64, 0, len(daily))
+	for _, v := range daily {
+		values = append(values, v)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	sort.Float64s(values)
+
+	var thresholds []float64
+	for _, q := range []int{1, 2, 3} {
+		thresholds = append(thresholds, values[(len(values)-1)*q/4])
+	}
+	return thresholds
+}
+
+func fillRect(r chart.Renderer, x, y, w, h int, c drawing.Color) {
+	r.SetFillColor(c)
+	r.SetStrokeColor(c)
+	r.MoveTo(x, y)
+	r.LineTo(x+w, y)
+	r.LineTo(x+w, y+h)
+	r.LineTo(x, y+h)
+	r.Close()
+	r.Fill()
+}
+
+func (rg *ReportGenerator) addPatternsSection(pdf *gofpdf.Fpdf, start, end time.Time, p *service.SpendingPatterns, loc reportLocale) {
+	if p.Total == 0 {
+		return
+	}
+
+	pdf.AddPage()
+	pdf.SetFont("DejaVuSans", "B", 14)
+	pdf.CellFormat(190, 10, loc.text("patterns"), "", 1, "L", false, 0, "")
+
+	charts := []struct {
+		title  string
+		render func() ([]byte, error)
+	}{
+		{"pt_weekday", func() ([]byte, error) { return rg.renderWeekdayPattern(p, loc) }},
+		{"pt_hour", func() ([]byte, error) { return rg.renderHourPattern(p) }},
+		{"pt_monthday", func() ([]byte, error) { return rg.renderMonthDayPattern(p) }},
+	}
+	for _, c := range charts {
+		img, err := c.render()
+		if err != nil {
+			continue
+		}
+		pdf.SetFont("DejaVuSans", "", 11)
+		pdf.CellFormat(190, 7, loc.text(c.title), "", 1, "L", false, 0, "")
+		y := pdf.GetY()
+		rg.addImageToPDF(pdf, img, "", 10, y, 150, 67)
+		pdf.SetY(y + 69)
+	}
+
+	img, err := rg.renderSpendingCalendar(start, end, p.Daily, loc)
+	if err != nil {
+		return
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(img))
+	if err != nil {
+		return
+	}
+	pdf.AddPage()
+	pdf.SetFont("DejaVuSans", "", 11)
+	pdf.CellFormat(190, 7, loc.text("pt_calendar"), "", 1, "L", false, 0, "")
+	rg.addImageToPDF(pdf, img, "", 10, pdf.GetY(), math.Min(190, float64(cfg.Width)*0.2), 0)
+}
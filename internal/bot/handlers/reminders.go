@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/logger"
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+	"github.com/IlyaMakar/finance_bot/internal/scheduler"
+	"github.com/IlyaMakar/finance_bot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CallbackReminderSettings  = "reminder_settings"
+	CallbackReminderHour      = "reminder_hour_"
+	CallbackReminderTimeInput = "reminder_time_input"
+	CallbackReminderDay       = "reminder_day_"
+	CallbackTimezoneList      = "timezone_list"
+	CallbackTimezoneSet       = "timezone_set_"
+	CallbackTimezoneInput     = "timezone_input"
+
+	reminderCatchUpMinutes = 60
+)
+
+var timezoneOptions = []struct {
+	name, label string
+}{
+	{"Europe/Kaliningrad", "Калининград"},
+	{"Europe/Moscow", "Москва"},
+	{"Europe/Samara", "Самара"},
+	{"Asia/Yekaterinburg", "Екатеринбург"},
+	{"Asia/Omsk", "Омск"},
+	{"Asia/Novosibirsk", "Новосибирск"},
+	{"Asia/Krasnoyarsk", "Красноярск"},
+	{"Asia/Irkutsk", "Иркутск"},
+	{"Asia/Yakutsk", "Якутск"},
+	{"Asia/Vladivostok", "Владивосток"},
+	{"Asia/Magadan", "Магадан"},
+	{"Asia/Kamchatka", "Камчатка"},
+}
+
+func (b *Bot) handleReminderCallback(chatID int64, messageID int, data string, svc *service.FinanceService) bool {
+	switch {
+	case data == CallbackReminderSettings:
+		b.deleteMessage(chatID, messageID)
+		b.showReminderSettings(chatID, svc)
+
+	case strings.HasPrefix(data, CallbackReminderHour):
+		hour, _ := strconv.Atoi(data[len(CallbackReminderHour):])
+		if err := svc.SetReminderTime(hour * 60); err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		b.deleteMessage(chatID, messageID)
+		b.showReminderSettings(chatID, svc)
+
+	case data == CallbackReminderTimeInput:
+		userStates[chatID] = UserState{Step: "enter_reminder_time"}
+		b.send(chatID, tgbotapi.NewMessage(chatID, "⏰ Введите время напоминания в формате ЧЧ:ММ, например 20:30:"))
+
+	case strings.HasPrefix(data, CallbackReminderDay):
+		day, err := strconv.Atoi(data[len(CallbackReminderDay):])
+		if err != nil || day < 0 || day > 6 {
+			return true
+		}
+		user, err := b.repo.GetOrCreateUser(chatID, "", "", "")
+		if err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		if err := svc.SetReminderDays(user.ReminderDays ^ 1<<day); err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		b.deleteMessage(chatID, messageID)
+		b.showReminderSettings(chatID, svc)
+
+	case data == CallbackTimezoneList:
+		b.deleteMessage(chatID, messageID)
+		b.showTimezoneOptions(chatID, svc)
+
+	case strings.HasPrefix(data, CallbackTimezoneSet):
+		if err := svc.SetTimezone(data[len(CallbackTimezoneSet):]); err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		b.deleteMessage(chatID, messageID)
+		b.showReminderSettings(chatID, svc)
+
+	case data == CallbackTimezoneInput:
+		userStates[chatID] = UserState{Step: "enter_timezone"}
+		b.send(chatID, tgbotapi.NewMessage(chatID, "🌍 Введите часовой пояс в формате региона, например Europe/Berlin или Asia/Almaty:"))
+
+	default:
+		return false
+	}
+	return true
+}
+
+func (b *Bot) showReminderSettings(chatID int64, svc *service.FinanceService) {
+	user, err := b.repo.GetOrCreateUser(chatID, "", "", "")
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	text := fmt.Sprintf("⏰ <b>Напоминания и часовой пояс</b>\n\n"+
+		"Часовой пояс: <b>%s</b>\nСейчас у вас: <b>%s</b>\n\n"+
+		"Время напоминания: <b>%s</b>\nДни: <b>%s</b>\n\n"+
+		"<i>Напоминание приходит, если за день не добавлено ни одной операции. "+
+		"По этому же поясу считаются дни и месяцы в отчётах.</i>",
+		timezoneLabel(svc.Location()), svc.Now().Format("15:04"),
+		formatClock(user.ReminderMinutes), reminderDaysLabel(user.ReminderDays))
+
+	mark := func(ok bool) string {
+		if ok {
+			return "✅ "
+		}
+		return ""
+	}
+
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("🌍 Часовой пояс", CallbackTimezoneList)),
+	}
+
+	var hours []tgbotapi.InlineKeyboardButton
+	for _, h := range service.ReminderHours {
+		hours = append(hours, tgbotapi.NewInlineKeyboardButtonData(
+			fmt.Sprintf("%s%02d:00", mark(user.ReminderMinutes == h*60), h), CallbackReminderHour+strconv.Itoa(h)))
+		if len(hours) == 3 {
+			rows = append(rows, hours)
+			hours = nil
+		}
+	}
+	if len(hours) > 0 {
+		rows = append(rows, hours)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("✏️ Другое время", CallbackReminderTimeInput)))
+
+	var days []tgbotapi.InlineKeyboardButton
+	for i, name := range weekdayShortNames {
+		label := name
+		if user.ReminderDays&(1<<i) != 0 {
+			label = "✅" + name
+		}
+		days = append(days, tgbotapi.NewInlineKeyboardButtonData(label, CallbackReminderDay+strconv.Itoa(i)))
+	}
+	rows = append(rows, days,
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", "notification_settings")),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) showTimezoneOptions(chatID int64, svc *service.FinanceService) {
+	current := svc.Location().String()
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, tz := range timezoneOptions {
+		label := fmt.Sprintf("%s (%s)", tz.label, utcOffset(loadTimezone(tz.name)))
+		if tz.name == current {
+			label = "✅ " + label
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, CallbackTimezoneSet+tz.name))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("✏️ Другой пояс", CallbackTimezoneInput)),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", CallbackReminderSettings)),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🌍 <b>Часовой пояс</b>\n\nСейчас: <b>%s</b>\n\nВыберите город с нужным временем:", timezoneLabel(svc.Location())))
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) handleReminderTimeInput(m *tgbotapi.Message, svc *service.FinanceService) {
+	minutes, err := parseClock(m.Text)
+	if err != nil {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите время в формате ЧЧ:ММ, например 20:30:"))
+		return
+	}
+	if err := svc.SetReminderTime(minutes); err != nil {
+		b.sendError(m.Chat.ID, err)
+		return
+	}
+	delete(userStates, m.From.ID)
+
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✅ Напоминание будет приходить в %s.", formatClock(minutes))))
+	b.showReminderSettings(m.Chat.ID, svc)
+}
+
+func (b *Bot) handleTimezoneInput(m *tgbotapi.Message, svc *service.FinanceService) {
+	if err := svc.SetTimezone(m.Text); err != nil {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Не знаю такой часовой пояс. Пример: Europe/Berlin"))
+		return
+	}
+	delete(userStates, m.From.ID)
+
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✅ Часовой пояс: %s", timezoneLabel(svc.Location()))))
+	b.showReminderSettings(m.Chat.ID, svc)
+}
+
+func parseClock(text string) (int, error) {
+	text = strings.ReplaceAll(strings.TrimSpace(text), ".", ":")
+	for _, layout := range []string{"15:04", "15"} {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t.Hour()*60 + t.Minute(), nil
+		}
+	}
+	return 0, fmt.Errorf("неверный формат времени")
+}
+
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+func reminderDaysLabel(days int) string {
+	switch days {
+	case repository.AllWeekdays:
+		return "каждый день"
+	case 0:
+		return "не выбраны"
+	}
+	var names []string
+	for i, name := range weekdayShortNames {
+		if days&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+func loadTimezone(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func timezoneLabel(loc *time.Location) string {
+	name := loc.String()
+	for _, tz := range timezoneOptions {
+		if tz.name == name {
+			name = tz.label
+			break
+		}
+	}
+	return fmt.Sprintf("%s, %s", name, utcOffset(loc))
+}
+
+func utcOffset(loc *time.Location) string {
+	_, offset := time.Now().In(loc).Zone()
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+	if offset%3600 != 0 {
+		return fmt.Sprintf("UTC%s%d:%02d", sign, offset/3600, offset%3600/60)
+	}
+	return fmt.Sprintf("UTC%s%d", sign, offset/3600)
+}
+
+func (b *Bot) reminderPlanner(testMode bool) scheduler.Handler {
+	return func(job repository.Job) (string, error) {
+		users, err := b.repo.GetAllUsers()
+		if err != nil {
+			return "", err
+		}
+
+		now := time.Now()
+		planned := 0
+		for _, user := range users {
+			if !user.CanNotify() {
+				continue
+			}
+
+			local := now.In(user.Location())
+			if !testMode && !reminderDue(user, local) {
+				continue
+			}
+
+			key := fmt.Sprintf("%d:%s", user.ID, local.Format("2006-01-02"))
+			added, err := b.jobs.Enqueue(JobReminder, key, strconv.Itoa(user.ID), now)
+			if err != nil {
+				logger.Error("Reminder enqueue error", "user_id", user.TelegramID, "error", err)
+				continue
+			}
+			if added {
+				planned++
+			}
+		}
+		return fmt.Sprintf("planned %d reminders", planned), nil
+	}
+}
+
+func reminderDue(user repository.User, local time.Time) bool {
+	if !user.RemindsOn(local.Weekday()) {
+		return false
+	}
+	minutes := local.Hour()*60 + local.Minute()
+	return minutes >= user.ReminderMinutes && minutes < user.ReminderMinutes+reminderCatchUpMinutes
+}
+
+func (b *Bot) runReminder(testMode bool) scheduler.Handler {
+	return func(job repository.Job) (string, error) {
+		userID, err := strconv.Atoi(job.Payload)
+		if err != nil {
+			return "", fmt.Errorf("bad reminder payload %q", job.Payload)
+		}
+		user, err := b.repo.GetUserByID(userID)
+		if err != nil {
+			return "", err
+		}
+		if user == nil || !user.CanNotify() {
+			return "skipped: notifications off or chat unreachable", nil
+		}
+
+		hasTransactions, err := b.repo.HasTransactionsToday(user.ID, time.Now().In(user.Location()))
+		if err != nil {
+			return "", err
+		}
+		if hasTransactions {
+			return "skipped: has transactions today", nil
+		}
+
+		msg := tgbotapi.NewMessage(user.TelegramID, reminderText(testMode))
+		msg.ParseMode = tgbotapi.ModeHTML
+		if err := b.deliver(user.TelegramID, msg); err != nil {
+			return "", err
+		}
+		logger.Info("Reminder sent", "user_id", user.TelegramID, "timezone", user.Timezone)
+		return "sent", nil
+	}
+}
+
+func reminderText(testMode bool) string {
+	message := "💡 <b>Напоминание о транзакциях</b>\n\n" +
+		"Привет! Похоже, ты сегодня еще не добавлял(а) ни одной транзакции.\n\n" +
+		"Не забывай вести учет своих финансов — это поможет лучше контролировать бюджет!\n\n" +
+		"➕ Нажми \"Добавить операцию\" "
+
+	if testMode {
+		message = "🔔 <b>ТЕСТ: Напоминание о транзакциях</b>\n\n" +
+			"Это тестовое напоминание (в рабочем режиме приходит в выбранное в настройках время).\n\n" +
+			message
+	}
+	return message
+}
@@ -30,81 +30,69 @@ func NewReportGenerator(bot *Bot, repo *repository.SQLiteRepository) *ReportGene
 	}
 }
 
-func (rg *ReportGenerator) GeneratePDFReport(chatID int64, start, end time.Time, svc *service.FinanceService) ([]byte, error) {
+func (rg *ReportGenerator) GeneratePDFReport(chatID int64, start, end time.Time, svc *service.FinanceService, lang string) ([]byte, error) {
 	transactions, err := svc.GetTransactionsForPeriod(start, end)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка получения транзакций: %v", err)
 	}
 
-	pdf := gofpdf.New("P", "mm", "A4", "")
-	fontPath := filepath.Join("fonts", "DejaVuSans.ttf")
-	pdf.AddUTF8Font("DejaVuSans", "", fontPath)
-	pdf.AddUTF8Font("DejaVuSans", "B", fontPath)
-	pdf.SetMargins(10, 10, 10)
+	currency, err := svc.GetCurrency()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения валюты: %v", err)
+	}
+	loc := reportLocale{lang: lang, currency: currency}
+
+	pdf := rg.newPDF(loc)
 	pdf.AddPage()
 	pdf.SetFont("DejaVuSans", "B", 20)
 	pdf.SetTextColor(30, 30, 30)
-	pdf.CellFormat(190, 10, "Финансовый отчёт", "", 1, "C", false, 0, "")
+	pdf.CellFormat(190, 10, loc.text("title"), "", 1, "C", false, 0, "")
 	pdf.SetFont("DejaVuSans", "", 12)
-	pdf.CellFormat(190, 8, fmt.Sprintf("Период: %s – %s", start.Format("02.01.2006"), end.Format("02.01.2006")), "", 1, "C", false, 0, "")
-	pdf.CellFormat(190, 8, fmt.Sprintf("Сформировано: %s", time.Now().Format("02.01.2006 15:04")), "", 1, "C", false, 0, "")
+	pdf.CellFormat(190, 8, loc.textf("period", loc.date(start), loc.date(end)), "", 1, "C", false, 0, "")
+	pdf.CellFormat(190, 8, loc.textf("generated", loc.date(svc.Now())+svc.Now().Format(" 15:04")), "", 1, "C", false, 0, "")
 	pdf.Ln(10)
 
-	var (
-		totalIncome, totalExpense float64
-		incomeDetails             = make(map[string]float64)
-		expenseDetails            = make(map[string]float64)
-		incomeTrend               []chart.Value
-		expenseTrend              []chart.Value
-		balanceTrend              []chart.Value
-		balanceByDate             = make(map[string]float64)
-	)
-
-	dates := map[string]bool{}
-	for _, t := range transactions {
-		dateStr := t.Date.Format("2006-01-02")
-		cat := removeEmoji(t.CategoryName)
-		if cat == "" {
-			cat = "Неизвестно"
-		}
-		if t.Amount > 0 {
-			totalIncome += t.Amount
-			incomeDetails[cat] += t.Amount
-		} else {
-			amount := -t.Amount
-			totalExpense += amount
-			expenseDetails[cat] += amount
-		}
-		balanceByDate[dateStr] += t.Amount
-		dates[dateStr] = true
+	sums, err := svc.GetCategorySums(start, end)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения сумм по категориям: %v", err)
+	}
+	daily, err := svc.GetDailySums(start, end)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения сумм по дням: %v", err)
 	}
 
-	var dateList []string
-	for date := range dates {
-		dateList = append(dateList, date)
+	incomeDetails := make(map[string]float64)
+	expenseDetails := make(map[string]float64)
+	income, expense := service.RootCategoryTotals(sums)
+	for name, amount := range income {
+		incomeDetails[localizedCategory(name, loc)] += amount
 	}
-	sort.Strings(dateList)
+	for name, amount := range expense {
+		expenseDetails[localizedCategory(name, loc)] += amount
+	}
+	totalIncome, totalExpense := sum(incomeDetails), sum(expenseDetails)
 
+	var incomeTrend, expenseTrend, balanceTrend []chart.Value
 	var incomeSum, expenseSum, balanceSum float64
-	for _, d := range dateList {
-		dayBalance := balanceByDate[d]
+	for _, d := range daily {
+		dayBalance := d.Income - d.Expense
 		if dayBalance > 0 {
 			incomeSum += dayBalance
 		} else {
 			expenseSum += -dayBalance
 		}
 		balanceSum += dayBalance
-		incomeTrend = append(incomeTrend, chart.Value{Label: d, Value: incomeSum})
-		expenseTrend = append(expenseTrend, chart.Value{Label: d, Value: expenseSum})
-		balanceTrend = append(balanceTrend, chart.Value{Label: d, Value: balanceSum})
+		incomeTrend = append(incomeTrend, chart.Value{Label: d.Period, Value: incomeSum})
+		expenseTrend = append(expenseTrend, chart.Value{Label: d.Period, Value: expenseSum})
+		balanceTrend = append(balanceTrend, chart.Value{Label: d.Period, Value: balanceSum})
 	}
 
 	pdf.SetFont("DejaVuSans", "B", 16)
-	pdf.CellFormat(190, 10, "Общая статистика", "", 1, "L", false, 0, "")
+	pdf.CellFormat(190, 10, loc.text("summary"), "", 1, "L", false, 0, "")
 	pdf.SetFont("DejaVuSans", "", 12)
-	pdf.CellFormat(190, 8, fmt.Sprintf("Общий доход: %.2f ₽", totalIncome), "", 1, "L", false, 0, "")
-	pdf.CellFormat(190, 8, fmt.Sprintf("Общий расход: %.2f ₽", totalExpense), "", 1, "L", false, 0, "")
-	pdf.CellFormat(190, 8, fmt.Sprintf("Баланс: %.2f ₽", totalIncome-totalExpense), "", 1, "L", false, 0, "")
+	pdf.CellFormat(190, 8, loc.textf("total_income", loc.money(totalIncome)), "", 1, "L", false, 0, "")
+	pdf.CellFormat(190, 8, loc.textf("total_expense", loc.money(totalExpense)), "", 1, "L", false, 0, "")
+	pdf.CellFormat(190, 8, loc.textf("balance", loc.money(totalIncome-totalExpense)), "", 1, "L", false, 0, "")
 	pdf.Ln(10)
 
 	startY := pdf.GetY()
@@ -121,19 +109,19 @@ func (rg *ReportGenerator) GeneratePDFReport(chatID int64, start, end time.Time,
 	pdf.Ln(5)
 
 	pdf.SetFont("DejaVuSans", "B", 14)
-	pdf.CellFormat(190, 10, "Распределение по категориям", "", 1, "L", false, 0, "")
+	pdf.CellFormat(190, 10, loc.text("distribution"), "", 1, "L", false, 0, "")
 	pdf.Ln(3)
 
 	yStart := pdf.GetY()
 
 	if len(incomeDetails) > 0 {
-		incomeChart, legendIncome, colorsIncome := rg.generatePieWithLegend(incomeDetails)
+		incomeChart, legendIncome, colorsIncome := rg.generatePieWithLegend(incomeDetails, loc)
 		rg.addImageToPDF(pdf, incomeChart, "", 10, yStart, 90, 60)
 		rg.addLegendWithColor(pdf, legendIncome, colorsIncome, 10, yStart+62)
 	}
 
 	if len(expenseDetails) > 0 {
-		expenseChart, legendExpense, colorsExpense := rg.generatePieWithLegend(expenseDetails)
+		expenseChart, legendExpense, colorsExpense := rg.generatePieWithLegend(expenseDetails, loc)
 		rg.addImageToPDF(pdf, expenseChart, "", 110, yStart, 90, 60)
 		rg.addLegendWithColor(pdf, legendExpense, colorsExpense, 110, yStart+62)
 	}
@@ -142,37 +130,59 @@ func (rg *ReportGenerator) GeneratePDFReport(chatID int64, start, end time.Time,
 	pdf.Ln(10)
 
 	pdf.SetFont("DejaVuSans", "B", 14)
-	pdf.CellFormat(190, 10, "Детализация по категориям", "", 1, "L", false, 0, "")
+	pdf.CellFormat(190, 10, loc.text("details"), "", 1, "L", false, 0, "")
 	pdf.SetFont("DejaVuSans", "", 11)
 	pdf.Ln(3)
 
 	if len(incomeDetails) > 0 {
-		pdf.CellFormat(190, 7, "Доходы:", "", 1, "L", false, 0, "")
+		pdf.CellFormat(190, 7, loc.text("income"), "", 1, "L", false, 0, "")
 		total := sum(incomeDetails)
 		for _, cat := range sortedKeys(incomeDetails) {
 			amount := incomeDetails[cat]
 			percent := amount / total * 100
-			pdf.CellFormat(190, 6, fmt.Sprintf("  • %-20s %.2f ₽ (%.0f%%)", cat, amount, percent), "", 1, "L", false, 0, "")
+			pdf.CellFormat(190, 6, fmt.Sprintf("  • %-20s %s (%.0f%%)", cat, loc.money(amount), percent), "", 1, "L", false, 0, "")
 		}
 		pdf.Ln(4)
 	}
 
 	if len(expenseDetails) > 0 {
-		pdf.CellFormat(190, 7, "Расходы:", "", 1, "L", false, 0, "")
+		pdf.CellFormat(190, 7, loc.text("expense"), "", 1, "L", false, 0, "")
 		total := sum(expenseDetails)
 		for _, cat := range sortedKeys(expenseDetails) {
 			amount := expenseDetails[cat]
 			percent := amount / total * 100
-			pdf.CellFormat(190, 6, fmt.Sprintf("  • %-20s %.2f ₽ (%.0f%%)", cat, amount, percent), "", 1, "L", false, 0, "")
+			pdf.CellFormat(190, 6, fmt.Sprintf("  • %-20s %s (%.0f%%)", cat, loc.money(amount), percent), "", 1, "L", false, 0, "")
 		}
 	}
 
+	rg.addSubcategorySection(pdf, sums, loc)
+
 	pdf.Ln(10)
 
 	pdf.SetFont("DejaVuSans", "B", 14)
-	pdf.CellFormat(190, 10, "Автоматический анализ", "", 1, "L", false, 0, "")
+	pdf.CellFormat(190, 10, loc.text("analysis"), "", 1, "L", false, 0, "")
 	pdf.SetFont("DejaVuSans", "", 12)
-	pdf.MultiCell(190, 7, generateInsights(totalIncome, totalExpense, incomeDetails, expenseDetails), "", "L", false)
+	pdf.MultiCell(190, 7, generateInsights(totalIncome, totalExpense, incomeDetails, expenseDetails, loc), "", "L", false)
+
+	if err := rg.addComparisonSection(pdf, start, end, svc, loc); err != nil {
+		return nil, fmt.Errorf("ошибка раздела сравнения: %v", err)
+	}
+
+	if err := rg.addLoansSection(pdf, start, end, svc, loc); err != nil {
+		return nil, fmt.Errorf("ошибка раздела кредитов: %v", err)
+	}
+
+	if err := rg.addHouseholdSection(pdf, start, end, svc, loc); err != nil {
+		return nil, fmt.Errorf("ошибка раздела семьи: %v", err)
+	}
+
+	patterns, err := svc.GetSpendingPatterns(start, end)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка раздела паттернов: %v", err)
+	}
+	rg.addPatternsSection(pdf, start, end, patterns, loc)
+
+	rg.addTransactionsAppendix(pdf, transactions, loc)
 
 	var buf bytes.Buffer
 	if err := pdf.Output(&buf); err != nil {
@@ -182,6 +192,89 @@ func (rg *ReportGenerator) GeneratePDFReport(chatID int64, start, end time.Time,
 	return buf.Bytes(), nil
 }
 
+func localizedCategory(name string, loc reportLocale) string {
+	name = removeEmoji(name)
+	if name == "" {
+		return loc.text("unknown")
+	}
+	return name
+}
+
+func (rg *ReportGenerator) addTransactionsAppendix(pdf *gofpdf.Fpdf, transactions []repository.Transaction, loc reportLocale) {
+	pdf.AddPage()
+	pdf.SetFont("DejaVuSans", "B", 14)
+	pdf.CellFormat(190, 10, loc.text("appendix"), "", 1, "L", false, 0, "")
+
+	if len(transactions) == 0 {
+		pdf.SetFont("DejaVuSans", "", 11)
+		pdf.CellFormat(190, 6, loc.text("no_transactions"), "", 1, "L", false, 0, "")
+		return
+	}
+
+	sorted := make([]repository.Transaction, len(transactions))
+	copy(sorted, transactions)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+
+	widths := []float64{28, 55, 72, 35}
+	header := func() {
+		pdf.SetFont("DejaVuSans", "B", 10)
+		pdf.SetFillColor(235, 235, 235)
+		for i, h := range []string{loc.text("col_date"), loc.text("col_category"), loc.text("col_comment"), loc.text("col_amount")} {
+			pdf.CellFormat(widths[i], 7, h, "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("DejaVuSans", "", 9)
+	}
+	header()
+
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+	for _, t := range sorted {
+		if pdf.GetY()+6 > pageHeight-bottom-5 {
+			pdf.AddPage()
+			header()
+		}
+
+		category := removeEmoji(t.CategoryName)
+		if t.ParentCategoryName != "" {
+			category = removeEmoji(t.ParentCategoryName) + " / " + category
+		}
+		pdf.CellFormat(widths[0], 6, loc.date(t.Date), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 6, fitText(pdf, category, widths[1]-2), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], 6, fitText(pdf, t.Comment, widths[2]-2), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[3], 6, loc.money(t.Amount), "1", 1, "R", false, 0, "")
+	}
+}
+
+func (rg *ReportGenerator) newPDF(loc reportLocale) *gofpdf.Fpdf {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	fontPath := filepath.Join("fonts", "DejaVuSans.ttf")
+	pdf.AddUTF8Font("DejaVuSans", "", fontPath)
+	pdf.AddUTF8Font("DejaVuSans", "B", fontPath)
+	pdf.SetMargins(10, 10, 10)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("DejaVuSans", "", 8)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(190, 5, loc.textf("page", pdf.PageNo()), "", 0, "C", false, 0, "")
+		pdf.SetTextColor(30, 30, 30)
+	})
+	return pdf
+}
+
+func fitText(pdf *gofpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
 func (rg *ReportGenerator) generateLineChart(data []chart.Value, color drawing.Color) ([]byte, error) {
 	graph := chart.Chart{
 		Width:  600,
@@ -204,7 +297,7 @@ func (rg *ReportGenerator) generateLineChart(data []chart.Value, color drawing.C
 	return buf.Bytes(), err
 }
 
-func (rg *ReportGenerator) generatePieWithLegend(data map[string]float64) ([]byte, []string, []color.Color) {
+func (rg *ReportGenerator) generatePieWithLegend(data map[string]float64, loc reportLocale) ([]byte, []string, []color.Color) {
 	var values []chart.Value
 	var legend []string
 	var colors []color.Color
@@ -221,7 +314,7 @@ func (rg *ReportGenerator) generatePieWithLegend(data map[string]float64) ([]byt
 			Label: "",
 			Style: chart.Style{FillColor: c},
 		})
-		legend = append(legend, fmt.Sprintf("%s – %.2f ₽ (%.0f%%)", k, val, percent))
+		legend = append(legend, fmt.Sprintf("%s – %s (%.0f%%)", k, loc.money(val), percent))
 		colors = append(colors, c)
 	}
 
@@ -310,23 +403,17 @@ func removeEmoji(text string) string {
 	return strings.TrimSpace(b.String())
 }
 
-func generateInsights(income, expense float64, incomeCat, expenseCat map[string]float64) string {
+func generateInsights(income, expense float64, incomeCat, expenseCat map[string]float64, loc reportLocale) string {
 	balance := income - expense
-	direction := "Положительный"
+	direction := loc.text("positive")
 	if balance < 0 {
-		direction = "Отрицательный"
+		direction = loc.text("negative")
 	}
 
 	topIncome := topCategory(incomeCat)
 	topExpense := topCategory(expenseCat)
 
-	return fmt.Sprintf(
-		"Ваш баланс за период составил: %.2f ₽ (%s).\n"+
-			"Основной источник дохода: %s.\n"+
-			"Основная статья расходов: %s.\n"+
-			"Рекомендуем обратить внимание на контроль расходов в наиболее активной категории.",
-		balance, direction, topIncome, topExpense,
-	)
+	return loc.textf("insights", loc.money(balance), direction, topIncome, topExpense)
 }
 
 func topCategory(data map[string]float64) string {
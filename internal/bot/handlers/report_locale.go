@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	LangRU = "ru"
+	LangEN = "en"
+)
+
+type reportLocale struct {
+	lang     string
+	currency string
+}
+
+var reportTexts = map[string]map[string]string{
+	LangRU: {
+		"title":              "Финансовый отчёт",
+		"period":             "Период: %s – %s",
+		"generated":          "Сформировано: %s",
+		"summary":            "Общая статистика",
+		"total_income":       "Общий доход: %s",
+		"total_expense":      "Общий расход: %s",
+		"balance":            "Баланс: %s",
+		"distribution":       "Распределение по категориям",
+		"details":            "Детализация по категориям",
+		"income":             "Доходы:",
+		"expense":            "Расходы:",
+		"analysis":           "Автоматический анализ",
+		"insights":           "Ваш баланс за период составил: %s (%s).\nОсновной источник дохода: %s.\nОсновная статья расходов: %s.\nРекомендуем обратить внимание на контроль расходов в наиболее активной категории.",
+		"positive":           "Положительный",
+		"negative":           "Отрицательный",
+		"unknown":            "Неизвестно",
+		"subcategories":      "Подкатегории",
+		"no_subcategory":     "без подкатегории",
+		"cmp_previous":       "Сравнение с предыдущим периодом",
+		"cmp_year":           "Сравнение с прошлым годом",
+		"cmp_base":           "База: %s – %s",
+		"cmp_no_data":        "Нет данных за период сравнения",
+		"cmp_income":         "Доходы: %s (было %s, %s)",
+		"cmp_expense":        "Расходы: %s (было %s, %s)",
+		"cmp_category":       "Категория расходов",
+		"cmp_now":            "Сейчас",
+		"cmp_before":         "Было",
+		"cmp_diff":           "Разница",
+		"cmp_new":            "новое",
+		"cmp_growth":         "Рост: %s %s (%s)",
+		"cmp_decline":        "Снижение: %s %s (%s)",
+		"loans":              "Кредиты",
+		"loan_principal":     "  Сумма кредита: %s, ставка %.2f%%, %s",
+		"loan_balance":       "  Остаток долга: %s",
+		"loan_paid":          "  Выплачено за период: долг %s, проценты %s",
+		"loan_next":          "  Следующий платёж: %s — %s",
+		"loan_left":          "  Осталось платежей: %d, проценты до конца срока: %s",
+		"loan_annuity":       "аннуитетный",
+		"loan_differential":  "дифференцированный",
+		"household":          "Семья: %s",
+		"household_personal": "В отчёт включены только ваши операции",
+		"household_member":   "  %s: доходы %s, расходы %s, операций %d",
+		"appendix":           "Приложение: все операции за период",
+		"col_date":           "Дата",
+		"col_category":       "Категория",
+		"col_comment":        "Комментарий",
+		"col_amount":         "Сумма",
+		"no_transactions":    "Операций за период нет",
+		"page":               "Страница %d из {nb}",
+		"file_name":          "Отчет_%s_%s.pdf",
+		"patterns":           "Паттерны трат",
+		"pt_weekday":         "Расходы по дням недели",
+		"pt_hour":            "Расходы по времени суток",
+		"pt_monthday":        "Расходы по числам месяца",
+		"pt_calendar":        "Календарь трат",
+		"pt_less":            "меньше",
+		"pt_more":            "больше",
+		"yr_title":           "Финансовый итог %d года",
+		"yr_earned":          "Заработано: %s",
+		"yr_spent":           "Потрачено: %s",
+		"yr_saved":           "Отложено в копилки: %s",
+		"yr_months":          "Доходы и расходы по месяцам",
+		"yr_best":            "Лучший месяц: %s (баланс %s)",
+		"yr_worst":           "Худший месяц: %s (баланс %s)",
+		"yr_top":             "Топ категорий расходов",
+		"yr_trends":          "Динамика категорий по месяцам",
+		"yr_purchases":       "Самые крупные покупки",
+		"yr_streak":          "Самая длинная серия без трат: %d дн. (с %s)",
+		"yr_no_streak":       "Дней без трат не было",
+		"yr_goals":           "Достигнутые цели",
+		"yr_no_goals":        "В этом году цели копилок не достигнуты",
+		"yr_file_name":       "Итоги_%d.pdf",
+	},
+	LangEN: {
+		"title":              "Financial report",
+		"period":             "Period: %s – %s",
+		"generated":          "Generated: %s",
+		"summary":            "Summary",
+		"total_income":       "Total income: %s",
+		"total_expense":      "Total expenses: %s",
+		"balance":            "Balance: %s",
+		"distribution":       "Breakdown by category",
+		"details":            "Category details",
+		"income":             "Income:",
+		"expense":            "Expenses:",
+		"analysis":           "Automatic analysis",
+		"insights":           "Your balance for the period: %s (%s).\nMain source of income: %s.\nLargest expense category: %s.\nConsider keeping a closer eye on spending in the most active category.",
+		"positive":           "Positive",
+		"negative":           "Negative",
+		"unknown":            "Unknown",
+		"subcategories":      "Subcategories",
+		"no_subcategory":     "no subcategory",
+		"cmp_previous":       "Compared with the previous period",
+		"cmp_year":           "Compared with last year",
+		"cmp_base":           "Baseline: %s – %s",
+		"cmp_no_data":        "No data for the comparison period",
+		"cmp_income":         "Income: %s (was %s, %s)",
+		"cmp_expense":        "Expenses: %s (was %s, %s)",
+		"cmp_category":       "Expense category",
+		"cmp_now":            "Now",
+		"cmp_before":         "Was",
+		"cmp_diff":           "Change",
+		"cmp_new":            "new",
+		"cmp_growth":         "Up: %s %s (%s)",
+		"cmp_decline":        "Down: %s %s (%s)",
+		"loans":              "Loans",
+		"loan_principal":     "  Principal: %s, rate %.2f%%, %s",
+		"loan_balance":       "  Outstanding: %s",
+		"loan_paid":          "  Paid in period: principal %s, interest %s",
+		"loan_next":          "  Next payment: %s — %s",
+		"loan_left":          "  Payments left: %d, interest until the end: %s",
+		"loan_annuity":       "annuity",
+		"loan_differential":  "differentiated",
+		"household":          "Household: %s",
+		"household_personal": "Only your own transactions are included",
+		"household_member":   "  %s: income %s, expenses %s, transactions %d",
+		"appendix":           "Appendix: all transactions for the period",
+		"col_date":           "Date",
+		"col_category":       "Category",
+		"col_comment":        "Comment",
+		"col_amount":         "Amount",
+		"no_transactions":    "No transactions for the period",
+		"page":               "Page %d of {nb}",
+		"file_name":          "Report_%s_%s.pdf",
+		"patterns":           "Spending patterns",
+		"pt_weekday":         "Expenses by day of week",
+		"pt_hour":            "Expenses by time of day",
+		"pt_monthday":        "Expenses by day of month",
+		"pt_calendar":        "Spending calendar",
+		"pt_less":            "less",
+		"pt_more":            "more",
+		"yr_title":           "%d in review",
+		"yr_earned":          "Earned: %s",
+		"yr_spent":           "Spent: %s",
+		"yr_saved":           "Put into savings: %s",
+		"yr_months":          "Income and expenses by month",
+		"yr_best":            "Best month: %s (balance %s)",
+		"yr_worst":           "Worst month: %s (balance %s)",
+		"yr_top":             "Top expense categories",
+		"yr_trends":          "Category trends by month",
+		"yr_purchases":       "Biggest purchases",
+		"yr_streak":          "Longest no-spend streak: %d days (from %s)",
+		"yr_no_streak":       "There were no days without spending",
+		"yr_goals":           "Goals reached",
+		"yr_no_goals":        "No savings goals were reached this year",
+		"yr_file_name":       "Year_%d.pdf",
+	},
+}
+
+func reportLanguage(languageCode string) string {
+	if strings.HasPrefix(strings.ToLower(languageCode), LangEN) {
+		return LangEN
+	}
+	return LangRU
+}
+
+func (l reportLocale) text(key string) string {
+	if s, ok := reportTexts[l.lang][key]; ok {
+		return s
+	}
+	return reportTexts[LangRU][key]
+}
+
+func (l reportLocale) textf(key string, args ...interface{}) string {
+	return fmt.Sprintf(l.text(key), args...)
+}
+
+func (l reportLocale) money(amount float64) string {
+	return formatAmount(amount, l.currency, l.lang)
+}
+
+func (l reportLocale) signedMoney(amount float64) string {
+	if amount > 0 {
+		return "+" + l.money(amount)
+	}
+	return l.money(amount)
+}
+
+func (l reportLocale) date(t time.Time) string {
+	if l.lang == LangEN {
+		return t.Format("Jan 2, 2006")
+	}
+	return t.Format("02.01.2006")
+}
+
+func (l reportLocale) monthShort(m time.Month) string {
+	if l.lang == LangEN {
+		return m.String()[:3]
+	}
+	return monthShortName(m)
+}
+
+func (l reportLocale) weekdayShort(i int) string {
+	if l.lang == LangEN {
+		return time.Weekday((i + 1) % 7).String()[:3]
+	}
+	return weekdayShortNames[i]
+}
+
+func (l reportLocale) month(t time.Time) string {
+	if l.lang == LangEN {
+		return t.Format("January 2006")
+	}
+	return monthNames[t.Month()-1] + " " + t.Format("2006")
+}
+
+func groupThousands(amount float64, lang string) string {
+	thousands, decimal := "\u00a0", ","
+	if lang == LangEN {
+		thousands, decimal = ",", "."
+	}
+
+	cents := int64(math.Round(math.Abs(amount) * 100))
+	whole := fmt.Sprintf("%d", cents/100)
+
+	var b strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(thousands)
+		}
+		b.WriteRune(r)
+	}
+	return fmt.Sprintf("%s%s%02d", b.String(), decimal, cents%100)
+}
+
+func formatAmount(amount float64, currency, lang string) string {
+	sign := ""
+	if math.Round(amount*100) < 0 {
+		sign = "-"
+	}
+	number := groupThousands(amount, lang)
+
+	switch currency {
+	case CurrencyRUB:
+		return fmt.Sprintf("%s%s ₽", sign, number)
+	case CurrencyUSD:
+		return fmt.Sprintf("%s$%s", sign, number)
+	case CurrencyEUR:
+		return fmt.Sprintf("%s€%s", sign, number)
+	default:
+		return fmt.Sprintf("%s%s %s", sign, number, currency)
+	}
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/logger"
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+	"github.com/IlyaMakar/finance_bot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CallbackSavingRules     = "saving_rules_"
+	CallbackAddRulePercent  = "add_rule_percent_"
+	CallbackAddRuleRoundUp  = "add_rule_roundup_"
+	CallbackAddRuleSchedule = "add_rule_schedule_"
+	CallbackDeleteRule      = "delete_rule_"
+	CallbackSavingAutoLog   = "saving_autolog_"
+	CallbackUndoAutoMove    = "undo_move_"
+)
+
+func (b *Bot) handleSavingRuleCallback(chatID int64, messageID int, data string, svc *service.FinanceService) bool {
+	switch {
+	case strings.HasPrefix(data, CallbackSavingRules):
+		savingID, _ := strconv.Atoi(data[len(CallbackSavingRules):])
+		b.deleteMessage(chatID, messageID)
+		b.showSavingRules(chatID, savingID, svc)
+
+	case strings.HasPrefix(data, CallbackAddRulePercent):
+		savingID, _ := strconv.Atoi(data[len(CallbackAddRulePercent):])
+		state := userStates[chatID]
+		state.Step = "enter_rule_percent"
+		state.TempCategoryID = savingID
+		userStates[chatID] = state
+		b.send(chatID, tgbotapi.NewMessage(chatID, "📈 Какой процент от каждого дохода откладывать? Введите число, например 10:"))
+
+	case strings.HasPrefix(data, CallbackAddRuleRoundUp):
+		savingID, _ := strconv.Atoi(data[len(CallbackAddRuleRoundUp):])
+		if err := svc.CreateSavingRule(savingID, repository.SavingRuleRoundUp, 100, 0); err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		b.deleteMessage(chatID, messageID)
+		b.send(chatID, tgbotapi.NewMessage(chatID, "✅ Теперь каждый расход будет округляться до 100, а разница — уходить в копилку."))
+		b.showSavingRules(chatID, savingID, svc)
+
+	case strings.HasPrefix(data, CallbackAddRuleSchedule):
+		savingID, _ := strconv.Atoi(data[len(CallbackAddRuleSchedule):])
+		state := userStates[chatID]
+		state.Step = "enter_rule_schedule_amount"
+		state.TempCategoryID = savingID
+		userStates[chatID] = state
+		b.send(chatID, tgbotapi.NewMessage(chatID, "💵 Какую сумму откладывать по расписанию?"))
+
+	case strings.HasPrefix(data, CallbackDeleteRule):
+		parts := strings.Split(data[len(CallbackDeleteRule):], "_")
+		if len(parts) != 2 {
+			b.sendError(chatID, fmt.Errorf("неверный формат правила"))
+			return true
+		}
+		savingID, _ := strconv.Atoi(parts[0])
+		ruleID, _ := strconv.Atoi(parts[1])
+		if err := svc.DeleteSavingRule(ruleID); err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		b.deleteMessage(chatID, messageID)
+		b.send(chatID, tgbotapi.NewMessage(chatID, "✅ Правило удалено"))
+		b.showSavingRules(chatID, savingID, svc)
+
+	case strings.HasPrefix(data, CallbackSavingAutoLog):
+		savingID, _ := strconv.Atoi(data[len(CallbackSavingAutoLog):])
+		b.deleteMessage(chatID, messageID)
+		b.showSavingAutoMoves(chatID, savingID, svc)
+
+	case strings.HasPrefix(data, CallbackUndoAutoMove):
+		moveID, _ := strconv.Atoi(data[len(CallbackUndoAutoMove):])
+		move, err := svc.UndoAutoMove(moveID)
+		if err != nil {
+			b.sendError(chatID, err)
+			return true
+		}
+		b.send(chatID, tgbotapi.NewMessage(chatID, fmt.Sprintf("↩️ Автопополнение копилки «%s» на %s отменено",
+			move.SavingName, b.formatCurrency(move.Amount, chatID))))
+
+	default:
+		return false
+	}
+	return true
+}
+
+func (b *Bot) describeSavingRule(rule repository.SavingRule, chatID int64) string {
+	switch rule.Type {
+	case repository.SavingRulePercentIncome:
+		return fmt.Sprintf("%.0f%% от каждого дохода", rule.Value)
+	case repository.SavingRuleRoundUp:
+		return fmt.Sprintf("Округление расходов до %.0f", rule.Value)
+	case repository.SavingRuleSchedule:
+		text := fmt.Sprintf("%s каждые %d дн.", b.formatCurrency(rule.Value, chatID), rule.IntervalDays)
+		if rule.NextRun != nil {
+			text += fmt.Sprintf(" (след. %s)", rule.NextRun.Format("02.01.2006"))
+		}
+		return text
+	}
+	return rule.Type
+}
+
+func ruleTypeIcon(ruleType string) string {
+	switch ruleType {
+	case repository.SavingRulePercentIncome:
+		return "📈"
+	case repository.SavingRuleRoundUp:
+		return "🪙"
+	case repository.SavingRuleSchedule:
+		return "🗓"
+	}
+	return "🤖"
+}
+
+func (b *Bot) showSavingRules(chatID int64, savingID int, svc *service.FinanceService) {
+	saving, err := svc.GetSavingByID(savingID)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	rules, err := svc.GetSavingRules(savingID)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("🤖 <b>Автопополнение копилки «%s»</b>\n\n", html.EscapeString(saving.Name)))
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if len(rules) == 0 {
+		text.WriteString("Правил пока нет. Добавьте правило, и копилка будет пополняться сама.")
+	} else {
+		for _, rule := range rules {
+			desc := b.describeSavingRule(rule, chatID)
+			text.WriteString(fmt.Sprintf("%s %s\n", ruleTypeIcon(rule.Type), desc))
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🗑 "+desc, fmt.Sprintf("%s%d_%d", CallbackDeleteRule, savingID, rule.ID)),
+			))
+		}
+	}
+
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("➕ % от дохода", fmt.Sprintf("%s%d", CallbackAddRulePercent, savingID)),
+			tgbotapi.NewInlineKeyboardButtonData("➕ Округление", fmt.Sprintf("%s%d", CallbackAddRuleRoundUp, savingID)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("➕ По расписанию", fmt.Sprintf("%s%d", CallbackAddRuleSchedule, savingID)),
+			tgbotapi.NewInlineKeyboardButtonData("📜 Журнал", fmt.Sprintf("%s%d", CallbackSavingAutoLog, savingID)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", fmt.Sprintf("%s%d", CallbackEditSaving, savingID)),
+		),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) showSavingAutoMoves(chatID int64, savingID int, svc *service.FinanceService) {
+	moves, err := svc.GetSavingAutoMoves(savingID, 10)
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString("📜 <b>Журнал автопополнений</b>\n\n")
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if len(moves) == 0 {
+		text.WriteString("Автопополнений пока не было.")
+	}
+	for i, m := range moves {
+		status := ""
+		if m.Undone {
+			status = " (отменено)"
+		}
+		text.WriteString(fmt.Sprintf("%d. %s %s %s%s\n",
+			i+1, m.CreatedAt.Format("02.01.2006"), ruleTypeIcon(m.RuleType), b.formatCurrency(m.Amount, chatID), status))
+
+		if !m.Undone {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("↩️ Отменить #%d", i+1), fmt.Sprintf("%s%d", CallbackUndoAutoMove, m.ID)),
+			))
+		}
+	}
+
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", fmt.Sprintf("%s%d", CallbackSavingRules, savingID)),
+	))
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) notifyAutoMoves(chatID int64, moves []repository.SavingAutoMove) {
+	if len(moves) == 0 {
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString("🤖 <b>Автопополнение копилок</b>\n\n")
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, m := range moves {
+		formatted := b.formatCurrency(m.Amount, chatID)
+		text.WriteString(fmt.Sprintf("%s %s → «%s»\n", ruleTypeIcon(m.RuleType), formatted, html.EscapeString(m.SavingName)))
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("↩️ Отменить %s", formatted), fmt.Sprintf("%s%d", CallbackUndoAutoMove, m.ID)),
+		))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) handleRulePercent(m *tgbotapi.Message, svc *service.FinanceService) {
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(m.Text), "%"), 64)
+	if err != nil || percent <= 0 || percent > 100 {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите процент от 1 до 100:"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	if err := svc.CreateSavingRule(state.TempCategoryID, repository.SavingRulePercentIncome, percent, 0); err != nil {
+		b.sendError(m.Chat.ID, err)
+		return
+	}
+
+	delete(userStates, m.From.ID)
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✅ Теперь %.0f%% каждого дохода будет уходить в копилку.", percent)))
+	b.showSavingRules(m.Chat.ID, state.TempCategoryID, svc)
+}
+
+func (b *Bot) handleRuleScheduleAmount(m *tgbotapi.Message) {
+	amount, err := strconv.ParseFloat(m.Text, 64)
+	if err != nil || amount <= 0 {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите корректную сумму (например, 1000):"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	state.TempAmount = amount
+	state.Step = "enter_rule_schedule_days"
+	userStates[m.From.ID] = state
+
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "🗓 Как часто откладывать? Введите количество дней (например, 7 или 30):"))
+}
+
+func (b *Bot) handleRuleScheduleDays(m *tgbotapi.Message, svc *service.FinanceService) {
+	days, err := strconv.Atoi(strings.TrimSpace(m.Text))
+	if err != nil || days < 1 || days > 366 {
+		b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, "⚠️ Введите число дней от 1 до 366:"))
+		return
+	}
+
+	state := userStates[m.From.ID]
+	if err := svc.CreateSavingRule(state.TempCategoryID, repository.SavingRuleSchedule, state.TempAmount, days); err != nil {
+		b.sendError(m.Chat.ID, err)
+		return
+	}
+
+	delete(userStates, m.From.ID)
+	b.send(m.Chat.ID, tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✅ Каждые %d дн. в копилку будет откладываться %s.",
+		days, b.formatCurrency(state.TempAmount, m.Chat.ID))))
+	b.showSavingRules(m.Chat.ID, state.TempCategoryID, svc)
+}
+
+func (b *Bot) RunScheduledSavingRules() {
+	users, err := b.repo.GetAllUsers()
+	if err != nil {
+		logger.Error("Saving rules error getting users", "error", err)
+		return
+	}
+
+	total := 0
+	now := time.Now()
+	for i := range users {
+		user := users[i]
+		svc := service.NewService(b.repo, &user)
+		if svc.IsHouseholdGuest() {
+			continue
+		}
+		moves, err := svc.RunScheduledSavingRules(now)
+		if err != nil {
+			logger.Error("Scheduled saving rules failed", "user_id", user.ID, "error", err)
+		}
+		if user.Reachable() {
+			b.notifyAutoMoves(user.TelegramID, moves)
+		}
+		total += len(moves)
+	}
+
+	logger.Info("Scheduled saving rules completed", "moves", total)
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/logger"
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+	"github.com/IlyaMakar/finance_bot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const savingReminderDaysBefore = 2
+
+func parseUserDate(text string, loc *time.Location) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	for _, layout := range []string{"02.01.2006", "2.1.2006", "02.01.06"} {
+		if t, err := time.ParseInLocation(layout, text, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("неверный формат даты")
+}
+
+func periodLabel(period string) string {
+	if period == service.ContributionWeekly {
+		return "неделю"
+	}
+	return "месяц"
+}
+
+func currentPeriodLabel(period string) string {
+	if period == service.ContributionWeekly {
+		return "на этой неделе"
+	}
+	return "в этом месяце"
+}
+
+func (b *Bot) formatSavingPlan(plan *service.SavingPlan, saving *repository.Saving, chatID int64) string {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("\nСрок: %s", saving.TargetDate.Format("02.01.2006")))
+
+	switch {
+	case plan.Reached:
+		text.WriteString("\n🎉 Цель достигнута!")
+	case plan.Overdue:
+		text.WriteString(fmt.Sprintf("\n⏰ Срок прошёл, осталось накопить %s", b.formatCurrency(plan.Remaining, chatID)))
+	default:
+		text.WriteString(fmt.Sprintf("\nНужно откладывать: %s в %s (осталось периодов: %d)",
+			b.formatCurrency(plan.Required, chatID), periodLabel(plan.Period), plan.PeriodsLeft))
+		text.WriteString(fmt.Sprintf("\nОтложено %s: %s", currentPeriodLabel(plan.Period), b.formatCurrency(plan.ContributedPeriod, chatID)))
+
+		if plan.OnTrack {
+			text.WriteString("\n✅ Вы идёте по плану")
+		} else if plan.ProjectedDate != nil {
+			text.WriteString(fmt.Sprintf("\n⚠️ При текущем темпе (%s в %s) цель будет достигнута к %s",
+				b.formatCurrency(plan.AvgPerPeriod, chatID), periodLabel(plan.Period), plan.ProjectedDate.Format("02.01.2006")))
+		} else {
+			text.WriteString("\n⚠️ Пополнений пока не было — самое время начать!")
+		}
+	}
+
+	return text.String()
+}
+
+func (b *Bot) celebrateSavingGoal(chatID int64, saving *repository.Saving) {
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf(
+		"🎉🎉🎉 <b>Поздравляем!</b>\n\nЦель копилки «%s» достигнута: %s.\nВы молодец — так держать! 🏆",
+		html.EscapeString(saving.Name), b.formatCurrency(*saving.Goal, chatID)))
+	msg.ParseMode = "HTML"
+	b.send(chatID, msg)
+}
+
+func (b *Bot) SendSavingsReminders() {
+	users, err := b.repo.GetAllUsers()
+	if err != nil {
+		logger.Error("Savings reminder error getting users", "error", err)
+		return
+	}
+
+	sent := 0
+	for i := range users {
+		user := users[i]
+		if !user.CanNotify() {
+			continue
+		}
+
+		svc := service.NewService(b.repo, &user)
+		now := svc.Now()
+		if svc.IsHouseholdGuest() {
+			continue
+		}
+		savings, err := svc.GetSavings()
+		if err != nil {
+			logger.Error("Savings reminder error getting savings", "user_id", user.ID, "error", err)
+			continue
+		}
+
+		for j := range savings {
+			saving := savings[j]
+			plan, err := svc.GetSavingPlan(&saving, now)
+			if err != nil || plan == nil || plan.Reached || plan.Overdue {
+				continue
+			}
+
+			if plan.ContributedPeriod >= plan.Required {
+				continue
+			}
+			if plan.PeriodEnd.Sub(now) > savingReminderDaysBefore*24*time.Hour {
+				continue
+			}
+			if saving.LastReminderAt != nil && !saving.LastReminderAt.Before(plan.PeriodStart) {
+				continue
+			}
+
+			left := plan.Required - plan.ContributedPeriod
+			msg := tgbotapi.NewMessage(user.TelegramID, fmt.Sprintf(
+				"🐷 <b>Напоминание о копилке «%s»</b>\n\n"+
+					"Чтобы успеть к %s, %s стоит отложить ещё %s.\n"+
+					"Даже небольшой взнос приблизит вас к цели 💪",
+				html.EscapeString(saving.Name), saving.TargetDate.Format("02.01.2006"),
+				currentPeriodLabel(plan.Period),
+				b.formatCurrency(left, user.TelegramID)))
+			msg.ParseMode = "HTML"
+			msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+				tgbotapi.NewInlineKeyboardRow(
+					tgbotapi.NewInlineKeyboardButtonData("➕ Пополнить", fmt.Sprintf("saving_add_%d", saving.ID)),
+				),
+			)
+			b.SendMessage(msg)
+
+			if err := svc.MarkSavingReminded(saving.ID, now); err != nil {
+				logger.Error("Failed to mark saving reminded", "saving_id", saving.ID, "error", err)
+			}
+			sent++
+		}
+	}
+
+	logger.Info("Savings reminders completed", "sent", sent)
+}
@@ -27,15 +27,25 @@ type StatsResponse struct {
 	AllUsers      []UserStats    `json:"all_users"`
 	FeedbackStats FeedbackStats  `json:"feedback_stats"`
 	AllFeedbacks  []Feedback     `json:"all_feedbacks"`
+	Churn         ChurnStats     `json:"churn"`
 }
 
 type UserStats struct {
-	TelegramID int64     `json:"telegram_id"`
-	Username   string    `json:"username"`
-	FirstName  string    `json:"first_name"`
-	LastName   string    `json:"last_name"`
-	LastActive time.Time `json:"last_active"`
-	JoinDate   time.Time `json:"join_date"`
+	TelegramID     int64     `json:"telegram_id"`
+	Username       string    `json:"username"`
+	FirstName      string    `json:"first_name"`
+	LastName       string    `json:"last_name"`
+	LastActive     time.Time `json:"last_active"`
+	JoinDate       time.Time `json:"join_date"`
+	DeliveryStatus string    `json:"delivery_status"`
+}
+
+type ChurnStats struct {
+	Blocked       int `json:"blocked"`
+	Deactivated   int `json:"deactivated"`
+	BlockedWeek   int `json:"blocked_week"`
+	BlockedMonth  int `json:"blocked_month"`
+	ReturnedMonth int `json:"returned_month"`
 }
 
 type FeedbackStats struct {
@@ -91,10 +101,11 @@ func translateButtonName(buttonName string) string {
 		"type_income":  "📈 Доход",
 		"type_expense": "📉 Расход",
 
-		"skip_comment":     "Пропустить",
-		"skip_saving_goal": "Пропустить",
-		"main_menu":        "🏠 Главное меню",
-		"support":          "🆘 Поддержка",
+		"skip_comment":         "Пропустить",
+		"skip_saving_goal":     "Пропустить",
+		"skip_saving_deadline": "Пропустить",
+		"main_menu":            "🏠 Главное меню",
+		"support":              "🆘 Поддержка",
 
 		"edit_amount":        "✏️ Сумма",
 		"edit_category":      "📂 Категория",
@@ -131,6 +142,126 @@ func translateButtonName(buttonName string) string {
 		"saving_rename_":   "✏️ Переименовать",
 		"saving_delete_":   "🗑️ Удалить",
 
+		"saving_deadline_":       "📅 Срок цели",
+		"saving_deadline_clear_": "🗑 Убрать срок",
+		"saving_pace_":           "🔁 Темп",
+
+		"saving_rules_":      "🤖 Автопополнение",
+		"add_rule_percent_":  "➕ % от дохода",
+		"add_rule_roundup_":  "➕ Округление",
+		"add_rule_schedule_": "➕ По расписанию",
+		"delete_rule_":       "🗑 Удалить правило",
+		"saving_autolog_":    "📜 Журнал автопополнений",
+		"undo_move_":         "↩️ Отменить автопополнение",
+
+		"saving_deposit_":  "🏦 Вклад",
+		"saving_rate_":     "✏️ Ставка вклада",
+		"saving_maturity_": "📅 Окончание вклада",
+		"saving_cap_":      "🏦 Капитализация: ",
+
+		"debts":         "🤝 Долги",
+		"debts_closed":  "📜 Закрытые долги",
+		"debt_new_":     "➕ Новый долг: ",
+		"debt_view_":    "🤝 Просмотр долга",
+		"debt_repay_":   "💵 Частичное погашение",
+		"debt_full_":    "✅ Погасить полностью",
+		"debt_delete_":  "🗑 Удалить долг",
+		"debt_link_":    "🧾 Записать погашение: ",
+		"debt_skip_due": "Без срока",
+
+		"loans":            "🏦 Кредиты",
+		"loan_new":         "➕ Новый кредит",
+		"loan_type_":       "📊 Тип платежей: ",
+		"loan_issue_today": "📅 Выдан сегодня",
+		"loan_view_":       "🏦 Просмотр кредита",
+		"loan_pay_":        "💳 Внести платёж",
+		"loan_early_":      "⚡ Досрочное погашение",
+		"loan_mode_":       "⚡ Режим досрочного погашения: ",
+		"loan_schedule_":   "📋 График платежей",
+		"loan_delete_":     "🗑 Удалить кредит",
+
+		"credit_cards":       "💳 Кредитные карты",
+		"ccard_new":          "➕ Добавить карту",
+		"ccard_view_":        "💳 Просмотр карты",
+		"ccard_pay_":         "💸 Платёж по карте",
+		"ccard_grace_":       "✅ Погасить выписку",
+		"ccard_delete_":      "🗑 Удалить карту",
+		"pay_method_card":    "💳 Оплата дебетовой картой",
+		"pay_method_credit_": "🟥 Оплата кредитной картой",
+
+		"bills":        "🧾 Счета",
+		"bill_new":     "➕ Добавить счёт",
+		"bill_view_":   "🧾 Просмотр счёта",
+		"bill_cat_":    "📂 Категория счёта",
+		"bill_acc_":    "💳 Счёт списания",
+		"bill_remind_": "⏰ Напоминание о счёте",
+		"bill_paid_":   "✅ Счёт оплачен",
+		"bill_delete_": "🗑 Удалить счёт",
+
+		"household":              "👨‍👩‍👧 Семейный бюджет",
+		"household_create":       "➕ Создать семью",
+		"household_join":         "🔑 Ввести код семьи",
+		"household_invite":       "📨 Пригласить в семью",
+		"household_regen":        "🔄 Новый код приглашения",
+		"household_leave":        "🚪 Выйти из семьи",
+		"household_dissolve":     "🗑 Распустить семью",
+		"household_dissolve_yes": "✅ Подтвердить роспуск семьи",
+		"household_remove_":      "❌ Исключить участника",
+		"household_scope_":       "📊 Режим отчётов: ",
+		"household_report_":      "📊 Отчёт семьи/личный",
+
+		"stats_custom":  "🗓 Произвольный период",
+		"range_month_":  "🗓 Календарь: ",
+		"range_day_":    "🗓 Выбор даты: ",
+		"range_noop":    "🗓 Календарь",
+		"compare_month": "🔄 Сравнение с прошлым месяцем",
+		"compare_year":  "🔄 Сравнение с прошлым годом",
+
+		"subcat_new_":  "➕ Подкатегория",
+		"subcat_add_":  "✨ Новая подкатегория",
+		"subcat_pick_": "📂 Выбор подкатегории",
+		"catmove_":     "📂 Переместить категорию",
+		"catmoveto_":   "📂 Перемещение категории",
+		"drill_":       "🔍 Детализация категории",
+
+		"chart_pie_":     "📈 График расходов",
+		"chart_balance_": "📈 График баланса",
+		"chart_year":     "📈 График: год",
+		"chart_trend":    "📈 График: тренды",
+
+		"digest_settings": "🗞 Дайджесты",
+		"digest_freq_":    "🗞 Частота дайджеста: ",
+		"digest_hour_":    "🗞 Время дайджеста: ",
+		"digest_pdf":      "📎 PDF в дайджесте",
+		"digest_preview":  "👀 Прислать дайджест",
+
+		"reminder_settings":   "⏰ Время напоминаний",
+		"reminder_hour_":      "⏰ Время напоминания: ",
+		"reminder_time_input": "✏️ Другое время напоминания",
+		"reminder_day_":       "📅 День напоминаний: ",
+		"timezone_list":       "🌍 Часовой пояс",
+		"timezone_set_":       "🌍 Часовой пояс: ",
+		"timezone_input":      "✏️ Другой часовой пояс",
+
+		"networth":       "🏛 Капитал",
+		"networth_chart": "📈 Динамика капитала",
+		"assets":         "🏠 Имущество",
+		"asset_new":      "➕ Добавить имущество",
+		"asset_view_":    "🏠 Просмотр имущества",
+		"asset_value_":   "✏️ Переоценка имущества",
+		"asset_delete_":  "🗑 Удаление имущества",
+
+		"patterns":         "🧭 Паттерны трат",
+		"pattern_weekday":  "📅 Паттерны: дни недели",
+		"pattern_hour":     "🕐 Паттерны: время суток",
+		"pattern_monthday": "📆 Паттерны: числа месяца",
+		"pattern_calendar": "🗓 Паттерны: календарь",
+
+		"year_review_": "🎆 Итоги года ",
+		"year_pdf_":    "📄 Итоги года в PDF ",
+
+		"anomaly_toggle": "📈 Оповещения о необычных тратах",
+
 		"cat_": "📂 Категория: ",
 
 		"edit_":           "✏️ Редактировать: ",
@@ -207,6 +338,18 @@ func (s *StatsAPI) GetStats(w http.ResponseWriter, r *http.Request) {
 
 	stats.AllUsers = s.getAllUsers(users)
 
+	if churn, err := s.repo.GetChurnStats(time.Now()); err == nil {
+		stats.Churn = ChurnStats{
+			Blocked:       churn.Blocked,
+			Deactivated:   churn.Deactivated,
+			BlockedWeek:   churn.BlockedWeek,
+			BlockedMonth:  churn.BlockedMonth,
+			ReturnedMonth: churn.ReturnedMonth,
+		}
+	} else {
+		logger.Error("Failed to get churn stats", "error", err)
+	}
+
 	feedbackStats, err := s.repo.GetFeedbackStats()
 	if err == nil && feedbackStats != nil {
 		// Безопасное извлечение данных с проверкой типов
@@ -249,12 +392,13 @@ func (s *StatsAPI) getAllUsers(users []repository.User) []UserStats {
 		}
 
 		allUsers = append(allUsers, UserStats{
-			TelegramID: user.TelegramID,
-			Username:   user.Username,
-			FirstName:  user.FirstName,
-			LastName:   user.LastName,
-			LastActive: lastActive,
-			JoinDate:   user.CreatedAt,
+			TelegramID:     user.TelegramID,
+			Username:       user.Username,
+			FirstName:      user.FirstName,
+			LastName:       user.LastName,
+			LastActive:     lastActive,
+			JoinDate:       user.CreatedAt,
+			DeliveryStatus: user.DeliveryStatus,
 		})
 	}
 	return allUsers
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/IlyaMakar/finance_bot/internal/logger"
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type sendFailure int
+
+const (
+	sendTransient sendFailure = iota
+	sendRateLimited
+	sendBlocked
+	sendDeactivated
+	sendRejected
+)
+
+func classifySendError(err error) (sendFailure, int) {
+	var tgErr *tgbotapi.Error
+	if !errors.As(err, &tgErr) {
+		return sendTransient, 0
+	}
+
+	description := strings.ToLower(tgErr.Message)
+	switch {
+	case tgErr.Code == http.StatusTooManyRequests || tgErr.RetryAfter > 0:
+		return sendRateLimited, tgErr.RetryAfter
+	case strings.Contains(description, "user is deactivated"),
+		strings.Contains(description, "chat not found"):
+		return sendDeactivated, 0
+	case tgErr.Code == http.StatusForbidden:
+		return sendBlocked, 0
+	case tgErr.Code >= 400 && tgErr.Code < 500:
+		return sendRejected, 0
+	default:
+		return sendTransient, 0
+	}
+}
+
+func (b *Bot) trackDeliveryError(chatID int64, err error) {
+	status := ""
+	switch failure, _ := classifySendError(err); failure {
+	case sendBlocked:
+		status = repository.UserBlocked
+	case sendDeactivated:
+		status = repository.UserDeactivated
+	default:
+		return
+	}
+
+	changed, dbErr := b.repo.SetUserDeliveryStatus(chatID, status, err.Error())
+	if dbErr != nil {
+		logger.Error("Failed to update delivery status", "chat_id", chatID, "error", dbErr)
+		return
+	}
+	if changed {
+		logger.Info("User is no longer reachable", "chat_id", chatID, "status", status, "error", err)
+	}
+}
+
+func (b *Bot) markReachable(user *repository.User) {
+	if user.Reachable() {
+		return
+	}
+
+	changed, err := b.repo.SetUserDeliveryStatus(user.TelegramID, repository.UserActive, "")
+	if err != nil {
+		logger.Error("Failed to reactivate user", "user_id", user.TelegramID, "error", err)
+		return
+	}
+	if changed {
+		logger.Info("User is reachable again", "user_id", user.TelegramID, "was", user.DeliveryStatus)
+	}
+	user.DeliveryStatus = repository.UserActive
+}
+
+func (b *Bot) handleMyChatMember(u *tgbotapi.ChatMemberUpdated) {
+	status := repository.UserActive
+	if u.NewChatMember.WasKicked() || u.NewChatMember.HasLeft() {
+		status = repository.UserBlocked
+	}
+
+	changed, err := b.repo.SetUserDeliveryStatus(u.Chat.ID, status, "my_chat_member: "+u.NewChatMember.Status)
+	if err != nil {
+		logger.Error("Failed to update delivery status", "chat_id", u.Chat.ID, "error", err)
+		return
+	}
+	if changed {
+		logger.Info("User changed bot membership", "chat_id", u.Chat.ID, "status", status)
+	}
+}
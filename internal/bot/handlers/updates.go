@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/IlyaMakar/finance_bot/internal/logger"
 	"github.com/IlyaMakar/finance_bot/internal/repository"
 	"github.com/IlyaMakar/finance_bot/internal/service"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -37,7 +38,12 @@ func (b *Bot) NotifyUsersAboutUpdate() {
 		return
 	}
 
+	queued := 0
+	now := time.Now()
 	for _, user := range users {
+		if !user.Reachable() {
+			continue
+		}
 		svc := service.NewService(b.repo, &user)
 
 		hasRead, err := svc.HasUserReadVersion(latestVersion.ID)
@@ -45,19 +51,68 @@ func (b *Bot) NotifyUsersAboutUpdate() {
 			continue
 		}
 
-		msg := tgbotapi.NewMessage(
-			user.TelegramID,
-			fmt.Sprintf("🎉 *Обновление бота до v%s!*\n\n%s\n\n_Спасибо, что используете нашего бота!_",
-				latestVersion.Version,
-				latestVersion.Description),
-		)
-		msg.ParseMode = "Markdown"
-		b.SendMessage(msg)
+		key := fmt.Sprintf("%d:%d", latestVersion.ID, user.ID)
+		added, err := b.jobs.Enqueue(JobUpdateNotice, key, key, now)
+		if err != nil {
+			logger.Error("Update notice enqueue error", "user_id", user.TelegramID, "error", err)
+			continue
+		}
+		if added {
+			queued++
+		}
+	}
+	if queued > 0 {
+		logger.Info("Update notices queued", "version", latestVersion.Version, "count", queued)
+	}
+}
 
-		svc.MarkVersionAsRead(latestVersion.ID)
+func (b *Bot) runUpdateNotice(job repository.Job) (string, error) {
+	var versionID, userID int
+	if _, err := fmt.Sscanf(job.Payload, "%d:%d", &versionID, &userID); err != nil {
+		return "", fmt.Errorf("bad update notice payload %q", job.Payload)
+	}
 
-		time.Sleep(100 * time.Millisecond)
+	version, err := b.repo.GetLatestVersion()
+	if err != nil {
+		return "", err
+	}
+	if version == nil || version.ID != versionID {
+		return "skipped: version superseded", nil
 	}
+	user, err := b.repo.GetUserByID(userID)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
+		return "skipped: user not found", nil
+	}
+	if !user.Reachable() {
+		return "skipped: chat unreachable", nil
+	}
+
+	svc := service.NewService(b.repo, user)
+	if hasRead, err := svc.HasUserReadVersion(versionID); err != nil {
+		return "", err
+	} else if hasRead {
+		return "skipped: already read", nil
+	}
+
+	msg := tgbotapi.NewMessage(
+		user.TelegramID,
+		fmt.Sprintf("🎉 *Обновление бота до v%s!*\n\n%s\n\n_Спасибо, что используете нашего бота!_",
+			version.Version,
+			version.Description),
+	)
+	msg.ParseMode = "Markdown"
+	b.bulk.wait()
+	if err := b.deliver(user.TelegramID, msg); err != nil {
+		return "", err
+	}
+	if err := svc.MarkVersionAsRead(versionID); err != nil {
+		logger.Error("Update notice mark error", "user_id", user.TelegramID, "error", err)
+	}
+
+	return "sent", nil
 }
 
 func getVersionDescription(version string) string {
@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/logger"
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+	"github.com/IlyaMakar/finance_bot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	CallbackYearReview    = "year_review_"
+	CallbackYearReviewPDF = "year_pdf_"
+
+	yearReviewLimit = 5
+)
+
+func (b *Bot) handleYearReviewCallback(chatID int64, data string, lang string, svc *service.FinanceService) bool {
+	prefix := CallbackYearReview
+	if strings.HasPrefix(data, CallbackYearReviewPDF) {
+		prefix = CallbackYearReviewPDF
+	} else if !strings.HasPrefix(data, CallbackYearReview) {
+		return false
+	}
+
+	start, err := time.ParseInLocation("20060102", data[len(prefix):], svc.Location())
+	if err != nil {
+		b.sendError(chatID, fmt.Errorf("неверный период"))
+		return true
+	}
+	review, err := svc.BuildYearReview(start, start.AddDate(1, 0, 0), svc.Now(), yearReviewLimit)
+	if err != nil {
+		b.sendError(chatID, err)
+		return true
+	}
+
+	if prefix == CallbackYearReviewPDF {
+		b.sendYearReviewPDF(chatID, review, lang, svc)
+	} else {
+		b.sendYearReviewCards(chatID, review)
+	}
+	return true
+}
+
+func yearReviewButton(start time.Time) tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardButtonData("🎆 Итоги года", CallbackYearReview+start.Format("20060102"))
+}
+
+func (b *Bot) sendYearReviewCards(chatID int64, review *service.YearReview) {
+	year := review.Start.Year()
+	if review.Income == 0 && review.Expense == 0 {
+		b.send(chatID, tgbotapi.NewMessage(chatID, fmt.Sprintf("😔 За %d год нет операций — итоги подводить пока рано.", year)))
+		return
+	}
+
+	b.sendHTML(chatID, fmt.Sprintf("🎆 <b>Финансовый итог %d года</b>\n<i>%s</i>\n\n"+
+		"💰 Заработано: <b>%s</b>\n💸 Потрачено: <b>%s</b>\n🐷 Отложено в копилки: <b>%s</b>\n\n💵 Итог года: <b>%s</b>",
+		year, rangeLabel(review.Start, review.End.AddDate(0, 0, -1)),
+		b.formatCurrency(review.Income, chatID), b.formatCurrency(review.Expense, chatID),
+		b.formatCurrency(review.Saved, chatID), b.formatCurrency(review.Balance(), chatID)))
+
+	var months strings.Builder
+	months.WriteString("📅 <b>Месяцы</b>\n")
+	if review.BestMonth != nil {
+		months.WriteString(fmt.Sprintf("🏆 Лучший: %s, баланс %s\n", reportLocale{lang: LangRU}.month(review.BestMonth.Month),
+			b.formatCurrency(review.BestMonth.Income-review.BestMonth.Expense, chatID)))
+	}
+	if review.WorstMonth != nil && review.WorstMonth != review.BestMonth {
+		months.WriteString(fmt.Sprintf("🥶 Худший: %s, баланс %s\n", reportLocale{lang: LangRU}.month(review.WorstMonth.Month),
+			b.formatCurrency(review.WorstMonth.Income-review.WorstMonth.Expense, chatID)))
+	}
+	img, err := b.reportGen.renderMonthlyBars(review.Months, monthShortName)
+	b.sendChart(chatID, img, err, months.String())
+
+	if len(review.TopCategories) > 0 {
+		var top strings.Builder
+		top.WriteString("🛒 <b>Куда ушли деньги</b>\n")
+		for i, c := range review.TopCategories {
+			top.WriteString(fmt.Sprintf("%d. %s: %s (%.0f%%)\n", i+1, html.EscapeString(c.Name), b.formatCurrency(c.Amount, chatID), c.Share))
+		}
+		img, err := b.reportGen.renderCategoryTrends(review.Start, review.Trends, monthShortName)
+		b.sendChart(chatID, img, err, top.String())
+	}
+
+	if len(review.BiggestPurchases) > 0 {
+		var purchases strings.Builder
+		purchases.WriteString("💎 <b>Самые крупные покупки</b>\n")
+		for _, t := range review.BiggestPurchases {
+			line := fmt.Sprintf("┣ %s · %s: %s", t.Date.In(review.Start.Location()).Format("02.01"), html.EscapeString(t.CategoryName), b.formatCurrency(-t.Amount, chatID))
+			if t.Comment != "" {
+				line += " — " + html.EscapeString(t.Comment)
+			}
+			purchases.WriteString(line + "\n")
+		}
+		b.sendHTML(chatID, purchases.String())
+	}
+
+	var final strings.Builder
+	final.WriteString("🌟 <b>Достижения</b>\n")
+	if review.NoSpendStreak > 0 {
+		final.WriteString(fmt.Sprintf("🧘 Самая длинная серия без трат: %d дн. (с %s)\n", review.NoSpendStreak, review.StreakStart.Format("02.01")))
+	} else {
+		final.WriteString("🧘 Дней без трат не было\n")
+	}
+	if len(review.GoalsReached) == 0 {
+		final.WriteString("🎯 Цели копилок в этом году не достигнуты — всё впереди!\n")
+	} else {
+		final.WriteString("🎯 Достигнутые цели:\n")
+		for _, s := range review.GoalsReached {
+			final.WriteString(fmt.Sprintf("┣ %s: %s\n", html.EscapeString(s.Name), b.formatCurrency(*s.Goal, chatID)))
+		}
+	}
+
+	msg := tgbotapi.NewMessage(chatID, final.String())
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📄 PDF-версия", CallbackYearReviewPDF+review.Start.Format("20060102")),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("◀️ Главное меню", "main_menu"),
+		),
+	)
+	b.send(chatID, msg)
+}
+
+func (b *Bot) sendHTML(chatID int64, text string) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	b.send(chatID, msg)
+}
+
+func (b *Bot) sendYearReviewPDF(chatID int64, review *service.YearReview, lang string, svc *service.FinanceService) {
+	currency, err := svc.GetCurrency()
+	if err != nil {
+		b.sendError(chatID, err)
+		return
+	}
+
+	loc := reportLocale{lang: lang, currency: currency}
+	pdfData, err := b.reportGen.GenerateYearReviewPDF(review, loc)
+	if err != nil {
+		logger.Error("Year review PDF error", "chat_id", chatID, "error", err)
+		b.sendError(chatID, fmt.Errorf("не удалось сформировать отчёт"))
+		return
+	}
+
+	b.send(chatID, tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  loc.textf("yr_file_name", review.Start.Year()),
+		Bytes: pdfData,
+	}))
+}
+
+func (b *Bot) yearReviewPlanner(job repository.Job) (string, error) {
+	users, err := b.repo.GetAllUsers()
+	if err != nil {
+		return "", err
+	}
+
+	planned := 0
+	for i := range users {
+		user := users[i]
+		if !user.CanNotify() {
+			continue
+		}
+
+		start, end := yearPeriod(time.Now().In(user.Location()), user.PeriodStartDay)
+		key := fmt.Sprintf("%d:%d", user.ID, start.Year())
+		payload := fmt.Sprintf("%d:%s", user.ID, start.Format("2006-01-02"))
+		added, err := b.jobs.Enqueue(JobYearReview, key, payload, end)
+		if err != nil {
+			logger.Error("Year review enqueue error", "user_id", user.TelegramID, "error", err)
+			continue
+		}
+		if added {
+			planned++
+		}
+	}
+	return fmt.Sprintf("planned %d year reviews", planned), nil
+}
+
+func (b *Bot) runYearReview(job repository.Job) (string, error) {
+	parts := strings.SplitN(job.Payload, ":", 2)
+	if len(parts) != 2 {
+		return "", fmt.Errorf("bad year review payload %q", job.Payload)
+	}
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return "", fmt.Errorf("bad year review payload %q", job.Payload)
+	}
+
+	user, err := b.repo.GetUserByID(userID)
+	if err != nil {
+		return "", err
+	}
+	if user == nil || !user.CanNotify() {
+		return "skipped: notifications off or chat unreachable", nil
+	}
+
+	svc := service.NewService(b.repo, user)
+	start, err := time.ParseInLocation("2006-01-02", parts[1], svc.Location())
+	if err != nil {
+		return "", fmt.Errorf("bad year review payload %q", job.Payload)
+	}
+	settings, err := svc.GetDigestSettings()
+	if err != nil {
+		return "", err
+	}
+	if settings.LastYearReview >= start.Year() {
+		return "skipped: already sent", nil
+	}
+
+	review, err := svc.BuildYearReview(start, start.AddDate(1, 0, 0), svc.Now(), yearReviewLimit)
+	if err != nil {
+		return "", err
+	}
+	b.sendYearReviewCards(user.TelegramID, review)
+	if err := svc.MarkYearReviewSent(start.Year()); err != nil {
+		logger.Error("Year review mark error", "user_id", user.TelegramID, "error", err)
+	}
+	return "sent", nil
+}
+
+func (rg *ReportGenerator) GenerateYearReviewPDF(review *service.YearReview, loc reportLocale) ([]byte, error) {
+	pdf := rg.newPDF(loc)
+
+	pdf.AddPage()
+	pdf.SetFont("DejaVuSans", "B", 22)
+	pdf.SetTextColor(30, 30, 30)
+	pdf.CellFormat(190, 12, loc.textf("yr_title", review.Start.Year()), "", 1, "C", false, 0, "")
+	pdf.SetFont("DejaVuSans", "", 12)
+	pdf.CellFormat(190, 8, loc.textf("period", loc.date(review.Start), loc.date(review.End.AddDate(0, 0, -1))), "", 1, "C", false, 0, "")
+	pdf.Ln(8)
+
+	pdf.SetFont("DejaVuSans", "B", 14)
+	pdf.CellFormat(190, 9, loc.textf("yr_earned", loc.money(review.Income)), "", 1, "L", false, 0, "")
+	pdf.CellFormat(190, 9, loc.textf("yr_spent", loc.money(review.Expense)), "", 1, "L", false, 0, "")
+	pdf.CellFormat(190, 9, loc.textf("yr_saved", loc.money(review.Saved)), "", 1, "L", false, 0, "")
+	pdf.CellFormat(190, 9, loc.textf("balance", loc.signedMoney(review.Balance())), "", 1, "L", false, 0, "")
+	pdf.Ln(6)
+
+	pdf.SetFont("DejaVuSans", "B", 14)
+	pdf.CellFormat(190, 10, loc.text("yr_months"), "", 1, "L", false, 0, "")
+	if img, err := rg.renderMonthlyBars(review.Months, loc.monthShort); err == nil {
+		y := pdf.GetY()
+		rg.addImageToPDF(pdf, img, "", 10, y, 190, 80)
+		pdf.SetY(y + 84)
+	}
+	pdf.SetFont("DejaVuSans", "", 12)
+	if review.BestMonth != nil {
+		pdf.CellFormat(190, 7, loc.textf("yr_best", loc.month(review.BestMonth.Month),
+			loc.signedMoney(review.BestMonth.Income-review.BestMonth.Expense)), "", 1, "L", false, 0, "")
+	}
+	if review.WorstMonth != nil && review.WorstMonth != review.BestMonth {
+		pdf.CellFormat(190, 7, loc.textf("yr_worst", loc.month(review.WorstMonth.Month),
+			loc.signedMoney(review.WorstMonth.Income-review.WorstMonth.Expense)), "", 1, "L", false, 0, "")
+	}
+
+	if len(review.TopCategories) > 0 {
+		pdf.AddPage()
+		pdf.SetFont("DejaVuSans", "B", 14)
+		pdf.CellFormat(190, 10, loc.text("yr_top"), "", 1, "L", false, 0, "")
+
+		top := make(map[string]float64, len(review.TopCategories))
+		for _, c := range review.TopCategories {
+			top[removeEmoji(c.Name)] = c.Amount
+		}
+		y := pdf.GetY()
+		img, legend, colors := rg.generatePieWithLegend(top, loc)
+		rg.addImageToPDF(pdf, img, "", 10, y, 90, 60)
+		rg.addLegendWithColor(pdf, legend, colors, 105, y+10)
+		pdf.SetY(y + 66)
+
+		pdf.SetFont("DejaVuSans", "B", 14)
+		pdf.CellFormat(190, 10, loc.text("yr_trends"), "", 1, "L", false, 0, "")
+		trends := make([]service.CategoryTrend, len(review.Trends))
+		for i, t := range review.Trends {
+			trends[i] = service.CategoryTrend{Name: removeEmoji(t.Name), Values: t.Values}
+		}
+		if img, err := rg.renderCategoryTrends(review.Start, trends, loc.monthShort); err == nil {
+			y := pdf.GetY()
+			rg.addImageToPDF(pdf, img, "", 10, y, 190, 95)
+			pdf.SetY(y + 99)
+		}
+	}
+
+	pdf.AddPage()
+	rg.addYearPurchases(pdf, review, loc)
+
+	pdf.Ln(8)
+	pdf.SetFont("DejaVuSans", "B", 14)
+	pdf.CellFormat(190, 10, loc.text("yr_goals"), "", 1, "L", false, 0, "")
+	pdf.SetFont("DejaVuSans", "", 11)
+	if len(review.GoalsReached) == 0 {
+		pdf.CellFormat(190, 7, loc.text("yr_no_goals"), "", 1, "L", false, 0, "")
+	}
+	for _, s := range review.GoalsReached {
+		pdf.CellFormat(190, 7, fmt.Sprintf("%s – %s", removeEmoji(s.Name), loc.money(*s.Goal)), "", 1, "L", false, 0, "")
+	}
+
+	pdf.Ln(8)
+	pdf.SetFont("DejaVuSans", "B", 12)
+	if review.NoSpendStreak > 0 {
+		pdf.CellFormat(190, 8, loc.textf("yr_streak", review.NoSpendStreak, loc.date(review.StreakStart)), "", 1, "L", false, 0, "")
+	} else {
+		pdf.CellFormat(190, 8, loc.text("yr_no_streak"), "", 1, "L", false, 0, "")
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (rg *ReportGenerator) addYearPurchases(pdf *gofpdf.Fpdf, review *service.YearReview, loc reportLocale) {
+	pdf.SetFont("DejaVuSans", "B", 14)
+	pdf.CellFormat(190, 10, loc.text("yr_purchases"), "", 1, "L", false, 0, "")
+	if len(review.BiggestPurchases) == 0 {
+		pdf.SetFont("DejaVuSans", "", 11)
+		pdf.CellFormat(190, 7, loc.text("no_transactions"), "", 1, "L", false, 0, "")
+		return
+	}
+
+	widths := []float64{28, 55, 72, 35}
+	pdf.SetFont("DejaVuSans", "B", 10)
+	pdf.SetFillColor(235, 235, 235)
+	for i, h := range []string{loc.text("col_date"), loc.text("col_category"), loc.text("col_comment"), loc.text("col_amount")} {
+		pdf.CellFormat(widths[i], 7, h, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("DejaVuSans", "", 9)
+	for _, t := range review.BiggestPurchases {
+		pdf.CellFormat(widths[0], 6, loc.date(t.Date), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 6, fitText(pdf, removeEmoji(t.CategoryName), widths[1]-2), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], 6, fitText(pdf, t.Comment, widths[2]-2), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[3], 6, loc.money(-t.Amount), "1", 1, "R", false, 0, "")
+	}
+}
@@ -0,0 +1,494 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/logger"
+)
+
+type CategorySum struct {
+	CategoryID         int
+	CategoryName       string
+	ParentCategoryID   *int
+	ParentCategoryName string
+	Income             float64
+	Expense            float64
+	Count              int
+}
+
+type PeriodSum struct {
+	Period  string
+	Income  float64
+	Expense float64
+	Count   int
+}
+
+type MonthlyCategorySum struct {
+	Month string
+	CategorySum
+}
+
+type MemberSum struct {
+	UserID  int
+	Income  float64
+	Expense float64
+	Count   int
+}
+
+const (
+	sumColumns = `
+        COALESCE(SUM(CASE WHEN t.amount > 0 THEN t.amount ELSE 0 END), 0),
+        COALESCE(SUM(CASE WHEN t.amount > 0 THEN 0 ELSE -t.amount END), 0),
+        COUNT(*)`
+	sumFilter = `
+        WHERE t.user_id = ? AND t.date >= ? AND t.date < ?
+          AND (? = 0 OR COALESCE(t.created_by, t.user_id) = ?)
+          AND t.transfer = 0`
+	aggregateColumns = `
+        COALESCE(SUM(t.income), 0),
+        COALESCE(SUM(t.expense), 0),
+        COALESCE(SUM(t.count), 0)`
+	aggregateFilter = `
+        WHERE t.user_id = ? AND t.month >= ? AND t.month < ?
+          AND (? = 0 OR t.created_by = ?)`
+	categoryJoins = `
+        LEFT JOIN categories c ON c.id = t.category_id AND c.user_id = t.user_id
+        LEFT JOIN categories p ON p.id = c.parent_id AND p.user_id = t.user_id`
+)
+
+type sumSource struct {
+	table   string
+	hour    string
+	day     string
+	month   string
+	columns string
+	filter  string
+	args    []interface{}
+}
+
+func transactionSource(userID, createdBy int, start, end time.Time) sumSource {
+	_, offset := start.Zone()
+	local := fmt.Sprintf("datetime(t.date, '%+d seconds')", offset)
+	return sumSource{
+		table:   "transactions t",
+		hour:    "substr(" + local + ", 1, 13)",
+		day:     "substr(" + local + ", 1, 10)",
+		month:   "substr(" + local + ", 1, 7)",
+		columns: sumColumns,
+		filter:  sumFilter,
+		args:    []interface{}{userID, formatTime(start), formatTime(end), createdBy, createdBy},
+	}
+}
+
+func (r *SQLiteRepository) newSumSource(userID, createdBy int, start, end time.Time) (sumSource, error) {
+	loc, err := userLocation(r.db, userID)
+	if err != nil {
+		return sumSource{}, err
+	}
+	start, end = start.In(loc), end.In(loc)
+	if !isMonthStart(start) || !isMonthStart(end) {
+		return transactionSource(userID, createdBy, start, end), nil
+	}
+	return sumSource{
+		table:   "monthly_aggregates t",
+		month:   "t.month",
+		columns: aggregateColumns,
+		filter:  aggregateFilter,
+		args:    []interface{}{userID, start.Format("2006-01"), end.Format("2006-01"), createdBy, createdBy},
+	}, nil
+}
+
+func isMonthStart(t time.Time) bool {
+	return t.Day() == 1 && t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
+
+func scanCategorySum(row rowScanner, extra ...interface{}) (CategorySum, error) {
+	var s CategorySum
+	var parentID sql.NullInt64
+	dest := append(extra, &s.CategoryID, &s.CategoryName, &parentID, &s.ParentCategoryName, &s.Income, &s.Expense, &s.Count)
+	if err := row.Scan(dest...); err != nil {
+		return s, err
+	}
+	if parentID.Valid {
+		id := int(parentID.Int64)
+		s.ParentCategoryID = &id
+	}
+	return s, nil
+}
+
+func (r *SQLiteRepository) GetCategorySums(userID, createdBy int, start, end time.Time) ([]CategorySum, error) {
+	src, err := r.newSumSource(userID, createdBy, start, end)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(`
+        SELECT t.category_id, COALESCE(c.name, ''), c.parent_id, COALESCE(p.name, ''),`+src.columns+`
+        FROM `+src.table+categoryJoins+src.filter+`
+        GROUP BY t.category_id`,
+		src.args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get category sums: %w", err)
+	}
+	defer rows.Close()
+
+	var res []CategorySum
+	for rows.Next() {
+		s, err := scanCategorySum(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan category sum: %w", err)
+		}
+		res = append(res, s)
+	}
+	return res, rows.Err()
+}
+
+func (r *SQLiteRepository) GetMonthlyCategorySums(userID, createdBy int, start, end time.Time) ([]MonthlyCategorySum, error) {
+	src, err := r.newSumSource(userID, createdBy, start, end)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(`
+        SELECT `+src.month+` AS month, t.category_id, COALESCE(c.name, ''), c.parent_id, COALESCE(p.name, ''),`+src.columns+`
+        FROM `+src.table+categoryJoins+src.filter+`
+        GROUP BY month, t.category_id
+        ORDER BY month`,
+		src.args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get monthly category sums: %w", err)
+	}
+	defer rows.Close()
+
+	var res []MonthlyCategorySum
+	for rows.Next() {
+		var m MonthlyCategorySum
+		s, err := scanCategorySum(rows, &m.Month)
+		if err != nil {
+			return nil, fmt.Errorf("scan monthly category sum: %w", err)
+		}
+		m.CategorySum = s
+		res = append(res, m)
+	}
+	return res, rows.Err()
+}
+
+func (r *SQLiteRepository) GetHourlySums(userID, createdBy int, start, end time.Time) ([]PeriodSum, error) {
+	src := transactionSource(userID, createdBy, start, end)
+	return r.getPeriodSums(src, src.hour)
+}
+
+func (r *SQLiteRepository) GetDailySums(userID, createdBy int, start, end time.Time) ([]PeriodSum, error) {
+	src := transactionSource(userID, createdBy, start, end)
+	return r.getPeriodSums(src, src.day)
+}
+
+func (r *SQLiteRepository) GetMonthlySums(userID, createdBy int, start, end time.Time) ([]PeriodSum, error) {
+	src, err := r.newSumSource(userID, createdBy, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return r.getPeriodSums(src, src.month)
+}
+
+func (r *SQLiteRepository) getPeriodSums(src sumSource, period string) ([]PeriodSum, error) {
+	rows, err := r.db.Query(`
+        SELECT `+period+` AS period,`+src.columns+`
+        FROM `+src.table+src.filter+`
+        GROUP BY period
+        ORDER BY period`,
+		src.args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get period sums: %w", err)
+	}
+	defer rows.Close()
+
+	var res []PeriodSum
+	for rows.Next() {
+		var s PeriodSum
+		if err := rows.Scan(&s.Period, &s.Income, &s.Expense, &s.Count); err != nil {
+			return nil, fmt.Errorf("scan period sum: %w", err)
+		}
+		res = append(res, s)
+	}
+	return res, rows.Err()
+}
+
+func (r *SQLiteRepository) GetMemberSums(userID int, start, end time.Time) ([]MemberSum, error) {
+	src, err := r.newSumSource(userID, 0, start, end)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(`
+        SELECT COALESCE(t.created_by, t.user_id) AS member,`+src.columns+`
+        FROM `+src.table+src.filter+`
+        GROUP BY member`,
+		src.args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get member sums: %w", err)
+	}
+	defer rows.Close()
+
+	var res []MemberSum
+	for rows.Next() {
+		var s MemberSum
+		if err := rows.Scan(&s.UserID, &s.Income, &s.Expense, &s.Count); err != nil {
+			return nil, fmt.Errorf("scan member sum: %w", err)
+		}
+		res = append(res, s)
+	}
+	return res, rows.Err()
+}
+
+type aggregateRow struct {
+	userID     int
+	createdBy  int
+	categoryID int
+	date       string
+	amount     float64
+	transfer   bool
+}
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func getAggregateRow(q execer, userID, id int) (*aggregateRow, error) {
+	row := aggregateRow{userID: userID}
+	err := q.QueryRow(
+		"SELECT COALESCE(created_by, user_id), category_id, date, amount, transfer FROM transactions WHERE id = ? AND user_id = ?",
+		id, userID,
+	).Scan(&row.createdBy, &row.categoryID, &row.date, &row.amount, &row.transfer)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get transaction for aggregate: %w", err)
+	}
+	return &row, nil
+}
+
+func applyAggregate(q execer, row aggregateRow, sign int) error {
+	if row.transfer {
+		return nil
+	}
+	income, expense := row.amount, 0.0
+	if row.amount <= 0 {
+		income, expense = 0, -row.amount
+	}
+	loc, err := userLocation(q, row.userID)
+	if err != nil {
+		return err
+	}
+	month := aggregateMonth(row.date, loc)
+
+	_, err = q.Exec(`
+        INSERT INTO monthly_aggregates (user_id, created_by, category_id, month, income, expense, count)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT(user_id, month, category_id, created_by) DO UPDATE SET
+            income = income + excluded.income,
+            expense = expense + excluded.expense,
+            count = count + excluded.count`,
+		row.userID, row.createdBy, row.categoryID, month,
+		income*float64(sign), expense*float64(sign), sign,
+	)
+	if err != nil {
+		return fmt.Errorf("apply monthly aggregate: %w", err)
+	}
+
+	_, err = q.Exec(
+		"DELETE FROM monthly_aggregates WHERE user_id = ? AND month = ? AND category_id = ? AND created_by = ? AND count <= 0",
+		row.userID, month, row.categoryID, row.createdBy,
+	)
+	if err != nil {
+		return fmt.Errorf("clean monthly aggregate: %w", err)
+	}
+	return nil
+}
+
+func aggregateMonth(date string, loc *time.Location) string {
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return date[:len("2006-01")]
+	}
+	return t.In(loc).Format("2006-01")
+}
+
+func ensureMonthlyAggregates(db *sql.DB) error {
+	var aggregates, transactions int
+	if err := db.QueryRow("SELECT COUNT(*) FROM monthly_aggregates").Scan(&aggregates); err != nil {
+		return err
+	}
+	if aggregates > 0 {
+		return nil
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM transactions").Scan(&transactions); err != nil {
+		return err
+	}
+	if transactions == 0 {
+		return nil
+	}
+	return rebuildMonthlyAggregates(db, 0)
+}
+
+func rebuildMonthlyAggregates(db *sql.DB, userID int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	expected, err := expectedAggregates(tx, userID)
+	if err != nil {
+		return fmt.Errorf("compute monthly aggregates: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM monthly_aggregates WHERE ? = 0 OR user_id = ?", userID, userID); err != nil {
+		return fmt.Errorf("clear monthly aggregates: %w", err)
+	}
+	for k, v := range expected {
+		_, err := tx.Exec(
+			"INSERT INTO monthly_aggregates (user_id, created_by, category_id, month, income, expense, count) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			k.userID, k.createdBy, k.categoryID, k.month, v.income, v.expense, v.count,
+		)
+		if err != nil {
+			return fmt.Errorf("fill monthly aggregates: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteRepository) RebuildMonthlyAggregates() error {
+	return rebuildMonthlyAggregates(r.db, 0)
+}
+
+type aggregateKey struct {
+	userID, createdBy, categoryID int
+	month                         string
+}
+
+type aggregateValue struct {
+	income, expense float64
+	count           int
+}
+
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func userLocations(q querier) (map[int]*time.Location, error) {
+	rows, err := q.Query("SELECT id, timezone FROM users")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(map[int]*time.Location)
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		res[id] = loadLocation(name)
+	}
+	return res, rows.Err()
+}
+
+func expectedAggregates(q querier, userID int) (map[aggregateKey]aggregateValue, error) {
+	locations, err := userLocations(q)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Query(
+		"SELECT user_id, COALESCE(created_by, user_id), category_id, date, amount FROM transactions WHERE transfer = 0 AND (? = 0 OR user_id = ?)",
+		userID, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(map[aggregateKey]aggregateValue)
+	for rows.Next() {
+		var k aggregateKey
+		var date string
+		var amount float64
+		if err := rows.Scan(&k.userID, &k.createdBy, &k.categoryID, &date, &amount); err != nil {
+			return nil, err
+		}
+		loc := locations[k.userID]
+		if loc == nil {
+			loc = time.Local
+		}
+		k.month = aggregateMonth(date, loc)
+
+		v := res[k]
+		if amount > 0 {
+			v.income += amount
+		} else {
+			v.expense -= amount
+		}
+		v.count++
+		res[k] = v
+	}
+	return res, rows.Err()
+}
+
+func loadAggregates(q querier) (map[aggregateKey]aggregateValue, error) {
+	rows, err := q.Query("SELECT user_id, created_by, category_id, month, income, expense, count FROM monthly_aggregates")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(map[aggregateKey]aggregateValue)
+	for rows.Next() {
+		var k aggregateKey
+		var v aggregateValue
+		if err := rows.Scan(&k.userID, &k.createdBy, &k.categoryID, &k.month, &v.income, &v.expense, &v.count); err != nil {
+			return nil, err
+		}
+		res[k] = v
+	}
+	return res, rows.Err()
+}
+
+func (r *SQLiteRepository) CheckMonthlyAggregates() (int, error) {
+	expected, err := expectedAggregates(r.db, 0)
+	if err != nil {
+		return 0, fmt.Errorf("load expected aggregates: %w", err)
+	}
+	stored, err := loadAggregates(r.db)
+	if err != nil {
+		return 0, fmt.Errorf("load stored aggregates: %w", err)
+	}
+
+	mismatches := 0
+	for k, want := range expected {
+		got := stored[k]
+		delete(stored, k)
+		if got.count == want.count && math.Abs(got.income-want.income) < 0.005 && math.Abs(got.expense-want.expense) < 0.005 {
+			continue
+		}
+		mismatches++
+		logger.Warn("Monthly aggregate mismatch",
+			"user_id", k.userID, "created_by", k.createdBy, "category_id", k.categoryID, "month", k.month,
+			"income", got.income, "expected_income", want.income,
+			"expense", got.expense, "expected_expense", want.expense,
+			"count", got.count, "expected_count", want.count)
+	}
+	for k, got := range stored {
+		mismatches++
+		logger.Warn("Stale monthly aggregate",
+			"user_id", k.userID, "created_by", k.createdBy, "category_id", k.categoryID, "month", k.month,
+			"income", got.income, "expense", got.expense, "count", got.count)
+	}
+	return mismatches, nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+func (r *SQLiteRepository) GetAnomalyAlertsEnabled(userID int) (bool, error) {
+	var enabled bool
+	err := r.db.QueryRow("SELECT enabled FROM anomaly_settings WHERE user_id = ?", userID).Scan(&enabled)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("get anomaly settings: %w", err)
+	}
+	return enabled, nil
+}
+
+func (r *SQLiteRepository) SetAnomalyAlertsEnabled(userID int, enabled bool) error {
+	_, err := r.db.Exec(
+		"INSERT INTO anomaly_settings (user_id, enabled) VALUES (?, ?) ON CONFLICT(user_id) DO UPDATE SET enabled = excluded.enabled",
+		userID, enabled,
+	)
+	if err != nil {
+		return fmt.Errorf("set anomaly settings: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) MarkAnomalyAlerted(userID int, key string, at time.Time) (bool, error) {
+	res, err := r.db.Exec(
+		"INSERT OR IGNORE INTO anomaly_alerts (user_id, alert_key, sent_at) VALUES (?, ?, ?)",
+		userID, key, formatTime(at),
+	)
+	if err != nil {
+		return false, fmt.Errorf("mark anomaly alerted: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const billDateLayout = "2006-01-02"
+
+type Bill struct {
+	ID           int
+	UserID       int
+	Name         string
+	Amount       float64
+	DueDay       int
+	CategoryID   int
+	CreditCardID *int
+	RemindDays   int
+	PaidThrough  *time.Time
+	CreatedAt    time.Time
+}
+
+const billColumns = "id, name, amount, due_day, category_id, credit_card_id, remind_days, paid_through, created_at"
+
+func scanBill(row rowScanner, userID int) (*Bill, error) {
+	var b Bill
+	var cardID sql.NullInt64
+	var paidThrough sql.NullString
+	var createdAt string
+
+	if err := row.Scan(&b.ID, &b.Name, &b.Amount, &b.DueDay, &b.CategoryID, &cardID,
+		&b.RemindDays, &paidThrough, &createdAt); err != nil {
+		return nil, err
+	}
+
+	if cardID.Valid {
+		id := int(cardID.Int64)
+		b.CreditCardID = &id
+	}
+	if paidThrough.Valid {
+		if t, err := time.Parse(billDateLayout, paidThrough.String); err == nil {
+			b.PaidThrough = &t
+		}
+	}
+	b.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	b.UserID = userID
+	return &b, nil
+}
+
+func (r *SQLiteRepository) CreateBill(userID int, b Bill) (int, error) {
+	res, err := r.db.Exec(
+		`INSERT INTO bills (user_id, name, amount, due_day, category_id, credit_card_id, remind_days, created_at)
+         VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		userID, b.Name, b.Amount, b.DueDay, b.CategoryID, b.CreditCardID, b.RemindDays, formatTime(time.Now()),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("create bill: %w", err)
+	}
+	id, _ := res.LastInsertId()
+	return int(id), nil
+}
+
+func (r *SQLiteRepository) GetBills(userID int) ([]Bill, error) {
+	rows, err := r.db.Query(
+		"SELECT "+billColumns+" FROM bills WHERE user_id = ? AND active = TRUE ORDER BY due_day, id",
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get bills: %w", err)
+	}
+	defer rows.Close()
+
+	var bills []Bill
+	for rows.Next() {
+		b, err := scanBill(rows, userID)
+		if err != nil {
+			return nil, fmt.Errorf("scan bill: %w", err)
+		}
+		bills = append(bills, *b)
+	}
+	return bills, rows.Err()
+}
+
+func (r *SQLiteRepository) GetBillByID(userID, id int) (*Bill, error) {
+	b, err := scanBill(r.db.QueryRow(
+		"SELECT "+billColumns+" FROM bills WHERE id = ? AND user_id = ? AND active = TRUE",
+		id, userID,
+	), userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get bill: %w", err)
+	}
+	return b, nil
+}
+
+func (r *SQLiteRepository) DeleteBill(userID, id int) error {
+	_, err := r.db.Exec("UPDATE bills SET active = FALSE WHERE id = ? AND user_id = ?", id, userID)
+	return err
+}
+
+func (r *SQLiteRepository) UpdateBillRemindDays(userID, id, days int) error {
+	_, err := r.db.Exec("UPDATE bills SET remind_days = ? WHERE id = ? AND user_id = ?", days, id, userID)
+	return err
+}
+
+func (r *SQLiteRepository) MarkBillPaid(userID, id int, due time.Time) error {
+	_, err := r.db.Exec(
+		"UPDATE bills SET paid_through = ? WHERE id = ? AND user_id = ?",
+		due.Format(billDateLayout), id, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("mark bill paid: %w", err)
+	}
+	return nil
+}
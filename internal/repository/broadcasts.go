@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	BroadcastQueued  = "queued"
+	BroadcastSending = "sending"
+	BroadcastDone    = "done"
+
+	RecipientPending = "pending"
+	RecipientSent    = "sent"
+	RecipientFailed  = "failed"
+	RecipientBlocked = "blocked"
+)
+
+type BroadcastSegment struct {
+	ActiveDays int    `json:"active_days"`
+	Currency   string `json:"currency"`
+	HasSavings bool   `json:"has_savings"`
+}
+
+type BroadcastStats struct {
+	Total   int `json:"total"`
+	Pending int `json:"pending"`
+	Sent    int `json:"sent"`
+	Failed  int `json:"failed"`
+	Blocked int `json:"blocked"`
+}
+
+type Broadcast struct {
+	ID         int              `json:"id"`
+	Text       string           `json:"text"`
+	ParseMode  string           `json:"parse_mode"`
+	Segment    BroadcastSegment `json:"segment"`
+	Status     string           `json:"status"`
+	CreatedAt  time.Time        `json:"created_at"`
+	FinishedAt *time.Time       `json:"finished_at"`
+	Stats      BroadcastStats   `json:"stats"`
+}
+
+type BroadcastRecipient struct {
+	UserID     int        `json:"user_id"`
+	TelegramID int64      `json:"telegram_id"`
+	Username   string     `json:"username"`
+	Status     string     `json:"status"`
+	Attempts   int        `json:"attempts"`
+	Error      string     `json:"error"`
+	SentAt     *time.Time `json:"sent_at"`
+}
+
+const broadcastColumns = `b.id, b.text, b.parse_mode, b.segment, b.status, b.created_at, b.finished_at,
+    COUNT(r.user_id),
+    COALESCE(SUM(r.status = 'pending'), 0),
+    COALESCE(SUM(r.status = 'sent'), 0),
+    COALESCE(SUM(r.status = 'failed'), 0),
+    COALESCE(SUM(r.status = 'blocked'), 0)`
+
+func scanBroadcast(row rowScanner) (*Broadcast, error) {
+	var b Broadcast
+	var segment, createdAt string
+	var finishedAt sql.NullString
+
+	if err := row.Scan(&b.ID, &b.Text, &b.ParseMode, &segment, &b.Status, &createdAt, &finishedAt,
+		&b.Stats.Total, &b.Stats.Pending, &b.Stats.Sent, &b.Stats.Failed, &b.Stats.Blocked); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(segment), &b.Segment); err != nil {
+		return nil, fmt.Errorf("parse broadcast segment: %w", err)
+	}
+	b.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	b.FinishedAt = parseNullTime(finishedAt)
+	return &b, nil
+}
+
+func (r *SQLiteRepository) GetSegmentUsers(seg BroadcastSegment, now time.Time) ([]User, error) {
+	conds := []string{"delivery_status = ?"}
+	args := []interface{}{UserActive}
+	if seg.ActiveDays > 0 {
+		conds = append(conds, "EXISTS (SELECT 1 FROM user_activity a WHERE a.user_id = users.id AND a.last_active >= ?)")
+		args = append(args, formatTime(now.AddDate(0, 0, -seg.ActiveDays)))
+	}
+	if seg.Currency != "" {
+		conds = append(conds, "COALESCE((SELECT c.currency FROM user_currency_settings c WHERE c.user_id = users.id), 'RUB') = ?")
+		args = append(args, seg.Currency)
+	}
+	if seg.HasSavings {
+		conds = append(conds, "EXISTS (SELECT 1 FROM savings s WHERE s.user_id = users.id)")
+	}
+
+	rows, err := r.db.Query("SELECT "+userColumns+" FROM users WHERE "+strings.Join(conds, " AND ")+" ORDER BY id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("get segment users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan segment user: %w", err)
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func (r *SQLiteRepository) CreateBroadcast(b Broadcast, users []User) (int, error) {
+	segment, err := json.Marshal(b.Segment)
+	if err != nil {
+		return 0, fmt.Errorf("encode broadcast segment: %w", err)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		"INSERT INTO broadcasts (text, parse_mode, segment, status, created_at) VALUES (?, ?, ?, ?, ?)",
+		b.Text, b.ParseMode, string(segment), BroadcastQueued, formatTime(time.Now()),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("create broadcast: %w", err)
+	}
+	id, _ := res.LastInsertId()
+
+	for _, u := range users {
+		if _, err := tx.Exec(
+			"INSERT INTO broadcast_recipients (broadcast_id, user_id, telegram_id) VALUES (?, ?, ?)",
+			id, u.ID, u.TelegramID,
+		); err != nil {
+			return 0, fmt.Errorf("add broadcast recipient: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+func (r *SQLiteRepository) GetBroadcasts(limit int) ([]Broadcast, error) {
+	rows, err := r.db.Query(`
+        SELECT `+broadcastColumns+`
+        FROM broadcasts b
+        LEFT JOIN broadcast_recipients r ON r.broadcast_id = b.id
+        GROUP BY b.id
+        ORDER BY b.id DESC
+        LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("get broadcasts: %w", err)
+	}
+	defer rows.Close()
+
+	list := []Broadcast{}
+	for rows.Next() {
+		b, err := scanBroadcast(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan broadcast: %w", err)
+		}
+		list = append(list, *b)
+	}
+	return list, rows.Err()
+}
+
+func (r *SQLiteRepository) GetBroadcast(id int) (*Broadcast, error) {
+	b, err := scanBroadcast(r.db.QueryRow(`
+        SELECT `+broadcastColumns+`
+        FROM broadcasts b
+        LEFT JOIN broadcast_recipients r ON r.broadcast_id = b.id
+        WHERE b.id = ?
+        GROUP BY b.id`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get broadcast: %w", err)
+	}
+	return b, nil
+}
+
+func (r *SQLiteRepository) GetBroadcastRecipients(broadcastID int, status string, limit int) ([]BroadcastRecipient, error) {
+	rows, err := r.db.Query(`
+        SELECT r.user_id, r.telegram_id, COALESCE(u.username, ''), r.status, r.attempts, COALESCE(r.error, ''), r.sent_at
+        FROM broadcast_recipients r
+        LEFT JOIN users u ON u.id = r.user_id
+        WHERE r.broadcast_id = ? AND (? = '' OR r.status = ?)
+        ORDER BY r.attempts, r.user_id
+        LIMIT ?`,
+		broadcastID, status, status, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get broadcast recipients: %w", err)
+	}
+	defer rows.Close()
+
+	list := []BroadcastRecipient{}
+	for rows.Next() {
+		var rc BroadcastRecipient
+		var sentAt sql.NullString
+		if err := rows.Scan(&rc.UserID, &rc.TelegramID, &rc.Username, &rc.Status, &rc.Attempts, &rc.Error, &sentAt); err != nil {
+			return nil, fmt.Errorf("scan broadcast recipient: %w", err)
+		}
+		rc.SentAt = parseNullTime(sentAt)
+		list = append(list, rc)
+	}
+	return list, rows.Err()
+}
+
+func (r *SQLiteRepository) MarkBroadcastRecipient(broadcastID, userID int, status, lastError string) error {
+	var sentAt sql.NullString
+	if status == RecipientSent {
+		sentAt = sql.NullString{String: formatTime(time.Now()), Valid: true}
+	}
+	_, err := r.db.Exec(`
+        UPDATE broadcast_recipients SET status = ?, attempts = attempts + 1, error = ?, sent_at = ?
+        WHERE broadcast_id = ? AND user_id = ?`,
+		status, nullableString(lastError), sentAt, broadcastID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("mark broadcast recipient: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) UpdateBroadcastStatus(id int, status string) error {
+	var finishedAt sql.NullString
+	if status == BroadcastDone {
+		finishedAt = sql.NullString{String: formatTime(time.Now()), Valid: true}
+	}
+	_, err := r.db.Exec("UPDATE broadcasts SET status = ?, finished_at = ? WHERE id = ?", status, finishedAt, id)
+	if err != nil {
+		return fmt.Errorf("update broadcast status: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const PaymentMethodCard = "card"
+
+type CreditCard struct {
+	ID                int
+	UserID            int
+	Name              string
+	Limit             float64
+	StatementDay      int
+	GraceDays         int
+	MinPaymentPercent float64
+	MinPaymentFixed   float64
+	CreatedAt         time.Time
+	LastReminderAt    *time.Time
+}
+
+type CreditCardPayment struct {
+	ID     int
+	UserID int
+	CardID int
+	Amount float64
+	Date   time.Time
+}
+
+const creditCardColumns = "id, name, credit_limit, statement_day, grace_days, min_payment_percent, min_payment_fixed, created_at, last_reminder_at"
+
+func scanCreditCard(row rowScanner, userID int) (*CreditCard, error) {
+	var c CreditCard
+	var createdAt string
+	var lastReminder sql.NullString
+
+	if err := row.Scan(&c.ID, &c.Name, &c.Limit, &c.StatementDay, &c.GraceDays,
+		&c.MinPaymentPercent, &c.MinPaymentFixed, &createdAt, &lastReminder); err != nil {
+		return nil, err
+	}
+
+	c.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	c.LastReminderAt = parseNullTime(lastReminder)
+	c.UserID = userID
+	return &c, nil
+}
+
+func (r *SQLiteRepository) CreateCreditCard(userID int, c CreditCard) (int, error) {
+	res, err := r.db.Exec(
+		`INSERT INTO credit_cards (user_id, name, credit_limit, statement_day, grace_days, min_payment_percent, min_payment_fixed, created_at)
+         VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		userID, c.Name, c.Limit, c.StatementDay, c.GraceDays, c.MinPaymentPercent, c.MinPaymentFixed, time.Now().Format(time.RFC3339),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("create credit card: %w", err)
+	}
+	id, _ := res.LastInsertId()
+	return int(id), nil
+}
+
+func (r *SQLiteRepository) GetCreditCards(userID int) ([]CreditCard, error) {
+	rows, err := r.db.Query(
+		"SELECT "+creditCardColumns+" FROM credit_cards WHERE user_id = ? AND active = TRUE ORDER BY id",
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get credit cards: %w", err)
+	}
+	defer rows.Close()
+
+	var cards []CreditCard
+	for rows.Next() {
+		c, err := scanCreditCard(rows, userID)
+		if err != nil {
+			return nil, fmt.Errorf("scan credit card: %w", err)
+		}
+		cards = append(cards, *c)
+	}
+	return cards, rows.Err()
+}
+
+func (r *SQLiteRepository) GetCreditCardByID(userID, id int) (*CreditCard, error) {
+	c, err := scanCreditCard(r.db.QueryRow(
+		"SELECT "+creditCardColumns+" FROM credit_cards WHERE id = ? AND user_id = ? AND active = TRUE",
+		id, userID,
+	), userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get credit card: %w", err)
+	}
+	return c, nil
+}
+
+func (r *SQLiteRepository) DeleteCreditCard(userID, id int) error {
+	_, err := r.db.Exec("UPDATE credit_cards SET active = FALSE WHERE id = ? AND user_id = ?", id, userID)
+	return err
+}
+
+func (r *SQLiteRepository) MarkCreditCardReminded(userID, id int, at time.Time) error {
+	_, err := r.db.Exec(
+		"UPDATE credit_cards SET last_reminder_at = ? WHERE id = ? AND user_id = ?",
+		formatTime(at), id, userID,
+	)
+	return err
+}
+
+func (r *SQLiteRepository) AddCreditCardPayment(userID int, p CreditCardPayment) error {
+	_, err := r.db.Exec(
+		"INSERT INTO credit_card_payments (user_id, card_id, amount, date) VALUES (?, ?, ?, ?)",
+		userID, p.CardID, p.Amount, formatTime(p.Date),
+	)
+	return err
+}
+
+func (r *SQLiteRepository) GetCreditCardPaymentsTotal(userID, cardID int, from, until time.Time) (float64, error) {
+	var total float64
+	err := r.db.QueryRow(
+		"SELECT COALESCE(SUM(amount), 0) FROM credit_card_payments WHERE user_id = ? AND card_id = ? AND date >= ? AND date < ?",
+		userID, cardID, formatTime(from), formatTime(until),
+	).Scan(&total)
+	return total, err
+}
+
+func (r *SQLiteRepository) GetCreditCardChargesTotal(userID, cardID int, from, until time.Time) (float64, error) {
+	var total float64
+	err := r.db.QueryRow(
+		"SELECT COALESCE(SUM(amount), 0) FROM transactions WHERE user_id = ? AND credit_card_id = ? AND date >= ? AND date < ?",
+		userID, cardID, formatTime(from), formatTime(until),
+	).Scan(&total)
+	return total, err
+}
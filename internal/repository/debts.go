@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	DebtLent     = "lent"
+	DebtBorrowed = "borrowed"
+)
+
+type Debt struct {
+	ID             int
+	UserID         int
+	Counterparty   string
+	Direction      string
+	Amount         float64
+	Paid           float64
+	Currency       string
+	DueDate        *time.Time
+	Comment        string
+	CreatedAt      time.Time
+	ClosedAt       *time.Time
+	LastReminderAt *time.Time
+}
+
+type DebtPayment struct {
+	ID            int
+	UserID        int
+	DebtID        int
+	Amount        float64
+	Date          time.Time
+	TransactionID *int
+}
+
+func (d *Debt) Remaining() float64 {
+	return d.Amount - d.Paid
+}
+
+const debtQuery = `
+        SELECT d.id, d.counterparty, d.direction, d.amount, COALESCE(SUM(p.amount), 0), d.currency,
+               d.due_date, d.comment, d.created_at, d.closed_at, d.last_reminder_at
+        FROM debts d
+        LEFT JOIN debt_payments p ON p.debt_id = d.id
+        WHERE d.user_id = ?`
+
+func scanDebt(row rowScanner, userID int) (*Debt, error) {
+	var d Debt
+	var dueDate, comment, closedAt, lastReminder sql.NullString
+	var createdAt string
+
+	if err := row.Scan(&d.ID, &d.Counterparty, &d.Direction, &d.Amount, &d.Paid, &d.Currency,
+		&dueDate, &comment, &createdAt, &closedAt, &lastReminder); err != nil {
+		return nil, err
+	}
+
+	d.DueDate = parseNullTime(dueDate)
+	d.Comment = getStringFromNull(comment)
+	d.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	d.ClosedAt = parseNullTime(closedAt)
+	d.LastReminderAt = parseNullTime(lastReminder)
+	d.UserID = userID
+	return &d, nil
+}
+
+func (r *SQLiteRepository) CreateDebt(userID int, d Debt) (int, error) {
+	res, err := r.db.Exec(
+		"INSERT INTO debts (user_id, counterparty, direction, amount, currency, due_date, comment, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		userID, d.Counterparty, d.Direction, d.Amount, d.Currency, formatNullTime(d.DueDate), d.Comment, time.Now().Format(time.RFC3339),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("create debt: %w", err)
+	}
+	id, _ := res.LastInsertId()
+	return int(id), nil
+}
+
+func (r *SQLiteRepository) GetDebts(userID int, includeClosed bool) ([]Debt, error) {
+	query := debtQuery
+	if !includeClosed {
+		query += " AND d.closed_at IS NULL"
+	}
+	query += " GROUP BY d.id ORDER BY d.due_date IS NULL, d.due_date, d.created_at"
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get debts: %w", err)
+	}
+	defer rows.Close()
+
+	var debts []Debt
+	for rows.Next() {
+		d, err := scanDebt(rows, userID)
+		if err != nil {
+			return nil, fmt.Errorf("scan debt: %w", err)
+		}
+		debts = append(debts, *d)
+	}
+	return debts, rows.Err()
+}
+
+func (r *SQLiteRepository) GetDebtByID(userID, id int) (*Debt, error) {
+	d, err := scanDebt(r.db.QueryRow(debtQuery+" AND d.id = ? GROUP BY d.id", userID, id), userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get debt: %w", err)
+	}
+	return d, nil
+}
+
+func (r *SQLiteRepository) AddDebtPayment(userID int, p DebtPayment) error {
+	_, err := r.db.Exec(
+		"INSERT INTO debt_payments (user_id, debt_id, amount, date, transaction_id) VALUES (?, ?, ?, ?, ?)",
+		userID, p.DebtID, p.Amount, formatTime(p.Date), p.TransactionID,
+	)
+	return err
+}
+
+func (r *SQLiteRepository) GetDebtPayments(userID, debtID int) ([]DebtPayment, error) {
+	rows, err := r.db.Query(
+		"SELECT id, amount, date, transaction_id FROM debt_payments WHERE user_id = ? AND debt_id = ? ORDER BY date",
+		userID, debtID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get debt payments: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []DebtPayment
+	for rows.Next() {
+		var p DebtPayment
+		var ds string
+		var transactionID sql.NullInt64
+		if err := rows.Scan(&p.ID, &p.Amount, &ds, &transactionID); err != nil {
+			return nil, fmt.Errorf("scan debt payment: %w", err)
+		}
+		p.Date, _ = time.Parse(time.RFC3339, ds)
+		if transactionID.Valid {
+			id := int(transactionID.Int64)
+			p.TransactionID = &id
+		}
+		p.UserID = userID
+		p.DebtID = debtID
+		payments = append(payments, p)
+	}
+	return payments, rows.Err()
+}
+
+func (r *SQLiteRepository) CloseDebt(userID, id int, at time.Time) error {
+	_, err := r.db.Exec(
+		"UPDATE debts SET closed_at = ? WHERE id = ? AND user_id = ?",
+		formatTime(at), id, userID,
+	)
+	return err
+}
+
+func (r *SQLiteRepository) MarkDebtReminded(userID, id int, at time.Time) error {
+	_, err := r.db.Exec(
+		"UPDATE debts SET last_reminder_at = ? WHERE id = ? AND user_id = ?",
+		formatTime(at), id, userID,
+	)
+	return err
+}
+
+func (r *SQLiteRepository) DeleteDebt(userID, id int) error {
+	_, err := r.db.Exec("DELETE FROM debt_payments WHERE debt_id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec("DELETE FROM debts WHERE id = ? AND user_id = ?", id, userID)
+	return err
+}
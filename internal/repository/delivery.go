@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	UserActive      = "active"
+	UserBlocked     = "blocked"
+	UserDeactivated = "deactivated"
+)
+
+type ChurnStats struct {
+	Blocked       int
+	Deactivated   int
+	BlockedWeek   int
+	BlockedMonth  int
+	ReturnedMonth int
+}
+
+func (u User) Reachable() bool {
+	return u.DeliveryStatus == "" || u.DeliveryStatus == UserActive
+}
+
+func (u User) CanNotify() bool {
+	return u.NotificationsEnabled && u.Reachable()
+}
+
+func (r *SQLiteRepository) SetUserDeliveryStatus(telegramID int64, status, reason string) (bool, error) {
+	now := formatTime(time.Now())
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	err = tx.QueryRow(`
+        UPDATE users SET delivery_status = ?, delivery_status_at = ?
+        WHERE telegram_id = ? AND delivery_status != ?
+        RETURNING id`,
+		status, now, telegramID, status,
+	).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("update delivery status: %w", err)
+	}
+
+	if _, err := tx.Exec(
+		"INSERT INTO user_status_events (user_id, status, reason, created_at) VALUES (?, ?, ?, ?)",
+		userID, status, nullableString(reason), now,
+	); err != nil {
+		return false, fmt.Errorf("record delivery status: %w", err)
+	}
+	return true, tx.Commit()
+}
+
+func (r *SQLiteRepository) GetChurnStats(now time.Time) (*ChurnStats, error) {
+	var stats ChurnStats
+	err := r.db.QueryRow(`
+        SELECT
+            COALESCE(SUM(delivery_status = ?), 0),
+            COALESCE(SUM(delivery_status = ?), 0)
+        FROM users`,
+		UserBlocked, UserDeactivated,
+	).Scan(&stats.Blocked, &stats.Deactivated)
+	if err != nil {
+		return nil, fmt.Errorf("count unreachable users: %w", err)
+	}
+
+	err = r.db.QueryRow(`
+        SELECT
+            COUNT(DISTINCT CASE WHEN status = ? AND created_at >= ? THEN user_id END),
+            COUNT(DISTINCT CASE WHEN status = ? THEN user_id END),
+            COUNT(DISTINCT CASE WHEN status = ? THEN user_id END)
+        FROM user_status_events
+        WHERE created_at >= ?`,
+		UserBlocked, formatTime(now.AddDate(0, 0, -7)), UserBlocked, UserActive, formatTime(now.AddDate(0, 0, -30)),
+	).Scan(&stats.BlockedWeek, &stats.BlockedMonth, &stats.ReturnedMonth)
+	if err != nil {
+		return nil, fmt.Errorf("count churn events: %w", err)
+	}
+	return &stats, nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	DigestOff     = "off"
+	DigestWeekly  = "weekly"
+	DigestMonthly = "monthly"
+	DigestBoth    = "both"
+
+	DefaultDigestHour = 10
+)
+
+type DigestSettings struct {
+	UserID          int
+	Frequency       string
+	Hour            int
+	AttachPDF       bool
+	LastWeeklySent  *time.Time
+	LastMonthlySent *time.Time
+	LastYearReview  int
+}
+
+func (s DigestSettings) Weekly() bool {
+	return s.Frequency == DigestWeekly || s.Frequency == DigestBoth
+}
+
+func (s DigestSettings) Monthly() bool {
+	return s.Frequency == DigestMonthly || s.Frequency == DigestBoth
+}
+
+func (r *SQLiteRepository) GetDigestSettings(userID int) (*DigestSettings, error) {
+	s := DigestSettings{UserID: userID, Frequency: DigestBoth, Hour: DefaultDigestHour}
+	var lastWeekly, lastMonthly sql.NullString
+
+	err := r.db.QueryRow(
+		"SELECT frequency, hour, attach_pdf, last_weekly_sent, last_monthly_sent, last_year_review FROM digest_settings WHERE user_id = ?",
+		userID,
+	).Scan(&s.Frequency, &s.Hour, &s.AttachPDF, &lastWeekly, &lastMonthly, &s.LastYearReview)
+	if err == sql.ErrNoRows {
+		return &s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get digest settings: %w", err)
+	}
+
+	s.LastWeeklySent = parseNullTime(lastWeekly)
+	s.LastMonthlySent = parseNullTime(lastMonthly)
+	return &s, nil
+}
+
+func (r *SQLiteRepository) SaveDigestSettings(s DigestSettings) error {
+	_, err := r.db.Exec(`
+        INSERT INTO digest_settings (user_id, frequency, hour, attach_pdf)
+        VALUES (?, ?, ?, ?)
+        ON CONFLICT(user_id) DO UPDATE SET frequency = excluded.frequency, hour = excluded.hour, attach_pdf = excluded.attach_pdf`,
+		s.UserID, s.Frequency, s.Hour, s.AttachPDF,
+	)
+	if err != nil {
+		return fmt.Errorf("save digest settings: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) MarkDigestSent(userID int, frequency string, at time.Time) error {
+	column := "last_weekly_sent"
+	if frequency == DigestMonthly {
+		column = "last_monthly_sent"
+	}
+
+	if _, err := r.db.Exec(
+		"INSERT INTO digest_settings (user_id, frequency, hour) VALUES (?, ?, ?) ON CONFLICT(user_id) DO NOTHING",
+		userID, DigestBoth, DefaultDigestHour,
+	); err != nil {
+		return fmt.Errorf("mark digest sent: %w", err)
+	}
+	if _, err := r.db.Exec("UPDATE digest_settings SET "+column+" = ? WHERE user_id = ?", formatTime(at), userID); err != nil {
+		return fmt.Errorf("mark digest sent: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) MarkYearReviewSent(userID, year int) error {
+	_, err := r.db.Exec(`
+        INSERT INTO digest_settings (user_id, frequency, hour, last_year_review)
+        VALUES (?, ?, ?, ?)
+        ON CONFLICT(user_id) DO UPDATE SET last_year_review = excluded.last_year_review`,
+		userID, DigestBoth, DefaultDigestHour, year,
+	)
+	if err != nil {
+		return fmt.Errorf("mark year review sent: %w", err)
+	}
+	return nil
+}
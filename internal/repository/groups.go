@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	SplitEqual  = "equal"
+	SplitExact  = "exact"
+	SplitShares = "shares"
+)
+
+type GroupChat struct {
+	ID        int
+	ChatID    int64
+	Title     string
+	Currency  string
+	CreatedAt time.Time
+}
+
+type GroupMember struct {
+	ID         int
+	GroupID    int
+	TelegramID int64
+	Username   string
+	Name       string
+	JoinedAt   time.Time
+}
+
+type GroupExpense struct {
+	ID          int
+	GroupID     int
+	PayerID     int
+	Amount      float64
+	Description string
+	SplitType   string
+	CreatedBy   int64
+	CreatedAt   time.Time
+}
+
+type GroupExpenseShare struct {
+	MemberID int
+	Amount   float64
+}
+
+func (m *GroupMember) DisplayName() string {
+	if m.Username != "" {
+		return "@" + m.Username
+	}
+	if m.Name != "" {
+		return m.Name
+	}
+	return fmt.Sprintf("Участник %d", m.ID)
+}
+
+func (r *SQLiteRepository) GetOrCreateGroupChat(chatID int64, title string) (*GroupChat, error) {
+	var g GroupChat
+	var createdAt string
+
+	err := r.db.QueryRow(
+		"SELECT id, chat_id, title, currency, created_at FROM group_chats WHERE chat_id = ?", chatID,
+	).Scan(&g.ID, &g.ChatID, &g.Title, &g.Currency, &createdAt)
+
+	if err == sql.ErrNoRows {
+		now := time.Now()
+		res, err := r.db.Exec(
+			"INSERT INTO group_chats (chat_id, title, currency, created_at) VALUES (?, ?, 'RUB', ?)",
+			chatID, title, now.Format(time.RFC3339),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("create group chat: %w", err)
+		}
+		id, _ := res.LastInsertId()
+		return &GroupChat{ID: int(id), ChatID: chatID, Title: title, Currency: "RUB", CreatedAt: now}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	g.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	if title != "" && title != g.Title {
+		if _, err := r.db.Exec("UPDATE group_chats SET title = ? WHERE id = ?", title, g.ID); err == nil {
+			g.Title = title
+		}
+	}
+	return &g, nil
+}
+
+func (r *SQLiteRepository) SetGroupCurrency(groupID int, currency string) error {
+	_, err := r.db.Exec("UPDATE group_chats SET currency = ? WHERE id = ?", currency, groupID)
+	return err
+}
+
+const groupMemberColumns = "id, group_id, COALESCE(telegram_id, 0), COALESCE(username, ''), COALESCE(name, ''), joined_at"
+
+func scanGroupMember(row rowScanner) (*GroupMember, error) {
+	var m GroupMember
+	var joinedAt string
+	if err := row.Scan(&m.ID, &m.GroupID, &m.TelegramID, &m.Username, &m.Name, &joinedAt); err != nil {
+		return nil, err
+	}
+	m.JoinedAt, _ = time.Parse(time.RFC3339, joinedAt)
+	return &m, nil
+}
+
+func (r *SQLiteRepository) GetGroupMembers(groupID int) ([]GroupMember, error) {
+	rows, err := r.db.Query("SELECT "+groupMemberColumns+" FROM group_members WHERE group_id = ? ORDER BY id", groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []GroupMember
+	for rows.Next() {
+		m, err := scanGroupMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, *m)
+	}
+	return members, nil
+}
+
+func (r *SQLiteRepository) GetGroupMemberByTelegramID(groupID int, telegramID int64) (*GroupMember, error) {
+	m, err := scanGroupMember(r.db.QueryRow(
+		"SELECT "+groupMemberColumns+" FROM group_members WHERE group_id = ? AND telegram_id = ?", groupID, telegramID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return m, err
+}
+
+func (r *SQLiteRepository) GetGroupMemberByUsername(groupID int, username string) (*GroupMember, error) {
+	m, err := scanGroupMember(r.db.QueryRow(
+		"SELECT "+groupMemberColumns+" FROM group_members WHERE group_id = ? AND LOWER(username) = ?", groupID, strings.ToLower(username)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return m, err
+}
+
+func nullableTelegramID(id int64) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func (r *SQLiteRepository) CreateGroupMember(m GroupMember) (int, error) {
+	res, err := r.db.Exec(
+		"INSERT INTO group_members (group_id, telegram_id, username, name, joined_at) VALUES (?, ?, ?, ?, ?)",
+		m.GroupID, nullableTelegramID(m.TelegramID), nullableString(m.Username), m.Name, time.Now().Format(time.RFC3339),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("create group member: %w", err)
+	}
+	id, _ := res.LastInsertId()
+	return int(id), nil
+}
+
+func (r *SQLiteRepository) UpdateGroupMember(m GroupMember) error {
+	_, err := r.db.Exec(
+		"UPDATE group_members SET telegram_id = ?, username = ?, name = ? WHERE id = ? AND group_id = ?",
+		nullableTelegramID(m.TelegramID), nullableString(m.Username), m.Name, m.ID, m.GroupID,
+	)
+	return err
+}
+
+func (r *SQLiteRepository) AddGroupExpense(e GroupExpense, shares []GroupExpenseShare) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		"INSERT INTO group_expenses (group_id, payer_id, amount, description, split_type, created_by, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		e.GroupID, e.PayerID, e.Amount, e.Description, e.SplitType, e.CreatedBy, time.Now().Format(time.RFC3339),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("create group expense: %w", err)
+	}
+	id, _ := res.LastInsertId()
+
+	for _, s := range shares {
+		if _, err := tx.Exec(
+			"INSERT INTO group_expense_shares (expense_id, member_id, amount) VALUES (?, ?, ?)",
+			id, s.MemberID, s.Amount,
+		); err != nil {
+			return 0, fmt.Errorf("create group share: %w", err)
+		}
+	}
+
+	return int(id), tx.Commit()
+}
+
+const groupExpenseColumns = "id, group_id, payer_id, amount, description, split_type, created_by, created_at"
+
+func scanGroupExpense(row rowScanner) (*GroupExpense, error) {
+	var e GroupExpense
+	var createdAt string
+	if err := row.Scan(&e.ID, &e.GroupID, &e.PayerID, &e.Amount, &e.Description, &e.SplitType, &e.CreatedBy, &createdAt); err != nil {
+		return nil, err
+	}
+	e.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	return &e, nil
+}
+
+func (r *SQLiteRepository) GetGroupExpenses(groupID, limit int) ([]GroupExpense, error) {
+	rows, err := r.db.Query(
+		"SELECT "+groupExpenseColumns+" FROM group_expenses WHERE group_id = ? ORDER BY created_at DESC, id DESC LIMIT ?",
+		groupID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expenses []GroupExpense
+	for rows.Next() {
+		e, err := scanGroupExpense(rows)
+		if err != nil {
+			return nil, err
+		}
+		expenses = append(expenses, *e)
+	}
+	return expenses, nil
+}
+
+func (r *SQLiteRepository) GetGroupExpenseByID(groupID, id int) (*GroupExpense, error) {
+	e, err := scanGroupExpense(r.db.QueryRow(
+		"SELECT "+groupExpenseColumns+" FROM group_expenses WHERE id = ? AND group_id = ?", id, groupID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return e, err
+}
+
+func (r *SQLiteRepository) DeleteGroupExpense(groupID, id int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		"DELETE FROM group_expense_shares WHERE expense_id IN (SELECT id FROM group_expenses WHERE id = ? AND group_id = ?)",
+		id, groupID,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM group_expenses WHERE id = ? AND group_id = ?", id, groupID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteRepository) AddGroupSettlement(groupID, fromID, toID int, amount float64) error {
+	_, err := r.db.Exec(
+		"INSERT INTO group_settlements (group_id, from_member_id, to_member_id, amount, created_at) VALUES (?, ?, ?, ?, ?)",
+		groupID, fromID, toID, amount, time.Now().Format(time.RFC3339),
+	)
+	return err
+}
+
+func (r *SQLiteRepository) GetGroupNetBalances(groupID int) (map[int]float64, error) {
+	rows, err := r.db.Query(`
+        SELECT payer_id, amount FROM group_expenses WHERE group_id = ?
+        UNION ALL
+        SELECT s.member_id, -s.amount FROM group_expense_shares s
+        JOIN group_expenses e ON e.id = s.expense_id
+        WHERE e.group_id = ?
+        UNION ALL
+        SELECT from_member_id, amount FROM group_settlements WHERE group_id = ?
+        UNION ALL
+        SELECT to_member_id, -amount FROM group_settlements WHERE group_id = ?`,
+		groupID, groupID, groupID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	balances := make(map[int]float64)
+	for rows.Next() {
+		var memberID int
+		var amount float64
+		if err := rows.Scan(&memberID, &amount); err != nil {
+			return nil, err
+		}
+		balances[memberID] += amount
+	}
+	return balances, nil
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	HouseholdRoleOwner  = "owner"
+	HouseholdRoleMember = "member"
+
+	ReportScopeHousehold = "household"
+	ReportScopePersonal  = "personal"
+)
+
+type Household struct {
+	ID          int
+	Name        string
+	OwnerUserID int
+	InviteCode  string
+	CreatedAt   time.Time
+}
+
+type HouseholdMember struct {
+	HouseholdID int
+	UserID      int
+	TelegramID  int64
+	Username    string
+	FirstName   string
+	Role        string
+	ReportScope string
+	JoinedAt    time.Time
+}
+
+func (m *HouseholdMember) DisplayName() string {
+	if m.FirstName != "" {
+		return m.FirstName
+	}
+	if m.Username != "" {
+		return "@" + m.Username
+	}
+	return fmt.Sprintf("Участник %d", m.UserID)
+}
+
+const householdColumns = "h.id, h.name, h.owner_user_id, h.invite_code, h.created_at"
+
+func scanHousehold(row rowScanner) (*Household, error) {
+	var h Household
+	var createdAt string
+	if err := row.Scan(&h.ID, &h.Name, &h.OwnerUserID, &h.InviteCode, &createdAt); err != nil {
+		return nil, err
+	}
+	h.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	return &h, nil
+}
+
+func (r *SQLiteRepository) CreateHousehold(ownerID int, name, inviteCode string) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Format(time.RFC3339)
+	res, err := tx.Exec(
+		"INSERT INTO households (name, owner_user_id, invite_code, created_at) VALUES (?, ?, ?, ?)",
+		name, ownerID, inviteCode, now,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("create household: %w", err)
+	}
+	id, _ := res.LastInsertId()
+
+	if _, err := tx.Exec(
+		"INSERT INTO household_members (household_id, user_id, role, joined_at) VALUES (?, ?, ?, ?)",
+		id, ownerID, HouseholdRoleOwner, now,
+	); err != nil {
+		return 0, fmt.Errorf("add household owner: %w", err)
+	}
+
+	return int(id), tx.Commit()
+}
+
+func (r *SQLiteRepository) GetUserHousehold(userID int) (*Household, *HouseholdMember, error) {
+	var m HouseholdMember
+	var joinedAt string
+
+	row := r.db.QueryRow(`
+        SELECT `+householdColumns+`, m.role, m.report_scope, m.joined_at
+        FROM household_members m
+        JOIN households h ON h.id = m.household_id
+        WHERE m.user_id = ?`, userID)
+
+	var h Household
+	var createdAt string
+	err := row.Scan(&h.ID, &h.Name, &h.OwnerUserID, &h.InviteCode, &createdAt, &m.Role, &m.ReportScope, &joinedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	h.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	m.HouseholdID = h.ID
+	m.UserID = userID
+	m.JoinedAt, _ = time.Parse(time.RFC3339, joinedAt)
+	return &h, &m, nil
+}
+
+func (r *SQLiteRepository) GetHouseholdByInviteCode(code string) (*Household, error) {
+	h, err := scanHousehold(r.db.QueryRow("SELECT "+householdColumns+" FROM households h WHERE h.invite_code = ?", code))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return h, err
+}
+
+func (r *SQLiteRepository) GetHouseholdMembers(householdID int) ([]HouseholdMember, error) {
+	rows, err := r.db.Query(`
+        SELECT m.user_id, u.telegram_id, COALESCE(u.username, ''), COALESCE(u.first_name, ''), m.role, m.report_scope, m.joined_at
+        FROM household_members m
+        JOIN users u ON u.id = m.user_id
+        WHERE m.household_id = ?
+        ORDER BY m.role = 'owner' DESC, m.joined_at`, householdID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []HouseholdMember
+	for rows.Next() {
+		m := HouseholdMember{HouseholdID: householdID}
+		var joinedAt string
+		if err := rows.Scan(&m.UserID, &m.TelegramID, &m.Username, &m.FirstName, &m.Role, &m.ReportScope, &joinedAt); err != nil {
+			return nil, err
+		}
+		m.JoinedAt, _ = time.Parse(time.RFC3339, joinedAt)
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+func (r *SQLiteRepository) AddHouseholdMember(householdID, userID int) error {
+	_, err := r.db.Exec(
+		"INSERT INTO household_members (household_id, user_id, role, joined_at) VALUES (?, ?, ?, ?)",
+		householdID, userID, HouseholdRoleMember, time.Now().Format(time.RFC3339),
+	)
+	return err
+}
+
+func (r *SQLiteRepository) RemoveHouseholdMember(householdID, userID int) error {
+	_, err := r.db.Exec(
+		"DELETE FROM household_members WHERE household_id = ? AND user_id = ? AND role <> ?",
+		householdID, userID, HouseholdRoleOwner,
+	)
+	return err
+}
+
+func (r *SQLiteRepository) DeleteHousehold(householdID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM household_members WHERE household_id = ?", householdID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM households WHERE id = ?", householdID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteRepository) UpdateHouseholdInviteCode(householdID int, code string) error {
+	_, err := r.db.Exec("UPDATE households SET invite_code = ? WHERE id = ?", code, householdID)
+	return err
+}
+
+func (r *SQLiteRepository) SetHouseholdReportScope(householdID, userID int, scope string) error {
+	_, err := r.db.Exec(
+		"UPDATE household_members SET report_scope = ? WHERE household_id = ? AND user_id = ?",
+		scope, householdID, userID,
+	)
+	return err
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	JobPending = "pending"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+type Job struct {
+	ID          int
+	Kind        string
+	Key         string
+	Payload     string
+	Status      string
+	RunAt       time.Time
+	Interval    time.Duration
+	Attempts    int
+	MaxAttempts int
+	LockedUntil *time.Time
+	LastRunAt   *time.Time
+	LastResult  string
+	LastError   string
+	CreatedAt   time.Time
+}
+
+const jobColumns = "id, kind, idempotency_key, payload, status, run_at, interval_seconds, attempts, max_attempts, locked_until, last_run_at, COALESCE(last_result, ''), COALESCE(last_error, ''), created_at"
+
+func scanJob(row rowScanner) (*Job, error) {
+	var j Job
+	var runAt, createdAt string
+	var interval int
+	var lockedUntil, lastRunAt sql.NullString
+	err := row.Scan(&j.ID, &j.Kind, &j.Key, &j.Payload, &j.Status, &runAt, &interval, &j.Attempts, &j.MaxAttempts,
+		&lockedUntil, &lastRunAt, &j.LastResult, &j.LastError, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	j.RunAt, _ = time.Parse(time.RFC3339, runAt)
+	j.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	j.Interval = time.Duration(interval) * time.Second
+	j.LockedUntil = parseNullTime(lockedUntil)
+	j.LastRunAt = parseNullTime(lastRunAt)
+	return &j, nil
+}
+
+func (r *SQLiteRepository) EnqueueJob(j Job) (bool, error) {
+	res, err := r.db.Exec(`
+        INSERT INTO jobs (kind, idempotency_key, payload, run_at, interval_seconds, max_attempts, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT(idempotency_key) DO NOTHING`,
+		j.Kind, j.Key, j.Payload, formatTime(j.RunAt), int(j.Interval/time.Second), j.MaxAttempts, formatTime(time.Now()),
+	)
+	if err != nil {
+		return false, fmt.Errorf("enqueue job: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+func (r *SQLiteRepository) ScheduleRecurringJob(kind string, interval time.Duration, firstRun time.Time) error {
+	_, err := r.db.Exec(`
+        INSERT INTO jobs (kind, idempotency_key, run_at, interval_seconds, max_attempts, created_at)
+        VALUES (?, ?, ?, ?, 0, ?)
+        ON CONFLICT(idempotency_key) DO UPDATE SET interval_seconds = excluded.interval_seconds`,
+		kind, kind, formatTime(firstRun), int(interval/time.Second), formatTime(time.Now()),
+	)
+	if err != nil {
+		return fmt.Errorf("schedule recurring job: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) ClaimDueJob(now time.Time, lease time.Duration) (*Job, error) {
+	job, err := scanJob(r.db.QueryRow(`
+        UPDATE jobs SET status = ?, attempts = attempts + 1, locked_until = ?, last_run_at = ?
+        WHERE id = (
+            SELECT id FROM jobs
+            WHERE (status = ? AND run_at <= ?) OR (status = ? AND locked_until <= ?)
+            ORDER BY run_at, id
+            LIMIT 1
+        )
+        RETURNING `+jobColumns,
+		JobRunning, formatTime(now.Add(lease)), formatTime(now),
+		JobPending, formatTime(now), JobRunning, formatTime(now),
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("claim job: %w", err)
+	}
+	return job, nil
+}
+
+func (r *SQLiteRepository) FinishJob(id int, status, result, lastError string, nextRun *time.Time, resetAttempts bool) error {
+	runAt := sql.NullString{}
+	if nextRun != nil {
+		runAt = sql.NullString{String: formatTime(*nextRun), Valid: true}
+	}
+	_, err := r.db.Exec(`
+        UPDATE jobs SET status = ?, last_result = ?, last_error = ?, locked_until = NULL,
+            run_at = COALESCE(?, run_at),
+            attempts = CASE WHEN ? THEN 0 ELSE attempts END
+        WHERE id = ?`,
+		status, nullableString(result), nullableString(lastError), runAt, resetAttempts, id,
+	)
+	if err != nil {
+		return fmt.Errorf("finish job: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) CountJobsByStatus() (map[string]int, error) {
+	rows, err := r.db.Query("SELECT status, COUNT(*) FROM jobs GROUP BY status")
+	if err != nil {
+		return nil, fmt.Errorf("count jobs: %w", err)
+	}
+	defer rows.Close()
+
+	res := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, fmt.Errorf("scan job count: %w", err)
+		}
+		res[status] = n
+	}
+	return res, rows.Err()
+}
+
+func (r *SQLiteRepository) DeleteFinishedJobs(before time.Time) (int, error) {
+	res, err := r.db.Exec(
+		"DELETE FROM jobs WHERE interval_seconds = 0 AND status IN (?, ?) AND last_run_at < ?",
+		JobDone, JobFailed, formatTime(before),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("delete finished jobs: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
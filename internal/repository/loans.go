@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	LoanAnnuity        = "annuity"
+	LoanDifferentiated = "differentiated"
+
+	LoanPaymentScheduled = "scheduled"
+	LoanPaymentEarly     = "early"
+
+	EarlyReduceTerm    = "term"
+	EarlyReducePayment = "payment"
+)
+
+type Loan struct {
+	ID           int
+	UserID       int
+	Name         string
+	Principal    float64
+	Rate         float64
+	TermMonths   int
+	ScheduleType string
+	IssueDate    time.Time
+	CreatedAt    time.Time
+	ClosedAt     *time.Time
+}
+
+type LoanPayment struct {
+	ID                     int
+	UserID                 int
+	LoanID                 int
+	Kind                   string
+	EarlyMode              string
+	Principal              float64
+	Interest               float64
+	Date                   time.Time
+	TransactionID          *int
+	PrincipalTransactionID *int
+}
+
+const loanColumns = "id, name, principal, rate, term_months, schedule_type, issue_date, created_at, closed_at"
+
+func scanLoan(row rowScanner, userID int) (*Loan, error) {
+	var l Loan
+	var issueDate, createdAt string
+	var closedAt sql.NullString
+
+	if err := row.Scan(&l.ID, &l.Name, &l.Principal, &l.Rate, &l.TermMonths, &l.ScheduleType,
+		&issueDate, &createdAt, &closedAt); err != nil {
+		return nil, err
+	}
+
+	l.IssueDate, _ = time.Parse(time.RFC3339, issueDate)
+	l.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	l.ClosedAt = parseNullTime(closedAt)
+	l.UserID = userID
+	return &l, nil
+}
+
+func (r *SQLiteRepository) CreateLoan(userID int, l Loan) (int, error) {
+	res, err := r.db.Exec(
+		"INSERT INTO loans (user_id, name, principal, rate, term_months, schedule_type, issue_date, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		userID, l.Name, l.Principal, l.Rate, l.TermMonths, l.ScheduleType, formatTime(l.IssueDate), time.Now().Format(time.RFC3339),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("create loan: %w", err)
+	}
+	id, _ := res.LastInsertId()
+	return int(id), nil
+}
+
+func (r *SQLiteRepository) GetLoans(userID int, includeClosed bool) ([]Loan, error) {
+	query := "SELECT " + loanColumns + " FROM loans WHERE user_id = ?"
+	if !includeClosed {
+		query += " AND closed_at IS NULL"
+	}
+	query += " ORDER BY issue_date"
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get loans: %w", err)
+	}
+	defer rows.Close()
+
+	var loans []Loan
+	for rows.Next() {
+		l, err := scanLoan(rows, userID)
+		if err != nil {
+			return nil, fmt.Errorf("scan loan: %w", err)
+		}
+		loans = append(loans, *l)
+	}
+	return loans, rows.Err()
+}
+
+func (r *SQLiteRepository) GetLoanByID(userID, id int) (*Loan, error) {
+	l, err := scanLoan(r.db.QueryRow("SELECT "+loanColumns+" FROM loans WHERE id = ? AND user_id = ?", id, userID), userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get loan: %w", err)
+	}
+	return l, nil
+}
+
+func (r *SQLiteRepository) AddLoanPayment(userID int, p LoanPayment) error {
+	var mode interface{}
+	if p.EarlyMode != "" {
+		mode = p.EarlyMode
+	}
+	_, err := r.db.Exec(
+		"INSERT INTO loan_payments (user_id, loan_id, kind, early_mode, principal, interest, date, transaction_id, principal_transaction_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		userID, p.LoanID, p.Kind, mode, p.Principal, p.Interest, formatTime(p.Date), p.TransactionID, p.PrincipalTransactionID,
+	)
+	return err
+}
+
+func (r *SQLiteRepository) GetLoanPayments(userID, loanID int) ([]LoanPayment, error) {
+	rows, err := r.db.Query(
+		"SELECT id, kind, early_mode, principal, interest, date, transaction_id, principal_transaction_id FROM loan_payments WHERE user_id = ? AND loan_id = ? ORDER BY date, id",
+		userID, loanID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get loan payments: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []LoanPayment
+	for rows.Next() {
+		var p LoanPayment
+		var mode sql.NullString
+		var ds string
+		var transactionID, principalTransactionID sql.NullInt64
+		if err := rows.Scan(&p.ID, &p.Kind, &mode, &p.Principal, &p.Interest, &ds, &transactionID, &principalTransactionID); err != nil {
+			return nil, fmt.Errorf("scan loan payment: %w", err)
+		}
+		p.EarlyMode = getStringFromNull(mode)
+		p.Date, _ = time.Parse(time.RFC3339, ds)
+		if transactionID.Valid {
+			id := int(transactionID.Int64)
+			p.TransactionID = &id
+		}
+		if principalTransactionID.Valid {
+			id := int(principalTransactionID.Int64)
+			p.PrincipalTransactionID = &id
+		}
+		p.UserID = userID
+		p.LoanID = loanID
+		payments = append(payments, p)
+	}
+	return payments, rows.Err()
+}
+
+func (r *SQLiteRepository) CloseLoan(userID, id int, at time.Time) error {
+	_, err := r.db.Exec(
+		"UPDATE loans SET closed_at = ? WHERE id = ? AND user_id = ?",
+		formatTime(at), id, userID,
+	)
+	return err
+}
+
+func (r *SQLiteRepository) DeleteLoan(userID, id int) error {
+	_, err := r.db.Exec("DELETE FROM loan_payments WHERE loan_id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec("DELETE FROM loans WHERE id = ? AND user_id = ?", id, userID)
+	return err
+}
+
+func markLoanPrincipalTransfers(db *sql.DB) error {
+	res, err := db.Exec(`
+        UPDATE transactions SET transfer = 1
+        WHERE transfer = 0 AND id IN (
+            SELECT principal_transaction_id FROM loan_payments WHERE principal_transaction_id IS NOT NULL
+        )`)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+	return rebuildMonthlyAggregates(db, 0)
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type Asset struct {
+	ID        int
+	UserID    int
+	Name      string
+	Value     float64
+	UpdatedAt time.Time
+}
+
+type NetWorthSnapshot struct {
+	UserID      int
+	Month       time.Time
+	Assets      float64
+	Liabilities float64
+	TakenAt     time.Time
+}
+
+func (s NetWorthSnapshot) Net() float64 {
+	return s.Assets - s.Liabilities
+}
+
+func (r *SQLiteRepository) CreateAsset(userID int, name string, value float64) (int, error) {
+	res, err := r.db.Exec(
+		"INSERT INTO assets (user_id, name, value, updated_at) VALUES (?, ?, ?, ?)",
+		userID, name, value, time.Now().Format(time.RFC3339),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("create asset: %w", err)
+	}
+	id, _ := res.LastInsertId()
+	return int(id), nil
+}
+
+func (r *SQLiteRepository) GetAssets(userID int) ([]Asset, error) {
+	rows, err := r.db.Query("SELECT id, name, value, updated_at FROM assets WHERE user_id = ? ORDER BY value DESC", userID)
+	if err != nil {
+		return nil, fmt.Errorf("get assets: %w", err)
+	}
+	defer rows.Close()
+
+	var assets []Asset
+	for rows.Next() {
+		var a Asset
+		var updatedAt string
+		if err := rows.Scan(&a.ID, &a.Name, &a.Value, &updatedAt); err != nil {
+			return nil, fmt.Errorf("scan asset: %w", err)
+		}
+		a.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+		a.UserID = userID
+		assets = append(assets, a)
+	}
+	return assets, rows.Err()
+}
+
+func (r *SQLiteRepository) GetAssetByID(userID, id int) (*Asset, error) {
+	a := Asset{ID: id, UserID: userID}
+	var updatedAt string
+	err := r.db.QueryRow(
+		"SELECT name, value, updated_at FROM assets WHERE id = ? AND user_id = ?", id, userID,
+	).Scan(&a.Name, &a.Value, &updatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get asset: %w", err)
+	}
+	a.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	return &a, nil
+}
+
+func (r *SQLiteRepository) UpdateAssetValue(userID, id int, value float64) error {
+	_, err := r.db.Exec(
+		"UPDATE assets SET value = ?, updated_at = ? WHERE id = ? AND user_id = ?",
+		value, time.Now().Format(time.RFC3339), id, userID,
+	)
+	return err
+}
+
+func (r *SQLiteRepository) DeleteAsset(userID, id int) error {
+	_, err := r.db.Exec("DELETE FROM assets WHERE id = ? AND user_id = ?", id, userID)
+	return err
+}
+
+func (r *SQLiteRepository) GetAccountBalance(userID int) (float64, error) {
+	var transactions, cardPayments float64
+	err := r.db.QueryRow(
+		"SELECT COALESCE(SUM(amount), 0) FROM transactions WHERE user_id = ? AND credit_card_id IS NULL",
+		userID,
+	).Scan(&transactions)
+	if err != nil {
+		return 0, fmt.Errorf("get account balance: %w", err)
+	}
+
+	err = r.db.QueryRow(
+		"SELECT COALESCE(SUM(amount), 0) FROM credit_card_payments WHERE user_id = ?",
+		userID,
+	).Scan(&cardPayments)
+	if err != nil {
+		return 0, fmt.Errorf("get account balance: %w", err)
+	}
+	return transactions - cardPayments, nil
+}
+
+func (r *SQLiteRepository) SaveNetWorthSnapshot(s NetWorthSnapshot) error {
+	_, err := r.db.Exec(`
+        INSERT INTO net_worth_snapshots (user_id, month, assets, liabilities, taken_at)
+        VALUES (?, ?, ?, ?, ?)
+        ON CONFLICT(user_id, month) DO UPDATE SET assets = excluded.assets, liabilities = excluded.liabilities, taken_at = excluded.taken_at`,
+		s.UserID, s.Month.Format("2006-01"), s.Assets, s.Liabilities, formatTime(s.TakenAt),
+	)
+	if err != nil {
+		return fmt.Errorf("save net worth snapshot: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) GetNetWorthSnapshots(userID int, since time.Time) ([]NetWorthSnapshot, error) {
+	rows, err := r.db.Query(
+		"SELECT month, assets, liabilities, taken_at FROM net_worth_snapshots WHERE user_id = ? AND month >= ? ORDER BY month",
+		userID, since.Format("2006-01"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get net worth snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []NetWorthSnapshot
+	for rows.Next() {
+		var s NetWorthSnapshot
+		var month, takenAt string
+		if err := rows.Scan(&month, &s.Assets, &s.Liabilities, &takenAt); err != nil {
+			return nil, fmt.Errorf("scan net worth snapshot: %w", err)
+		}
+		s.Month, _ = time.ParseInLocation("2006-01", month, time.Local)
+		s.TakenAt, _ = time.Parse(time.RFC3339, takenAt)
+		s.UserID = userID
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, rows.Err()
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	SavingRulePercentIncome = "percent_income"
+	SavingRuleRoundUp       = "round_up"
+	SavingRuleSchedule      = "schedule"
+)
+
+type SavingRule struct {
+	ID           int
+	UserID       int
+	SavingID     int
+	Type         string
+	Value        float64
+	IntervalDays int
+	NextRun      *time.Time
+	Active       bool
+	CreatedAt    time.Time
+}
+
+type SavingAutoMove struct {
+	ID            int
+	UserID        int
+	RuleID        int
+	SavingID      int
+	TransactionID *int
+	Amount        float64
+	CreatedAt     time.Time
+	Undone        bool
+	RuleType      string
+	SavingName    string
+}
+
+const savingRuleColumns = "id, saving_id, type, value, interval_days, next_run, active, created_at"
+
+func scanSavingRule(row rowScanner, userID int) (*SavingRule, error) {
+	var rule SavingRule
+	var nextRun sql.NullString
+	var createdAt string
+
+	if err := row.Scan(&rule.ID, &rule.SavingID, &rule.Type, &rule.Value, &rule.IntervalDays, &nextRun, &rule.Active, &createdAt); err != nil {
+		return nil, err
+	}
+
+	rule.NextRun = parseNullTime(nextRun)
+	rule.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	rule.UserID = userID
+	return &rule, nil
+}
+
+func (r *SQLiteRepository) CreateSavingRule(userID int, rule SavingRule) (int, error) {
+	res, err := r.db.Exec(
+		"INSERT INTO saving_rules (user_id, saving_id, type, value, interval_days, next_run, active, created_at) VALUES (?, ?, ?, ?, ?, ?, TRUE, ?)",
+		userID, rule.SavingID, rule.Type, rule.Value, rule.IntervalDays, formatNullTime(rule.NextRun), time.Now().Format(time.RFC3339),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("create saving rule: %w", err)
+	}
+	id, _ := res.LastInsertId()
+	return int(id), nil
+}
+
+func (r *SQLiteRepository) GetSavingRules(userID int) ([]SavingRule, error) {
+	return r.querySavingRules(userID,
+		"SELECT "+savingRuleColumns+" FROM saving_rules WHERE user_id = ? AND active = TRUE ORDER BY id",
+		userID,
+	)
+}
+
+func (r *SQLiteRepository) GetSavingRulesBySaving(userID, savingID int) ([]SavingRule, error) {
+	return r.querySavingRules(userID,
+		"SELECT "+savingRuleColumns+" FROM saving_rules WHERE user_id = ? AND saving_id = ? AND active = TRUE ORDER BY id",
+		userID, savingID,
+	)
+}
+
+func (r *SQLiteRepository) GetDueSavingRules(userID int, now time.Time) ([]SavingRule, error) {
+	return r.querySavingRules(userID,
+		"SELECT "+savingRuleColumns+" FROM saving_rules WHERE user_id = ? AND type = ? AND active = TRUE AND next_run <= ? ORDER BY next_run",
+		userID, SavingRuleSchedule, formatTime(now),
+	)
+}
+
+func (r *SQLiteRepository) querySavingRules(userID int, query string, args ...interface{}) ([]SavingRule, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get saving rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []SavingRule
+	for rows.Next() {
+		rule, err := scanSavingRule(rows, userID)
+		if err != nil {
+			return nil, fmt.Errorf("scan saving rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+	return rules, rows.Err()
+}
+
+func (r *SQLiteRepository) UpdateSavingRuleNextRun(userID, id int, nextRun time.Time) error {
+	_, err := r.db.Exec(
+		"UPDATE saving_rules SET next_run = ? WHERE id = ? AND user_id = ?",
+		formatTime(nextRun), id, userID,
+	)
+	return err
+}
+
+func (r *SQLiteRepository) DeleteSavingRule(userID, id int) error {
+	_, err := r.db.Exec(
+		"UPDATE saving_rules SET active = FALSE WHERE id = ? AND user_id = ?",
+		id, userID,
+	)
+	return err
+}
+
+func (r *SQLiteRepository) AddSavingAutoMove(userID int, m SavingAutoMove) (int, error) {
+	res, err := r.db.Exec(
+		"INSERT INTO saving_auto_moves (user_id, rule_id, saving_id, transaction_id, amount, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		userID, m.RuleID, m.SavingID, m.TransactionID, m.Amount, formatTime(m.CreatedAt),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("insert auto move: %w", err)
+	}
+	id, _ := res.LastInsertId()
+	return int(id), nil
+}
+
+const savingAutoMoveQuery = `
+        SELECT m.id, m.rule_id, m.saving_id, m.transaction_id, m.amount, m.created_at, m.undone, r.type, s.name
+        FROM saving_auto_moves m
+        JOIN saving_rules r ON r.id = m.rule_id
+        JOIN savings s ON s.id = m.saving_id
+        WHERE m.user_id = ?`
+
+func (r *SQLiteRepository) GetSavingAutoMoves(userID, savingID, limit int) ([]SavingAutoMove, error) {
+	return r.querySavingAutoMoves(userID,
+		savingAutoMoveQuery+" AND m.saving_id = ? ORDER BY m.created_at DESC, m.id DESC LIMIT ?",
+		userID, savingID, limit,
+	)
+}
+
+func (r *SQLiteRepository) GetSavingAutoMovesByTransaction(userID, transactionID int) ([]SavingAutoMove, error) {
+	return r.querySavingAutoMoves(userID,
+		savingAutoMoveQuery+" AND m.transaction_id = ? ORDER BY m.id",
+		userID, transactionID,
+	)
+}
+
+func (r *SQLiteRepository) GetSavingAutoMoveByID(userID, id int) (*SavingAutoMove, error) {
+	moves, err := r.querySavingAutoMoves(userID, savingAutoMoveQuery+" AND m.id = ?", userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(moves) == 0 {
+		return nil, nil
+	}
+	return &moves[0], nil
+}
+
+func (r *SQLiteRepository) querySavingAutoMoves(userID int, query string, args ...interface{}) ([]SavingAutoMove, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get auto moves: %w", err)
+	}
+	defer rows.Close()
+
+	var moves []SavingAutoMove
+	for rows.Next() {
+		var m SavingAutoMove
+		var transactionID sql.NullInt64
+		var createdAt string
+		if err := rows.Scan(&m.ID, &m.RuleID, &m.SavingID, &transactionID, &m.Amount, &createdAt, &m.Undone, &m.RuleType, &m.SavingName); err != nil {
+			return nil, fmt.Errorf("scan auto move: %w", err)
+		}
+		if transactionID.Valid {
+			id := int(transactionID.Int64)
+			m.TransactionID = &id
+		}
+		m.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		m.UserID = userID
+		moves = append(moves, m)
+	}
+	return moves, rows.Err()
+}
+
+func (r *SQLiteRepository) MarkSavingAutoMoveUndone(userID, id int) error {
+	_, err := r.db.Exec(
+		"UPDATE saving_auto_moves SET undone = TRUE WHERE id = ? AND user_id = ?",
+		id, userID,
+	)
+	return err
+}
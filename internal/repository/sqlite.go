@@ -23,6 +23,11 @@ type User struct {
 	CreatedAt            time.Time
 	NotificationsEnabled bool
 	PeriodStartDay       int
+	Timezone             string
+	ReminderMinutes      int
+	ReminderDays         int
+	DeliveryStatus       string
+	DeliveryStatusAt     *time.Time
 }
 
 type Category struct {
@@ -34,25 +39,56 @@ type Category struct {
 }
 
 type Transaction struct {
-	ID            int
-	UserID        int
-	Amount        float64
-	CategoryID    int
-	Date          time.Time
-	CategoryName  string
-	PaymentMethod string
-	Comment       string
+	ID                 int
+	UserID             int
+	Amount             float64
+	CategoryID         int
+	Date               time.Time
+	CategoryName       string
+	PaymentMethod      string
+	Comment            string
+	CreditCardID       *int
+	CreatedBy          int
+	Transfer           bool
+	ParentCategoryID   *int
+	ParentCategoryName string
 }
 
 type Saving struct {
-	ID      int
-	UserID  int
-	Name    string
-	Amount  float64
-	Goal    *float64
-	Comment string
+	ID                 int
+	UserID             int
+	Name               string
+	Amount             float64
+	Goal               *float64
+	Comment            string
+	TargetDate         *time.Time
+	ContributionPeriod string
+	LastReminderAt     *time.Time
+	InterestRate       float64
+	Capitalization     string
+	MaturityDate       *time.Time
+	LastAccrualAt      *time.Time
 }
 
+type SavingContribution struct {
+	ID       int
+	UserID   int
+	SavingID int
+	Amount   float64
+	Source   string
+	Date     time.Time
+}
+
+const (
+	ContributionSourceManual   = "manual"
+	ContributionSourceAuto     = "auto"
+	ContributionSourceInterest = "interest"
+
+	CapitalizationMonthly   = "monthly"
+	CapitalizationQuarterly = "quarterly"
+	CapitalizationMaturity  = "maturity"
+)
+
 type GlobalCategory struct {
 	ID   int
 	Name string
@@ -87,7 +123,12 @@ CREATE TABLE IF NOT EXISTS users (
     last_name TEXT,
     created_at TEXT NOT NULL,
     notifications_enabled BOOLEAN NOT NULL DEFAULT TRUE,
-    period_start_day INTEGER NOT NULL DEFAULT 1
+    period_start_day INTEGER NOT NULL DEFAULT 1,
+    timezone TEXT NOT NULL DEFAULT 'Europe/Moscow',
+    reminder_minutes INTEGER NOT NULL DEFAULT 960,
+    reminder_days INTEGER NOT NULL DEFAULT 127,
+    delivery_status TEXT NOT NULL DEFAULT 'active',
+    delivery_status_at TEXT
 );
 
 CREATE TABLE IF NOT EXISTS global_categories (
@@ -146,6 +187,324 @@ CREATE TABLE IF NOT EXISTS savings (
     UNIQUE(user_id, name)
 );
 
+CREATE TABLE IF NOT EXISTS saving_contributions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    saving_id INTEGER NOT NULL,
+    amount REAL NOT NULL,
+    date TEXT NOT NULL,
+    FOREIGN KEY(user_id) REFERENCES users(id),
+    FOREIGN KEY(saving_id) REFERENCES savings(id)
+);
+
+CREATE TABLE IF NOT EXISTS saving_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    saving_id INTEGER NOT NULL,
+    type TEXT NOT NULL CHECK(type IN ('percent_income', 'round_up', 'schedule')),
+    value REAL NOT NULL,
+    interval_days INTEGER NOT NULL DEFAULT 0,
+    next_run TEXT,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TEXT NOT NULL,
+    FOREIGN KEY(user_id) REFERENCES users(id),
+    FOREIGN KEY(saving_id) REFERENCES savings(id)
+);
+
+CREATE TABLE IF NOT EXISTS saving_auto_moves (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    rule_id INTEGER NOT NULL,
+    saving_id INTEGER NOT NULL,
+    transaction_id INTEGER,
+    amount REAL NOT NULL,
+    created_at TEXT NOT NULL,
+    undone BOOLEAN NOT NULL DEFAULT FALSE,
+    FOREIGN KEY(user_id) REFERENCES users(id),
+    FOREIGN KEY(rule_id) REFERENCES saving_rules(id),
+    FOREIGN KEY(saving_id) REFERENCES savings(id)
+);
+
+CREATE TABLE IF NOT EXISTS debts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    counterparty TEXT NOT NULL,
+    direction TEXT NOT NULL CHECK(direction IN ('lent', 'borrowed')),
+    amount REAL NOT NULL,
+    currency TEXT NOT NULL DEFAULT 'RUB',
+    due_date TEXT,
+    comment TEXT,
+    created_at TEXT NOT NULL,
+    closed_at TEXT,
+    last_reminder_at TEXT,
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS debt_payments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    debt_id INTEGER NOT NULL,
+    amount REAL NOT NULL,
+    date TEXT NOT NULL,
+    transaction_id INTEGER,
+    FOREIGN KEY(user_id) REFERENCES users(id),
+    FOREIGN KEY(debt_id) REFERENCES debts(id),
+    FOREIGN KEY(transaction_id) REFERENCES transactions(id)
+);
+
+CREATE TABLE IF NOT EXISTS loans (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    principal REAL NOT NULL,
+    rate REAL NOT NULL,
+    term_months INTEGER NOT NULL,
+    schedule_type TEXT NOT NULL CHECK(schedule_type IN ('annuity', 'differentiated')),
+    issue_date TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    closed_at TEXT,
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS loan_payments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    loan_id INTEGER NOT NULL,
+    kind TEXT NOT NULL CHECK(kind IN ('scheduled', 'early')),
+    early_mode TEXT,
+    principal REAL NOT NULL,
+    interest REAL NOT NULL DEFAULT 0,
+    date TEXT NOT NULL,
+    transaction_id INTEGER,
+    principal_transaction_id INTEGER,
+    FOREIGN KEY(user_id) REFERENCES users(id),
+    FOREIGN KEY(loan_id) REFERENCES loans(id),
+    FOREIGN KEY(transaction_id) REFERENCES transactions(id),
+    FOREIGN KEY(principal_transaction_id) REFERENCES transactions(id)
+);
+
+CREATE TABLE IF NOT EXISTS credit_cards (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    credit_limit REAL NOT NULL,
+    statement_day INTEGER NOT NULL CHECK(statement_day BETWEEN 1 AND 28),
+    grace_days INTEGER NOT NULL,
+    min_payment_percent REAL NOT NULL DEFAULT 5,
+    min_payment_fixed REAL NOT NULL DEFAULT 0,
+    created_at TEXT NOT NULL,
+    last_reminder_at TEXT,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS credit_card_payments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    card_id INTEGER NOT NULL,
+    amount REAL NOT NULL,
+    date TEXT NOT NULL,
+    FOREIGN KEY(user_id) REFERENCES users(id),
+    FOREIGN KEY(card_id) REFERENCES credit_cards(id)
+);
+
+CREATE TABLE IF NOT EXISTS households (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    owner_user_id INTEGER NOT NULL UNIQUE,
+    invite_code TEXT NOT NULL UNIQUE,
+    created_at TEXT NOT NULL,
+    FOREIGN KEY(owner_user_id) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS household_members (
+    household_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL UNIQUE,
+    role TEXT NOT NULL CHECK(role IN ('owner','member')),
+    report_scope TEXT NOT NULL DEFAULT 'household' CHECK(report_scope IN ('household','personal')),
+    joined_at TEXT NOT NULL,
+    PRIMARY KEY(household_id, user_id),
+    FOREIGN KEY(household_id) REFERENCES households(id),
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS group_chats (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id INTEGER NOT NULL UNIQUE,
+    title TEXT,
+    currency TEXT NOT NULL DEFAULT 'RUB',
+    created_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS group_members (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id INTEGER NOT NULL,
+    telegram_id INTEGER,
+    username TEXT,
+    name TEXT,
+    joined_at TEXT NOT NULL,
+    UNIQUE(group_id, telegram_id),
+    FOREIGN KEY(group_id) REFERENCES group_chats(id)
+);
+
+CREATE TABLE IF NOT EXISTS group_expenses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id INTEGER NOT NULL,
+    payer_id INTEGER NOT NULL,
+    amount REAL NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    split_type TEXT NOT NULL CHECK(split_type IN ('equal','exact','shares')),
+    created_by INTEGER NOT NULL,
+    created_at TEXT NOT NULL,
+    FOREIGN KEY(group_id) REFERENCES group_chats(id),
+    FOREIGN KEY(payer_id) REFERENCES group_members(id)
+);
+
+CREATE TABLE IF NOT EXISTS group_expense_shares (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    expense_id INTEGER NOT NULL,
+    member_id INTEGER NOT NULL,
+    amount REAL NOT NULL,
+    FOREIGN KEY(expense_id) REFERENCES group_expenses(id),
+    FOREIGN KEY(member_id) REFERENCES group_members(id)
+);
+
+CREATE TABLE IF NOT EXISTS group_settlements (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id INTEGER NOT NULL,
+    from_member_id INTEGER NOT NULL,
+    to_member_id INTEGER NOT NULL,
+    amount REAL NOT NULL,
+    created_at TEXT NOT NULL,
+    FOREIGN KEY(group_id) REFERENCES group_chats(id),
+    FOREIGN KEY(from_member_id) REFERENCES group_members(id),
+    FOREIGN KEY(to_member_id) REFERENCES group_members(id)
+);
+
+CREATE TABLE IF NOT EXISTS digest_settings (
+    user_id INTEGER PRIMARY KEY,
+    frequency TEXT NOT NULL DEFAULT 'both' CHECK(frequency IN ('off', 'weekly', 'monthly', 'both')),
+    hour INTEGER NOT NULL DEFAULT 10,
+    attach_pdf BOOLEAN NOT NULL DEFAULT FALSE,
+    last_weekly_sent TEXT,
+    last_monthly_sent TEXT,
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS assets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    value REAL NOT NULL,
+    updated_at TEXT NOT NULL,
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS net_worth_snapshots (
+    user_id INTEGER NOT NULL,
+    month TEXT NOT NULL,
+    assets REAL NOT NULL,
+    liabilities REAL NOT NULL,
+    taken_at TEXT NOT NULL,
+    PRIMARY KEY(user_id, month),
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS anomaly_settings (
+    user_id INTEGER PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS anomaly_alerts (
+    user_id INTEGER NOT NULL,
+    alert_key TEXT NOT NULL,
+    sent_at TEXT NOT NULL,
+    PRIMARY KEY(user_id, alert_key),
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS monthly_aggregates (
+    user_id INTEGER NOT NULL,
+    created_by INTEGER NOT NULL,
+    category_id INTEGER NOT NULL,
+    month TEXT NOT NULL,
+    income REAL NOT NULL DEFAULT 0,
+    expense REAL NOT NULL DEFAULT 0,
+    count INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY(user_id, month, category_id, created_by),
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL,
+    idempotency_key TEXT NOT NULL UNIQUE,
+    payload TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending', 'running', 'done', 'failed')),
+    run_at TEXT NOT NULL,
+    interval_seconds INTEGER NOT NULL DEFAULT 0,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5,
+    locked_until TEXT,
+    last_run_at TEXT,
+    last_result TEXT,
+    last_error TEXT,
+    created_at TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_jobs_due ON jobs(status, run_at);
+
+CREATE TABLE IF NOT EXISTS bills (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    amount REAL NOT NULL,
+    due_day INTEGER NOT NULL CHECK(due_day BETWEEN 1 AND 31),
+    category_id INTEGER NOT NULL,
+    credit_card_id INTEGER,
+    remind_days INTEGER NOT NULL DEFAULT 1,
+    paid_through TEXT,
+    created_at TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    FOREIGN KEY(user_id) REFERENCES users(id),
+    FOREIGN KEY(category_id) REFERENCES categories(id),
+    FOREIGN KEY(credit_card_id) REFERENCES credit_cards(id)
+);
+
+CREATE TABLE IF NOT EXISTS broadcasts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    text TEXT NOT NULL,
+    parse_mode TEXT NOT NULL DEFAULT '',
+    segment TEXT NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'queued' CHECK(status IN ('queued', 'sending', 'done')),
+    created_at TEXT NOT NULL,
+    finished_at TEXT
+);
+
+CREATE TABLE IF NOT EXISTS broadcast_recipients (
+    broadcast_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    telegram_id INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending', 'sent', 'failed', 'blocked')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    sent_at TEXT,
+    PRIMARY KEY(broadcast_id, user_id),
+    FOREIGN KEY(broadcast_id) REFERENCES broadcasts(id),
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+CREATE INDEX IF NOT EXISTS idx_broadcast_recipients_status ON broadcast_recipients(broadcast_id, status);
+
+CREATE TABLE IF NOT EXISTS user_status_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    status TEXT NOT NULL CHECK(status IN ('active', 'blocked', 'deactivated')),
+    reason TEXT,
+    created_at TEXT NOT NULL,
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_status_events_created ON user_status_events(status, created_at);
+
 CREATE TABLE IF NOT EXISTS user_activity (
     user_id INTEGER PRIMARY KEY,
     last_active TEXT,
@@ -178,6 +537,22 @@ CREATE INDEX IF NOT EXISTS idx_transactions_category ON transactions(category_id
 CREATE INDEX IF NOT EXISTS idx_transactions_user ON transactions(user_id);
 CREATE INDEX IF NOT EXISTS idx_categories_user ON categories(user_id);
 CREATE INDEX IF NOT EXISTS idx_savings_user ON savings(user_id);
+CREATE INDEX IF NOT EXISTS idx_saving_contributions_saving ON saving_contributions(saving_id, date);
+CREATE INDEX IF NOT EXISTS idx_saving_rules_user ON saving_rules(user_id);
+CREATE INDEX IF NOT EXISTS idx_saving_auto_moves_saving ON saving_auto_moves(saving_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_debts_user ON debts(user_id);
+CREATE INDEX IF NOT EXISTS idx_debt_payments_debt ON debt_payments(debt_id);
+CREATE INDEX IF NOT EXISTS idx_loans_user ON loans(user_id);
+CREATE INDEX IF NOT EXISTS idx_loan_payments_loan ON loan_payments(loan_id, date);
+CREATE INDEX IF NOT EXISTS idx_credit_cards_user ON credit_cards(user_id);
+CREATE INDEX IF NOT EXISTS idx_credit_card_payments_card ON credit_card_payments(card_id, date);
+CREATE INDEX IF NOT EXISTS idx_household_members_household ON household_members(household_id);
+CREATE INDEX IF NOT EXISTS idx_group_members_group ON group_members(group_id);
+CREATE INDEX IF NOT EXISTS idx_group_expenses_group ON group_expenses(group_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_group_expense_shares_expense ON group_expense_shares(expense_id);
+CREATE INDEX IF NOT EXISTS idx_group_settlements_group ON group_settlements(group_id);
+CREATE INDEX IF NOT EXISTS idx_assets_user ON assets(user_id);
+CREATE INDEX IF NOT EXISTS idx_bills_user ON bills(user_id);
 CREATE INDEX IF NOT EXISTS idx_feedback_user ON user_feedback(user_id);
 
 CREATE TABLE IF NOT EXISTS versions (
@@ -209,23 +584,51 @@ CREATE INDEX IF NOT EXISTS idx_bot_logs_created ON bot_logs(created_at);
 		return fmt.Errorf("ошибка создания схемы базы данных: %w", err)
 	}
 
-	var columnExists int
-	err = db.QueryRow(`
-		SELECT COUNT(*) 
-		FROM pragma_table_info('users') 
-		WHERE name = 'period_start_day'
-	`).Scan(&columnExists)
-	if err != nil {
-		return fmt.Errorf("ошибка проверки столбца period_start_day: %w", err)
+	migrations := []struct {
+		table, column, ddl string
+	}{
+		{"users", "period_start_day", "INTEGER NOT NULL DEFAULT 1"},
+		{"users", "timezone", "TEXT NOT NULL DEFAULT 'Europe/Moscow'"},
+		{"users", "reminder_minutes", "INTEGER NOT NULL DEFAULT 960"},
+		{"users", "reminder_days", "INTEGER NOT NULL DEFAULT 127"},
+		{"users", "delivery_status", "TEXT NOT NULL DEFAULT 'active'"},
+		{"users", "delivery_status_at", "TEXT"},
+		{"savings", "target_date", "TEXT"},
+		{"savings", "contribution_period", "TEXT NOT NULL DEFAULT 'month'"},
+		{"savings", "last_reminder_at", "TEXT"},
+		{"savings", "interest_rate", "REAL NOT NULL DEFAULT 0"},
+		{"savings", "capitalization", "TEXT NOT NULL DEFAULT 'monthly'"},
+		{"savings", "maturity_date", "TEXT"},
+		{"savings", "last_accrual_at", "TEXT"},
+		{"saving_contributions", "source", "TEXT NOT NULL DEFAULT 'manual'"},
+		{"transactions", "credit_card_id", "INTEGER REFERENCES credit_cards(id)"},
+		{"transactions", "created_by", "INTEGER REFERENCES users(id)"},
+		{"transactions", "transfer", "INTEGER NOT NULL DEFAULT 0"},
+		{"loan_payments", "principal_transaction_id", "INTEGER REFERENCES transactions(id)"},
+		{"digest_settings", "last_year_review", "INTEGER NOT NULL DEFAULT 0"},
 	}
-
-	if columnExists == 0 {
-		_, err = db.Exec("ALTER TABLE users ADD COLUMN period_start_day INTEGER NOT NULL DEFAULT 1")
-		if err != nil && !strings.Contains(err.Error(), "duplicate column") {
-			return fmt.Errorf("ошибка добавления столбца period_start_day: %w", err)
+	for _, m := range migrations {
+		if err := addColumnIfMissing(db, m.table, m.column, m.ddl); err != nil {
+			return err
 		}
 	}
 
+	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_transactions_credit_card ON transactions(credit_card_id, date)"); err != nil {
+		return fmt.Errorf("ошибка создания индекса кредитных карт: %w", err)
+	}
+
+	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_transactions_user_date ON transactions(user_id, date, category_id, amount, created_by)"); err != nil {
+		return fmt.Errorf("ошибка создания индекса транзакций: %w", err)
+	}
+
+	if err := markLoanPrincipalTransfers(db); err != nil {
+		return fmt.Errorf("ошибка пометки погашений кредитов: %w", err)
+	}
+
+	if err := ensureMonthlyAggregates(db); err != nil {
+		return fmt.Errorf("ошибка построения месячных итогов: %w", err)
+	}
+
 	var count int
 	err = db.QueryRow("SELECT COUNT(*) FROM global_categories").Scan(&count)
 	if err != nil {
@@ -253,6 +656,25 @@ CREATE INDEX IF NOT EXISTS idx_bot_logs_created ON bot_logs(created_at);
 	return nil
 }
 
+func addColumnIfMissing(db *sql.DB, table, column, ddl string) error {
+	var columnExists int
+	err := db.QueryRow(
+		"SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?",
+		table, column,
+	).Scan(&columnExists)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки столбца %s.%s: %w", table, column, err)
+	}
+
+	if columnExists == 0 {
+		_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, ddl))
+		if err != nil && !strings.Contains(err.Error(), "duplicate column") {
+			return fmt.Errorf("ошибка добавления столбца %s.%s: %w", table, column, err)
+		}
+	}
+	return nil
+}
+
 func NewRepository(db *sql.DB) *SQLiteRepository {
 	return &SQLiteRepository{db: db}
 }
@@ -274,22 +696,37 @@ func (r *SQLiteRepository) GetUserNotificationsEnabled(userID int) (bool, error)
 	return enabled, err
 }
 
-func (r *SQLiteRepository) HasTransactionsToday(userID int) (bool, error) {
-	now := time.Now()
+func (r *SQLiteRepository) HasTransactionsToday(userID int, now time.Time) (bool, error) {
 	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 	end := start.AddDate(0, 0, 1)
 
 	var count int
 	err := r.db.QueryRow(
-		"SELECT COUNT(*) FROM transactions WHERE user_id = ? AND date >= ? AND date < ?",
-		userID, start.Format(time.RFC3339), end.Format(time.RFC3339),
+		"SELECT COUNT(*) FROM transactions WHERE COALESCE(created_by, user_id) = ? AND date >= ? AND date < ?",
+		userID, formatTime(start), formatTime(end),
 	).Scan(&count)
 
 	return count > 0, err
 }
 
+const userColumns = "id, telegram_id, username, first_name, last_name, created_at, notifications_enabled, period_start_day, timezone, reminder_minutes, reminder_days, delivery_status, delivery_status_at"
+
+func scanUser(row rowScanner) (User, error) {
+	var u User
+	var createdAt string
+	var statusAt sql.NullString
+	err := row.Scan(&u.ID, &u.TelegramID, &u.Username, &u.FirstName, &u.LastName, &createdAt, &u.NotificationsEnabled, &u.PeriodStartDay,
+		&u.Timezone, &u.ReminderMinutes, &u.ReminderDays, &u.DeliveryStatus, &statusAt)
+	if err != nil {
+		return u, err
+	}
+	u.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	u.DeliveryStatusAt = parseNullTime(statusAt)
+	return u, nil
+}
+
 func (r *SQLiteRepository) GetAllUsers() ([]User, error) {
-	rows, err := r.db.Query("SELECT id, telegram_id, username, first_name, last_name, created_at, notifications_enabled, period_start_day FROM users")
+	rows, err := r.db.Query("SELECT " + userColumns + " FROM users")
 	if err != nil {
 		return nil, err
 	}
@@ -297,29 +734,30 @@ func (r *SQLiteRepository) GetAllUsers() ([]User, error) {
 
 	var users []User
 	for rows.Next() {
-		var u User
-		var createdAt string
-		err := rows.Scan(&u.ID, &u.TelegramID, &u.Username, &u.FirstName, &u.LastName, &createdAt, &u.NotificationsEnabled, &u.PeriodStartDay)
+		u, err := scanUser(rows)
 		if err != nil {
 			return nil, err
 		}
-		u.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
 		users = append(users, u)
 	}
 	return users, nil
 }
 
-func (r *SQLiteRepository) GetOrCreateUser(telegramID int64, username, firstName, lastName string) (*User, error) {
-	var user User
-	var createdAt string
+func (r *SQLiteRepository) GetUserByID(userID int) (*User, error) {
+	user, err := scanUser(r.db.QueryRow("SELECT "+userColumns+" FROM users WHERE id = ?", userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+	return &user, nil
+}
 
-	err := r.db.QueryRow(
-		"SELECT id, telegram_id, username, first_name, last_name, created_at, notifications_enabled, period_start_day FROM users WHERE telegram_id = ?",
-		telegramID,
-	).Scan(&user.ID, &user.TelegramID, &user.Username, &user.FirstName, &user.LastName, &createdAt, &user.NotificationsEnabled, &user.PeriodStartDay)
+func (r *SQLiteRepository) GetOrCreateUser(telegramID int64, username, firstName, lastName string) (*User, error) {
+	user, err := scanUser(r.db.QueryRow("SELECT "+userColumns+" FROM users WHERE telegram_id = ?", telegramID))
 
 	if err == nil {
-		user.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
 		r.UpdateUserActivity(user.ID, time.Now())
 		logger.Debug("User found", "user_id", user.ID, "telegram_id", user.TelegramID)
 		return &user, nil
@@ -350,6 +788,10 @@ func (r *SQLiteRepository) GetOrCreateUser(telegramID int64, username, firstName
 			CreatedAt:            time.Now(),
 			NotificationsEnabled: true,
 			PeriodStartDay:       1,
+			Timezone:             DefaultTimezone,
+			ReminderMinutes:      DefaultReminderMinutes,
+			ReminderDays:         AllWeekdays,
+			DeliveryStatus:       UserActive,
 		}
 		r.UpdateUserActivity(user.ID, time.Now())
 
@@ -375,12 +817,12 @@ func (r *SQLiteRepository) UpdateUserActivity(userID int, activeTime time.Time)
 	if exists == 0 {
 		_, err = r.db.Exec(
 			"INSERT INTO user_activity (user_id, last_active, join_date) VALUES (?, ?, ?)",
-			userID, activeTime.Format(time.RFC3339), activeTime.Format(time.RFC3339),
+			userID, formatTime(activeTime), formatTime(activeTime),
 		)
 	} else {
 		_, err = r.db.Exec(
 			"UPDATE user_activity SET last_active = ? WHERE user_id = ?",
-			activeTime.Format(time.RFC3339), userID,
+			formatTime(activeTime), userID,
 		)
 	}
 	return err
@@ -415,7 +857,7 @@ func (r *SQLiteRepository) GetActiveUsersCount(since time.Time) (int, error) {
 	var count int
 	err := r.db.QueryRow(
 		"SELECT COUNT(*) FROM user_activity WHERE last_active >= ?",
-		since.Format(time.RFC3339),
+		formatTime(since),
 	).Scan(&count)
 	return count, err
 }
@@ -424,7 +866,7 @@ func (r *SQLiteRepository) GetButtonClicksCount(since time.Time) (map[string]int
 	counts := make(map[string]int)
 	rows, err := r.db.Query(
 		"SELECT button_name, COUNT(*) FROM button_clicks WHERE click_time >= ? GROUP BY button_name",
-		since.Format(time.RFC3339),
+		formatTime(since),
 	)
 	if err != nil {
 		return nil, err
@@ -446,7 +888,7 @@ func (r *SQLiteRepository) GetActiveUsersCountForPeriod(start, end time.Time) (i
 	var count int
 	err := r.db.QueryRow(
 		"SELECT COUNT(*) FROM user_activity WHERE last_active >= ? AND last_active < ?",
-		start.Format(time.RFC3339), end.Format(time.RFC3339),
+		formatTime(start), formatTime(end),
 	).Scan(&count)
 	return count, err
 }
@@ -455,7 +897,7 @@ func (r *SQLiteRepository) GetButtonClicksCountForPeriod(start, end time.Time) (
 	counts := make(map[string]int)
 	rows, err := r.db.Query(
 		"SELECT button_name, COUNT(*) FROM button_clicks WHERE click_time >= ? AND click_time < ? GROUP BY button_name",
-		start.Format(time.RFC3339), end.Format(time.RFC3339),
+		formatTime(start), formatTime(end),
 	)
 	if err != nil {
 		return nil, err
@@ -524,11 +966,11 @@ func (r *SQLiteRepository) GetCategories(userID int) ([]Category, error) {
 func (r *SQLiteRepository) GetCategoryByID(userID, id int) (*Category, error) {
 	var c Category
 	row := r.db.QueryRow(`
-        SELECT id, user_id, name, type 
+        SELECT id, user_id, name, type, parent_id
         FROM categories 
         WHERE id = ? AND user_id = ?`,
 		id, userID)
-	if err := row.Scan(&c.ID, &c.UserID, &c.Name, &c.Type); err != nil {
+	if err := row.Scan(&c.ID, &c.UserID, &c.Name, &c.Type, &c.ParentID); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
@@ -595,6 +1037,26 @@ func (r *SQLiteRepository) RenameCategory(userID, id int, newName string) error
 	return err
 }
 
+func (r *SQLiteRepository) SetCategoryParent(userID, id int, parentID *int) error {
+	_, err := r.db.Exec("UPDATE categories SET parent_id = ? WHERE id = ? AND user_id = ?", parentID, id, userID)
+	if err != nil {
+		return fmt.Errorf("set category parent: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) CountSubcategories(userID, id int) (int, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM categories WHERE user_id = ? AND parent_id = ?", userID, id).Scan(&count)
+	return count, err
+}
+
+func (r *SQLiteRepository) CategoryHasTransactions(userID, id int) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM transactions WHERE user_id = ? AND category_id = ?)", userID, id).Scan(&exists)
+	return exists, err
+}
+
 func (r *SQLiteRepository) DeleteCategory(userID, id int) error {
 	var globalID int
 	err := r.db.QueryRow(`
@@ -623,16 +1085,35 @@ func (r *SQLiteRepository) AddTransaction(userID int, t Transaction) (int, error
 		return 0, err
 	}
 
-	res, err := r.db.Exec(
-		"INSERT INTO transactions(user_id, amount, category_id, date, payment_method, comment) VALUES(?, ?, ?, ?, ?, ?)",
-		userID, t.Amount, t.CategoryID, t.Date.Format(time.RFC3339), t.PaymentMethod, t.Comment,
+	createdBy := t.CreatedBy
+	if createdBy == 0 {
+		createdBy = userID
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	date := formatTime(t.Date)
+	res, err := tx.Exec(
+		"INSERT INTO transactions(user_id, amount, category_id, date, payment_method, comment, credit_card_id, created_by, transfer) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		userID, t.Amount, t.CategoryID, date, t.PaymentMethod, t.Comment, t.CreditCardID, createdBy, t.Transfer,
 	)
 	if err != nil {
 		logger.Error("Failed to add transaction", "user_id", userID, "error", err)
 		return 0, fmt.Errorf("insert trans: %w", err)
 	}
 	id, _ := res.LastInsertId()
-	r.UpdateUserActivity(userID, time.Now())
+
+	if err := applyAggregate(tx, aggregateRow{userID, createdBy, t.CategoryID, date, t.Amount, t.Transfer}, 1); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit trans: %w", err)
+	}
+	r.UpdateUserActivity(createdBy, time.Now())
 
 	logger.Info("Transaction added",
 		"user_id", userID,
@@ -643,33 +1124,110 @@ func (r *SQLiteRepository) AddTransaction(userID int, t Transaction) (int, error
 	return int(id), nil
 }
 
+const transactionColumns = `
+        SELECT t.id, t.amount, t.category_id, t.date, t.payment_method, t.comment, COALESCE(t.created_by, t.user_id), t.transfer,
+               COALESCE(c.name, ''), c.parent_id, COALESCE(p.name, '')
+        FROM transactions t` + categoryJoins
+
 func (r *SQLiteRepository) GetTransactionsByPeriod(userID int, start, end time.Time) ([]Transaction, error) {
-	rows, err := r.db.Query(
-		"SELECT id, amount, category_id, date, payment_method, comment FROM transactions WHERE user_id = ? AND date >= ? AND date < ? ORDER BY date DESC",
-		userID, start.Format(time.RFC3339), end.Format(time.RFC3339),
+	rows, err := r.db.Query(transactionColumns+`
+        WHERE t.user_id = ? AND t.date >= ? AND t.date < ?
+        ORDER BY t.date DESC`,
+		userID, formatTime(start), formatTime(end),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("query trans: %w", err)
 	}
+	return scanTransactions(rows, userID)
+}
+
+func (r *SQLiteRepository) GetExpenses(userID, createdBy int, start, end time.Time, limit int) ([]Transaction, error) {
+	rows, err := r.db.Query(transactionColumns+sumFilter+`
+          AND t.amount < 0
+        ORDER BY t.amount, t.date
+        LIMIT ?`,
+		userID, formatTime(start), formatTime(end), createdBy, createdBy, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query expenses: %w", err)
+	}
+	return scanTransactions(rows, userID)
+}
+
+func scanTransactions(rows *sql.Rows, userID int) ([]Transaction, error) {
 	defer rows.Close()
 
 	var res []Transaction
 	for rows.Next() {
 		var t Transaction
 		var ds string
-		if err := rows.Scan(&t.ID, &t.Amount, &t.CategoryID, &ds, &t.PaymentMethod, &t.Comment); err != nil {
+		var parentID sql.NullInt64
+		if err := rows.Scan(&t.ID, &t.Amount, &t.CategoryID, &ds, &t.PaymentMethod, &t.Comment, &t.CreatedBy, &t.Transfer,
+			&t.CategoryName, &parentID, &t.ParentCategoryName); err != nil {
 			return nil, fmt.Errorf("scan trans: %w", err)
 		}
 		t.Date, _ = time.Parse(time.RFC3339, ds)
 		t.UserID = userID
+		if parentID.Valid {
+			id := int(parentID.Int64)
+			t.ParentCategoryID = &id
+		}
 		res = append(res, t)
 	}
-	return res, nil
+	return res, rows.Err()
+}
+
+const savingColumns = "id, name, amount, goal, comment, target_date, contribution_period, last_reminder_at, interest_rate, capitalization, maturity_date, last_accrual_at"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSaving(row rowScanner, userID int) (*Saving, error) {
+	var s Saving
+	var goal sql.NullFloat64
+	var comment, targetDate, lastReminder, maturity, lastAccrual sql.NullString
+
+	if err := row.Scan(&s.ID, &s.Name, &s.Amount, &goal, &comment, &targetDate, &s.ContributionPeriod, &lastReminder,
+		&s.InterestRate, &s.Capitalization, &maturity, &lastAccrual); err != nil {
+		return nil, err
+	}
+
+	if goal.Valid {
+		s.Goal = &goal.Float64
+	}
+	if comment.Valid {
+		s.Comment = comment.String
+	}
+	s.TargetDate = parseNullTime(targetDate)
+	s.LastReminderAt = parseNullTime(lastReminder)
+	s.MaturityDate = parseNullTime(maturity)
+	s.LastAccrualAt = parseNullTime(lastAccrual)
+	s.UserID = userID
+	return &s, nil
+}
+
+func parseNullTime(s sql.NullString) *time.Time {
+	if !s.Valid || s.String == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s.String)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+func formatNullTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return formatTime(*t)
 }
 
 func (r *SQLiteRepository) GetSavings(userID int) ([]Saving, error) {
 	rows, err := r.db.Query(
-		"SELECT id, name, amount, goal, comment FROM savings WHERE user_id = ? ORDER BY name",
+		"SELECT "+savingColumns+" FROM savings WHERE user_id = ? ORDER BY name",
 		userID,
 	)
 	if err != nil {
@@ -679,33 +1237,20 @@ func (r *SQLiteRepository) GetSavings(userID int) ([]Saving, error) {
 
 	var list []Saving
 	for rows.Next() {
-		var s Saving
-		var goal sql.NullFloat64
-		var comment sql.NullString
-		if err := rows.Scan(&s.ID, &s.Name, &s.Amount, &goal, &comment); err != nil {
+		s, err := scanSaving(rows, userID)
+		if err != nil {
 			return nil, fmt.Errorf("scan saving: %w", err)
 		}
-		if goal.Valid {
-			s.Goal = &goal.Float64
-		}
-		if comment.Valid {
-			s.Comment = comment.String
-		}
-		s.UserID = userID
-		list = append(list, s)
+		list = append(list, *s)
 	}
 	return list, nil
 }
 
 func (r *SQLiteRepository) GetSavingByID(userID, id int) (*Saving, error) {
-	var s Saving
-	var goal sql.NullFloat64
-	var comment sql.NullString
-
-	err := r.db.QueryRow(
-		"SELECT id, name, amount, goal, comment FROM savings WHERE id = ? AND user_id = ?",
+	s, err := scanSaving(r.db.QueryRow(
+		"SELECT "+savingColumns+" FROM savings WHERE id = ? AND user_id = ?",
 		id, userID,
-	).Scan(&s.ID, &s.Name, &s.Amount, &goal, &comment)
+	), userID)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -714,15 +1259,7 @@ func (r *SQLiteRepository) GetSavingByID(userID, id int) (*Saving, error) {
 		return nil, fmt.Errorf("ошибка запроса: %w", err)
 	}
 
-	if goal.Valid {
-		s.Goal = &goal.Float64
-	}
-	if comment.Valid {
-		s.Comment = comment.String
-	}
-	s.UserID = userID
-
-	return &s, nil
+	return s, nil
 }
 
 func (r *SQLiteRepository) UpdateSavingAmount(userID, id int, amount float64) error {
@@ -737,14 +1274,87 @@ func (r *SQLiteRepository) UpdateSavingAmount(userID, id int, amount float64) er
 	return err
 }
 
-func (r *SQLiteRepository) CreateSaving(userID int, name string, goal *float64) error {
+func (r *SQLiteRepository) CreateSaving(userID int, name string, goal *float64, targetDate *time.Time) error {
 	_, err := r.db.Exec(
-		"INSERT INTO savings (user_id, name, amount, goal) VALUES (?, ?, 0, ?)",
-		userID, name, goal,
+		"INSERT INTO savings (user_id, name, amount, goal, target_date) VALUES (?, ?, 0, ?, ?)",
+		userID, name, goal, formatNullTime(targetDate),
 	)
 	return err
 }
 
+func (r *SQLiteRepository) UpdateSavingTargetDate(userID, id int, targetDate *time.Time) error {
+	_, err := r.db.Exec(
+		"UPDATE savings SET target_date = ? WHERE id = ? AND user_id = ?",
+		formatNullTime(targetDate), id, userID,
+	)
+	return err
+}
+
+func (r *SQLiteRepository) UpdateSavingContributionPeriod(userID, id int, period string) error {
+	_, err := r.db.Exec(
+		"UPDATE savings SET contribution_period = ? WHERE id = ? AND user_id = ?",
+		period, id, userID,
+	)
+	return err
+}
+
+func (r *SQLiteRepository) MarkSavingReminded(userID, id int, at time.Time) error {
+	_, err := r.db.Exec(
+		"UPDATE savings SET last_reminder_at = ? WHERE id = ? AND user_id = ?",
+		formatTime(at), id, userID,
+	)
+	return err
+}
+
+func (r *SQLiteRepository) AddSavingContribution(userID, savingID int, amount float64, source string, date time.Time) error {
+	_, err := r.db.Exec(
+		"INSERT INTO saving_contributions (user_id, saving_id, amount, source, date) VALUES (?, ?, ?, ?, ?)",
+		userID, savingID, amount, source, formatTime(date),
+	)
+	return err
+}
+
+func (r *SQLiteRepository) UpdateSavingInterest(userID, id int, rate float64, capitalization string, maturity *time.Time) error {
+	_, err := r.db.Exec(
+		"UPDATE savings SET interest_rate = ?, capitalization = ?, maturity_date = ? WHERE id = ? AND user_id = ?",
+		rate, capitalization, formatNullTime(maturity), id, userID,
+	)
+	return err
+}
+
+func (r *SQLiteRepository) UpdateSavingAccrual(userID, id int, amount float64, accruedAt time.Time) error {
+	_, err := r.db.Exec(
+		"UPDATE savings SET amount = ?, last_accrual_at = ? WHERE id = ? AND user_id = ?",
+		amount, formatTime(accruedAt), id, userID,
+	)
+	return err
+}
+
+func (r *SQLiteRepository) GetSavingContributions(userID, savingID int, since time.Time) ([]SavingContribution, error) {
+	rows, err := r.db.Query(
+		"SELECT id, amount, source, date FROM saving_contributions WHERE user_id = ? AND saving_id = ? AND date >= ? ORDER BY date",
+		userID, savingID, formatTime(since),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get saving contributions: %w", err)
+	}
+	defer rows.Close()
+
+	var list []SavingContribution
+	for rows.Next() {
+		var c SavingContribution
+		var ds string
+		if err := rows.Scan(&c.ID, &c.Amount, &c.Source, &ds); err != nil {
+			return nil, fmt.Errorf("scan saving contribution: %w", err)
+		}
+		c.Date, _ = time.Parse(time.RFC3339, ds)
+		c.UserID = userID
+		c.SavingID = savingID
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
 func (s *Saving) Progress() float64 {
 	if s.Goal == nil || *s.Goal == 0 {
 		return 0
@@ -758,11 +1368,76 @@ func (r *SQLiteRepository) ClearUserData(userID int) error {
 		return fmt.Errorf("ошибка удаления транзакций: %w", err)
 	}
 
+	_, err = r.db.Exec("DELETE FROM monthly_aggregates WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления месячных итогов: %w", err)
+	}
+
+	_, err = r.db.Exec("DELETE FROM bills WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления счетов: %w", err)
+	}
+
+	_, err = r.db.Exec("DELETE FROM credit_card_payments WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления платежей по кредиткам: %w", err)
+	}
+
+	_, err = r.db.Exec("DELETE FROM credit_cards WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления кредитных карт: %w", err)
+	}
+
+	_, err = r.db.Exec("DELETE FROM loan_payments WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления платежей по кредитам: %w", err)
+	}
+
+	_, err = r.db.Exec("DELETE FROM loans WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления кредитов: %w", err)
+	}
+
+	_, err = r.db.Exec("DELETE FROM debt_payments WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления платежей по долгам: %w", err)
+	}
+
+	_, err = r.db.Exec("DELETE FROM debts WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления долгов: %w", err)
+	}
+
+	_, err = r.db.Exec("DELETE FROM saving_auto_moves WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления автопополнений: %w", err)
+	}
+
+	_, err = r.db.Exec("DELETE FROM saving_rules WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления правил копилок: %w", err)
+	}
+
+	_, err = r.db.Exec("DELETE FROM saving_contributions WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления истории копилок: %w", err)
+	}
+
 	_, err = r.db.Exec("DELETE FROM savings WHERE user_id = ?", userID)
 	if err != nil {
 		return fmt.Errorf("ошибка удаления копилок: %w", err)
 	}
 
+	_, err = r.db.Exec("DELETE FROM net_worth_snapshots WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления истории капитала: %w", err)
+	}
+
+	_, err = r.db.Exec("DELETE FROM assets WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления имущества: %w", err)
+	}
+
 	_, err = r.db.Exec("DELETE FROM categories WHERE user_id = ?", userID)
 	if err != nil {
 		return fmt.Errorf("ошибка удаления категорий: %w", err)
@@ -773,6 +1448,21 @@ func (r *SQLiteRepository) ClearUserData(userID int) error {
 		return fmt.Errorf("ошибка сброса настроек: %w", err)
 	}
 
+	_, err = r.db.Exec("DELETE FROM digest_settings WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("ошибка сброса настроек дайджеста: %w", err)
+	}
+
+	_, err = r.db.Exec("DELETE FROM anomaly_alerts WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления истории оповещений: %w", err)
+	}
+
+	_, err = r.db.Exec("DELETE FROM anomaly_settings WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("ошибка сброса настроек оповещений: %w", err)
+	}
+
 	return nil
 }
 
@@ -781,9 +1471,9 @@ func (r *SQLiteRepository) GetTransactionByID(userID, id int) (*Transaction, err
 	var ds string
 
 	err := r.db.QueryRow(
-		"SELECT id, amount, category_id, date, comment FROM transactions WHERE id = ? AND user_id = ?",
+		"SELECT id, amount, category_id, date, comment, COALESCE(created_by, user_id) FROM transactions WHERE id = ? AND user_id = ?",
 		id, userID,
-	).Scan(&t.ID, &t.Amount, &t.CategoryID, &ds, &t.Comment)
+	).Scan(&t.ID, &t.Amount, &t.CategoryID, &ds, &t.Comment, &t.CreatedBy)
 
 	if err != nil {
 		return nil, err
@@ -795,11 +1485,31 @@ func (r *SQLiteRepository) GetTransactionByID(userID, id int) (*Transaction, err
 }
 
 func (r *SQLiteRepository) UpdateTransactionAmount(userID, id int, amount float64) error {
-	_, err := r.db.Exec(
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	old, err := getAggregateRow(tx, userID, id)
+	if err != nil || old == nil {
+		return err
+	}
+	if _, err := tx.Exec(
 		"UPDATE transactions SET amount = ? WHERE id = ? AND user_id = ?",
 		amount, id, userID,
-	)
-	return err
+	); err != nil {
+		return err
+	}
+
+	if err := applyAggregate(tx, *old, -1); err != nil {
+		return err
+	}
+	old.amount = amount
+	if err := applyAggregate(tx, *old, 1); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *SQLiteRepository) UpdateTransactionComment(userID, id int, comment string) error {
@@ -811,14 +1521,40 @@ func (r *SQLiteRepository) UpdateTransactionComment(userID, id int, comment stri
 }
 
 func (r *SQLiteRepository) DeleteTransaction(userID, id int) error {
-	_, err := r.db.Exec(
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	old, err := getAggregateRow(tx, userID, id)
+	if err != nil || old == nil {
+		return err
+	}
+	if _, err := tx.Exec(
 		"DELETE FROM transactions WHERE id = ? AND user_id = ?",
 		id, userID,
-	)
-	return err
+	); err != nil {
+		return err
+	}
+
+	if err := applyAggregate(tx, *old, -1); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *SQLiteRepository) DeleteSaving(userID, id int) error {
+	for _, table := range []string{"saving_auto_moves", "saving_rules", "saving_contributions"} {
+		_, err := r.db.Exec(
+			"DELETE FROM "+table+" WHERE saving_id = ? AND user_id = ?",
+			id, userID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
 	_, err := r.db.Exec(
 		"DELETE FROM savings WHERE id = ? AND user_id = ?",
 		id, userID,
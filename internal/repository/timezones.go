@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	_ "time/tzdata"
+)
+
+const (
+	DefaultTimezone        = "Europe/Moscow"
+	DefaultReminderMinutes = 16 * 60
+	AllWeekdays            = 1<<7 - 1
+)
+
+func (u User) Location() *time.Location {
+	return loadLocation(u.Timezone)
+}
+
+func (u User) RemindsOn(day time.Weekday) bool {
+	return u.ReminderDays&WeekdayBit(day) != 0
+}
+
+func WeekdayBit(day time.Weekday) int {
+	return 1 << ((int(day) + 6) % 7)
+}
+
+func loadLocation(name string) *time.Location {
+	if name == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+func formatTime(t time.Time) string {
+	return t.In(time.Local).Format(time.RFC3339)
+}
+
+func userLocation(q execer, userID int) (*time.Location, error) {
+	var name string
+	err := q.QueryRow("SELECT timezone FROM users WHERE id = ?", userID).Scan(&name)
+	if err == sql.ErrNoRows {
+		return time.Local, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get user timezone: %w", err)
+	}
+	return loadLocation(name), nil
+}
+
+func (r *SQLiteRepository) UpdateUserTimezone(userID int, timezone string) error {
+	if _, err := r.db.Exec("UPDATE users SET timezone = ? WHERE id = ?", timezone, userID); err != nil {
+		return fmt.Errorf("update user timezone: %w", err)
+	}
+	return rebuildMonthlyAggregates(r.db, userID)
+}
+
+func (r *SQLiteRepository) UpdateUserReminderTime(userID, minutes int) error {
+	_, err := r.db.Exec("UPDATE users SET reminder_minutes = ? WHERE id = ?", minutes, userID)
+	return err
+}
+
+func (r *SQLiteRepository) UpdateUserReminderDays(userID, days int) error {
+	_, err := r.db.Exec("UPDATE users SET reminder_days = ? WHERE id = ?", days, userID)
+	return err
+}
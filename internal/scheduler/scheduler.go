@@ -0,0 +1,156 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/logger"
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+)
+
+const (
+	DefaultMaxAttempts = 5
+
+	pollInterval = 5 * time.Second
+	jobLease     = 5 * time.Minute
+	retryBase    = 30 * time.Second
+	retryMax     = time.Hour
+	jobRetention = 30 * 24 * time.Hour
+)
+
+type Handler func(job repository.Job) (string, error)
+
+type Scheduler struct {
+	repo     *repository.SQLiteRepository
+	handlers map[string]Handler
+}
+
+func New(repo *repository.SQLiteRepository) *Scheduler {
+	s := &Scheduler{
+		repo:     repo,
+		handlers: make(map[string]Handler),
+	}
+	s.Register("jobs_cleanup", s.cleanup)
+	return s
+}
+
+func (s *Scheduler) Register(kind string, h Handler) {
+	s.handlers[kind] = h
+}
+
+func (s *Scheduler) Every(kind string, interval time.Duration) error {
+	return s.repo.ScheduleRecurringJob(kind, interval, time.Now())
+}
+
+func (s *Scheduler) Enqueue(kind, key, payload string, runAt time.Time) (bool, error) {
+	return s.repo.EnqueueJob(repository.Job{
+		Kind:        kind,
+		Key:         kind + ":" + key,
+		Payload:     payload,
+		RunAt:       runAt,
+		MaxAttempts: DefaultMaxAttempts,
+	})
+}
+
+func (s *Scheduler) Start() {
+	if err := s.Every("jobs_cleanup", 24*time.Hour); err != nil {
+		logger.Error("Failed to schedule jobs cleanup", "error", err)
+	}
+	if counts, err := s.repo.CountJobsByStatus(); err == nil {
+		logger.Info("Job scheduler started",
+			"pending", counts[repository.JobPending],
+			"running", counts[repository.JobRunning],
+			"failed", counts[repository.JobFailed])
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.RunDue(time.Now())
+		<-ticker.C
+	}
+}
+
+func (s *Scheduler) RunDue(now time.Time) int {
+	ran := 0
+	for {
+		job, err := s.repo.ClaimDueJob(now, jobLease)
+		if err != nil {
+			logger.Error("Failed to claim job", "error", err)
+			return ran
+		}
+		if job == nil {
+			return ran
+		}
+		s.run(job)
+		ran++
+	}
+}
+
+func (s *Scheduler) run(job *repository.Job) {
+	handler, ok := s.handlers[job.Kind]
+	if !ok {
+		logger.Error("No handler for job", "job_id", job.ID, "kind", job.Kind)
+		if err := s.repo.FinishJob(job.ID, repository.JobFailed, "", "no handler for "+job.Kind, nil, false); err != nil {
+			logger.Error("Failed to finish job", "job_id", job.ID, "error", err)
+		}
+		return
+	}
+
+	result, err := safeRun(handler, *job)
+	now := time.Now()
+
+	status, lastError, reset := repository.JobDone, "", false
+	var next *time.Time
+	switch {
+	case err == nil && job.Interval > 0:
+		status, reset = repository.JobPending, true
+		next = nextInterval(job.RunAt, job.Interval, now)
+	case err != nil && (job.MaxAttempts == 0 || job.Attempts < job.MaxAttempts):
+		status, lastError = repository.JobPending, err.Error()
+		retryAt := now.Add(backoff(job.Attempts))
+		next = &retryAt
+		logger.Warn("Job failed, will retry", "job_id", job.ID, "kind", job.Kind, "attempt", job.Attempts, "retry_at", retryAt, "error", err)
+	case err != nil:
+		status, lastError = repository.JobFailed, err.Error()
+		logger.Error("Job failed permanently", "job_id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "error", err)
+	}
+
+	if err := s.repo.FinishJob(job.ID, status, result, lastError, next, reset); err != nil {
+		logger.Error("Failed to finish job", "job_id", job.ID, "error", err)
+	}
+}
+
+func safeRun(handler Handler, job repository.Job) (result string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(job)
+}
+
+func nextInterval(last time.Time, interval time.Duration, now time.Time) *time.Time {
+	next := last.Add(interval)
+	if !next.After(now) {
+		next = now.Add(interval - now.Sub(last)%interval)
+	}
+	return &next
+}
+
+func backoff(attempt int) time.Duration {
+	delay := retryBase
+	for i := 1; i < attempt && delay < retryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, retryMax)
+}
+
+func (s *Scheduler) cleanup(job repository.Job) (string, error) {
+	n, err := s.repo.DeleteFinishedJobs(time.Now().Add(-jobRetention))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("deleted %d jobs", n), nil
+}
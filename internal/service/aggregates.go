@@ -0,0 +1,78 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+)
+
+func (s *FinanceService) scopeCreator() int {
+	if s.PersonalScope() {
+		return s.actorID
+	}
+	return 0
+}
+
+func (s *FinanceService) GetCategorySums(start, end time.Time) ([]repository.CategorySum, error) {
+	sums, err := s.repo.GetCategorySums(s.userID, s.scopeCreator(), start, end)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить суммы по категориям: %v", err)
+	}
+	for i := range sums {
+		if sums[i].CategoryName == "" {
+			sums[i].CategoryName = "Неизвестно"
+		}
+	}
+	return sums, nil
+}
+
+func (s *FinanceService) GetDailySums(start, end time.Time) ([]repository.PeriodSum, error) {
+	sums, err := s.repo.GetDailySums(s.userID, s.scopeCreator(), start, end)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить суммы по дням: %v", err)
+	}
+	return sums, nil
+}
+
+func (s *FinanceService) GetExpenses(start, end time.Time, limit int) ([]repository.Transaction, error) {
+	expenses, err := s.repo.GetExpenses(s.userID, s.scopeCreator(), start, end, limit)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить расходы: %v", err)
+	}
+	for i := range expenses {
+		if expenses[i].CategoryName == "" {
+			expenses[i].CategoryName = "Неизвестно"
+		}
+	}
+	return expenses, nil
+}
+
+func RootSumName(c repository.CategorySum) string {
+	if c.ParentCategoryName != "" {
+		return c.ParentCategoryName
+	}
+	return c.CategoryName
+}
+
+func rootSumID(c repository.CategorySum) int {
+	if c.ParentCategoryID != nil {
+		return *c.ParentCategoryID
+	}
+	return c.CategoryID
+}
+
+func RootCategoryTotals(sums []repository.CategorySum) (map[string]float64, map[string]float64) {
+	income := make(map[string]float64)
+	expense := make(map[string]float64)
+	for _, c := range sums {
+		name := RootSumName(c)
+		if c.Income > 0 {
+			income[name] += c.Income
+		}
+		if c.Expense > 0 {
+			expense[name] += c.Expense
+		}
+	}
+	return income, expense
+}
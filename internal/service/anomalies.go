@@ -0,0 +1,250 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+)
+
+const (
+	AnomalyTransaction = "transaction"
+	AnomalySpike       = "spike"
+
+	anomalyScoreThreshold = 3.5
+	anomalyMinRatio       = 2.0
+	anomalyHistoryWeeks   = 26
+	anomalyMinSamples     = 5
+	anomalySpikeWindows   = 8
+	anomalyMinWindows     = 3
+)
+
+type Anomaly struct {
+	Kind        string
+	CategoryID  int
+	Category    string
+	Amount      float64
+	Baseline    float64
+	Transaction *repository.Transaction
+}
+
+func (a Anomaly) Ratio() float64 {
+	if a.Baseline == 0 {
+		return 0
+	}
+	return a.Amount / a.Baseline
+}
+
+type spendingBaseline struct {
+	median  float64
+	mad     float64
+	samples int
+}
+
+func newSpendingBaseline(values []float64, samples int) spendingBaseline {
+	med := median(values)
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - med)
+	}
+	return spendingBaseline{median: med, mad: median(deviations), samples: samples}
+}
+
+func (b spendingBaseline) isOutlier(value float64, minSamples int) bool {
+	if b.samples < minSamples || b.median <= 0 || value < b.median*anomalyMinRatio {
+		return false
+	}
+	if b.mad == 0 {
+		return true
+	}
+	return 0.6745*(value-b.median)/b.mad > anomalyScoreThreshold
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+func rootCategoryID(t repository.Transaction) int {
+	if t.ParentCategoryID != nil {
+		return *t.ParentCategoryID
+	}
+	return t.CategoryID
+}
+
+func (s *FinanceService) GetAnomalyAlertsEnabled() (bool, error) {
+	return s.repo.GetAnomalyAlertsEnabled(s.actorID)
+}
+
+func (s *FinanceService) ToggleAnomalyAlerts() (bool, error) {
+	enabled, err := s.GetAnomalyAlertsEnabled()
+	if err != nil {
+		return false, err
+	}
+	if err := s.repo.SetAnomalyAlertsEnabled(s.actorID, !enabled); err != nil {
+		return false, err
+	}
+	return !enabled, nil
+}
+
+func (s *FinanceService) MarkAnomalyAlerted(a Anomaly, periodStart time.Time) (bool, error) {
+	key := fmt.Sprintf("%s:%d:%s", a.Kind, a.CategoryID, periodStart.Format("20060102"))
+	if a.Transaction != nil {
+		key = fmt.Sprintf("%s:%d", a.Kind, a.Transaction.ID)
+	}
+	return s.repo.MarkAnomalyAlerted(s.actorID, key, time.Now())
+}
+
+func (s *FinanceService) DetectAnomalies(start, end time.Time) ([]Anomaly, error) {
+	outliers, err := s.detectOutliers(start, end)
+	if err != nil {
+		return nil, err
+	}
+	spikes, err := s.DetectSpikes(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return append(spikes, outliers...), nil
+}
+
+func (s *FinanceService) detectOutliers(start, end time.Time) ([]Anomaly, error) {
+	expenses, err := s.GetExpenses(start.AddDate(0, 0, -7*anomalyHistoryWeeks), end, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make(map[int][]float64)
+	var current []repository.Transaction
+	for _, t := range expenses {
+		if t.Date.Before(start) {
+			history[rootCategoryID(t)] = append(history[rootCategoryID(t)], -t.Amount)
+		} else {
+			current = append(current, t)
+		}
+	}
+
+	baselines := make(map[int]spendingBaseline)
+	var anomalies []Anomaly
+	for i := range current {
+		t := current[i]
+		id := rootCategoryID(t)
+		baseline, ok := baselines[id]
+		if !ok {
+			baseline = newSpendingBaseline(history[id], len(history[id]))
+			baselines[id] = baseline
+		}
+		if baseline.isOutlier(-t.Amount, anomalyMinSamples) {
+			anomalies = append(anomalies, Anomaly{
+				Kind:        AnomalyTransaction,
+				CategoryID:  id,
+				Category:    RootCategoryName(t),
+				Amount:      -t.Amount,
+				Baseline:    baseline.median,
+				Transaction: &t,
+			})
+		}
+	}
+
+	sort.SliceStable(anomalies, func(i, j int) bool { return anomalies[i].Ratio() > anomalies[j].Ratio() })
+	return anomalies, nil
+}
+
+func (s *FinanceService) DetectSpikes(start, end time.Time) ([]Anomaly, error) {
+	window := end.Sub(start)
+	if window <= 0 {
+		return nil, nil
+	}
+	sums, err := s.GetCategorySums(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[int]float64)
+	names := make(map[int]string)
+	for _, c := range sums {
+		if c.Expense == 0 {
+			continue
+		}
+		id := rootSumID(c)
+		current[id] += c.Expense
+		names[id] = RootSumName(c)
+	}
+
+	totals := make(map[int][]float64)
+	for i := 0; i < anomalySpikeWindows; i++ {
+		windowEnd := start.Add(-window * time.Duration(i))
+		sums, err := s.GetCategorySums(windowEnd.Add(-window), windowEnd)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range sums {
+			if c.Expense == 0 {
+				continue
+			}
+			id := rootSumID(c)
+			if _, ok := totals[id]; !ok {
+				totals[id] = make([]float64, anomalySpikeWindows)
+			}
+			totals[id][i] += c.Expense
+		}
+	}
+
+	var anomalies []Anomaly
+	for id, amount := range current {
+		nonZero := 0
+		for _, v := range totals[id] {
+			if v > 0 {
+				nonZero++
+			}
+		}
+		baseline := newSpendingBaseline(totals[id], nonZero)
+		if baseline.isOutlier(amount, anomalyMinWindows) {
+			anomalies = append(anomalies, Anomaly{
+				Kind:       AnomalySpike,
+				CategoryID: id,
+				Category:   names[id],
+				Amount:     amount,
+				Baseline:   baseline.median,
+			})
+		}
+	}
+
+	sort.SliceStable(anomalies, func(i, j int) bool {
+		if anomalies[i].Ratio() != anomalies[j].Ratio() {
+			return anomalies[i].Ratio() > anomalies[j].Ratio()
+		}
+		return anomalies[i].Category < anomalies[j].Category
+	})
+	return anomalies, nil
+}
+
+func (s *FinanceService) CheckTransactionAnomaly(id int) (*Anomaly, error) {
+	t, err := s.GetTransactionByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if t.Amount >= 0 {
+		return nil, nil
+	}
+
+	anomalies, err := s.detectOutliers(t.Date, t.Date.Add(time.Second))
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range anomalies {
+		if a.Transaction.ID == id {
+			return &a, nil
+		}
+	}
+	return nil, nil
+}
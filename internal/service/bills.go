@@ -0,0 +1,146 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+)
+
+var BillRemindDays = []int{0, 1, 3, 7}
+
+type BillStatus struct {
+	DueDate  time.Time
+	DaysLeft int
+	Overdue  bool
+}
+
+func (s *FinanceService) CreateBill(name string, amount float64, dueDay, categoryID, cardID, remindDays int) (int, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("название не может быть пустым")
+	}
+	if amount <= 0 {
+		return 0, fmt.Errorf("сумма должна быть положительной")
+	}
+	if dueDay < 1 || dueDay > 31 {
+		return 0, fmt.Errorf("день оплаты должен быть от 1 до 31")
+	}
+	if remindDays < 0 || remindDays > 31 {
+		return 0, fmt.Errorf("неверный срок напоминания")
+	}
+	if _, err := s.GetCategoryWithTypeCheck(categoryID, "expense"); err != nil {
+		return 0, err
+	}
+
+	bill := repository.Bill{
+		Name:       name,
+		Amount:     amount,
+		DueDay:     dueDay,
+		CategoryID: categoryID,
+		RemindDays: remindDays,
+	}
+	if cardID > 0 {
+		if _, err := s.GetCreditCardByID(cardID); err != nil {
+			return 0, err
+		}
+		bill.CreditCardID = &cardID
+	}
+
+	return s.repo.CreateBill(s.userID, bill)
+}
+
+func (s *FinanceService) GetBills() ([]repository.Bill, error) {
+	return s.repo.GetBills(s.userID)
+}
+
+func (s *FinanceService) GetBillByID(id int) (*repository.Bill, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("неверный ID счёта")
+	}
+
+	bill, err := s.repo.GetBillByID(s.userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка базы данных: %v", err)
+	}
+	if bill == nil {
+		return nil, fmt.Errorf("счёт не найден")
+	}
+	return bill, nil
+}
+
+func (s *FinanceService) DeleteBill(id int) error {
+	if id <= 0 {
+		return fmt.Errorf("неверный ID счёта")
+	}
+	return s.repo.DeleteBill(s.userID, id)
+}
+
+func (s *FinanceService) SetBillRemindDays(id, days int) error {
+	if _, err := s.GetBillByID(id); err != nil {
+		return err
+	}
+	if days < 0 || days > 31 {
+		return fmt.Errorf("неверный срок напоминания")
+	}
+	return s.repo.UpdateBillRemindDays(s.userID, id, days)
+}
+
+func (s *FinanceService) GetBillStatus(bill *repository.Bill, now time.Time) BillStatus {
+	loc := now.Location()
+	var after time.Time
+	if bill.PaidThrough != nil {
+		after = time.Date(bill.PaidThrough.Year(), bill.PaidThrough.Month(), bill.PaidThrough.Day(), 0, 0, 0, 0, loc)
+	} else {
+		created := bill.CreatedAt.In(loc)
+		after = time.Date(created.Year(), created.Month(), created.Day()-1, 0, 0, 0, 0, loc)
+	}
+
+	due := billDueDate(after.Year(), after.Month(), bill.DueDay, loc)
+	if !due.After(after) {
+		due = billDueDate(after.Year(), after.Month()+1, bill.DueDay, loc)
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	daysLeft := int(math.Round(due.Sub(today).Hours() / 24))
+	return BillStatus{
+		DueDate:  due,
+		DaysLeft: daysLeft,
+		Overdue:  daysLeft < 0,
+	}
+}
+
+func (s *FinanceService) PayBill(id int, due time.Time) (*repository.Bill, int, error) {
+	bill, err := s.GetBillByID(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	status := s.GetBillStatus(bill, s.Now())
+	if !status.DueDate.Equal(due) {
+		return nil, 0, fmt.Errorf("платёж за %s уже отмечен оплаченным", due.Format("02.01.2006"))
+	}
+
+	comment := fmt.Sprintf("Счёт: %s", bill.Name)
+	var transactionID int
+	if bill.CreditCardID != nil {
+		transactionID, err = s.AddCreditCardTransaction(-bill.Amount, bill.CategoryID, *bill.CreditCardID, comment)
+	} else {
+		transactionID, err = s.AddTransaction(-bill.Amount, bill.CategoryID, repository.PaymentMethodCard, comment)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := s.repo.MarkBillPaid(s.userID, id, status.DueDate); err != nil {
+		return nil, 0, fmt.Errorf("не удалось отметить оплату: %v", err)
+	}
+	bill.PaidThrough = &status.DueDate
+	return bill, transactionID, nil
+}
+
+func billDueDate(year int, month time.Month, day int, loc *time.Location) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+	return time.Date(year, month, min(day, last), 0, 0, 0, 0, loc)
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+)
+
+func (s *FinanceService) GetSubcategories(parentID int) ([]repository.Category, error) {
+	categories, err := s.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	var children []repository.Category
+	for _, c := range categories {
+		if c.ParentID != nil && *c.ParentID == parentID {
+			children = append(children, c)
+		}
+	}
+	return children, nil
+}
+
+func (s *FinanceService) CreateSubcategory(parentID int, name string) (int, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("название категории не может быть пустым")
+	}
+
+	parent, err := s.GetCategoryByID(parentID)
+	if err != nil {
+		return 0, err
+	}
+	if parent.ParentID != nil {
+		return 0, fmt.Errorf("подкатегории поддерживают только два уровня")
+	}
+
+	return s.CreateCategory(name, parent.Type, &parent.ID)
+}
+
+func (s *FinanceService) GetParentCandidates(id int) ([]repository.Category, error) {
+	category, err := s.GetCategoryByID(id)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []repository.Category
+	for _, c := range categories {
+		if c.ID != category.ID && c.Type == category.Type && c.ParentID == nil {
+			candidates = append(candidates, c)
+		}
+	}
+	return candidates, nil
+}
+
+func (s *FinanceService) MoveCategory(id int, parentID *int) error {
+	category, err := s.GetCategoryByID(id)
+	if err != nil {
+		return err
+	}
+
+	if parentID != nil {
+		if *parentID == id {
+			return fmt.Errorf("категория не может быть вложена сама в себя")
+		}
+
+		parent, err := s.GetCategoryByID(*parentID)
+		if err != nil {
+			return err
+		}
+		if parent.ParentID != nil {
+			return fmt.Errorf("подкатегории поддерживают только два уровня")
+		}
+		if parent.Type != category.Type {
+			return fmt.Errorf("нельзя вложить категорию другого типа")
+		}
+
+		children, err := s.repo.CountSubcategories(s.userID, id)
+		if err != nil {
+			return err
+		}
+		if children > 0 {
+			return fmt.Errorf("у категории есть свои подкатегории, её нельзя вложить")
+		}
+	}
+
+	return s.repo.SetCategoryParent(s.userID, id, parentID)
+}
+
+func RootCategoryName(t repository.Transaction) string {
+	if t.ParentCategoryName != "" {
+		return t.ParentCategoryName
+	}
+	return t.CategoryName
+}
+
+const NoSubcategory = "без подкатегории"
+
+type SubcategoryBreakdown struct {
+	ParentID int
+	Name     string
+	Type     string
+	Total    float64
+	Items    map[string]float64
+}
+
+func GroupBySubcategory(sums []repository.CategorySum) []SubcategoryBreakdown {
+	groups := make(map[int]*SubcategoryBreakdown)
+	for _, c := range sums {
+		if c.ParentCategoryID == nil {
+			continue
+		}
+		if _, ok := groups[*c.ParentCategoryID]; !ok {
+			typ := "income"
+			if c.Expense > c.Income {
+				typ = "expense"
+			}
+			groups[*c.ParentCategoryID] = &SubcategoryBreakdown{
+				ParentID: *c.ParentCategoryID,
+				Name:     c.ParentCategoryName,
+				Type:     typ,
+				Items:    make(map[string]float64),
+			}
+		}
+	}
+
+	for _, c := range sums {
+		parentID, name := c.CategoryID, NoSubcategory
+		if c.ParentCategoryID != nil {
+			parentID, name = *c.ParentCategoryID, c.CategoryName
+		}
+		g, ok := groups[parentID]
+		if !ok {
+			continue
+		}
+		amount := c.Income + c.Expense
+		g.Total += amount
+		g.Items[name] += amount
+	}
+
+	result := make([]SubcategoryBreakdown, 0, len(groups))
+	for _, g := range groups {
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Total != result[j].Total {
+			return result[i].Total > result[j].Total
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
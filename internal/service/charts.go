@@ -0,0 +1,88 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+type MonthlyTotals struct {
+	Month   time.Time
+	Income  float64
+	Expense float64
+}
+
+type CategoryTrend struct {
+	Name   string
+	Values []float64
+}
+
+func (s *FinanceService) GetMonthlyTotals(start time.Time, months int) ([]MonthlyTotals, error) {
+	start = time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location())
+	sums, err := s.repo.GetMonthlySums(s.userID, s.scopeCreator(), start, start.AddDate(0, months, 0))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить суммы по месяцам: %v", err)
+	}
+
+	totals := make([]MonthlyTotals, months)
+	index := make(map[string]int, months)
+	for i := range totals {
+		totals[i].Month = start.AddDate(0, i, 0)
+		index[totals[i].Month.Format("2006-01")] = i
+	}
+	for _, m := range sums {
+		i, ok := index[m.Period]
+		if !ok {
+			continue
+		}
+		totals[i].Income += m.Income
+		totals[i].Expense += m.Expense
+	}
+	return totals, nil
+}
+
+func (s *FinanceService) GetCategoryTrends(start time.Time, months, limit int) ([]CategoryTrend, error) {
+	start = time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location())
+	sums, err := s.repo.GetMonthlyCategorySums(s.userID, s.scopeCreator(), start, start.AddDate(0, months, 0))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить суммы по категориям: %v", err)
+	}
+
+	index := make(map[string]int, months)
+	for i := 0; i < months; i++ {
+		index[start.AddDate(0, i, 0).Format("2006-01")] = i
+	}
+
+	values := make(map[string][]float64)
+	totals := make(map[string]float64)
+	for _, m := range sums {
+		i, ok := index[m.Month]
+		if !ok || m.Expense == 0 {
+			continue
+		}
+		name := RootSumName(m.CategorySum)
+		if name == "" {
+			name = "Неизвестно"
+		}
+		if _, ok := values[name]; !ok {
+			values[name] = make([]float64, months)
+		}
+		values[name][i] += m.Expense
+		totals[name] += m.Expense
+	}
+
+	trends := make([]CategoryTrend, 0, len(values))
+	for name, v := range values {
+		trends = append(trends, CategoryTrend{Name: name, Values: v})
+	}
+	sort.Slice(trends, func(i, j int) bool {
+		if totals[trends[i].Name] != totals[trends[j].Name] {
+			return totals[trends[i].Name] > totals[trends[j].Name]
+		}
+		return trends[i].Name < trends[j].Name
+	})
+	if len(trends) > limit {
+		trends = trends[:limit]
+	}
+	return trends, nil
+}
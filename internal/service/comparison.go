@@ -0,0 +1,141 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+type CategoryDelta struct {
+	Name     string
+	Type     string
+	Current  float64
+	Previous float64
+}
+
+func (d CategoryDelta) Delta() float64 {
+	return d.Current - d.Previous
+}
+
+func (d CategoryDelta) Percent() (float64, bool) {
+	if d.Previous == 0 {
+		return 0, false
+	}
+	return d.Delta() / d.Previous * 100, true
+}
+
+type PeriodComparison struct {
+	CurrentStart, CurrentEnd   time.Time
+	PreviousStart, PreviousEnd time.Time
+	CurrentIncome              float64
+	PreviousIncome             float64
+	CurrentExpense             float64
+	PreviousExpense            float64
+	Categories                 []CategoryDelta
+}
+
+func PreviousPeriod(start, end time.Time) (time.Time, time.Time) {
+	switch {
+	case end.Equal(start.AddDate(1, 0, 0)):
+		return start.AddDate(-1, 0, 0), start
+	case end.Equal(start.AddDate(0, 1, 0)):
+		return start.AddDate(0, -1, 0), start
+	default:
+		return start.Add(-end.Sub(start)), start
+	}
+}
+
+func (s *FinanceService) ComparePeriods(curStart, curEnd, prevStart, prevEnd time.Time) (*PeriodComparison, error) {
+	current, err := s.GetCategorySums(curStart, curEnd)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.GetCategorySums(prevStart, prevEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	cmp := &PeriodComparison{
+		CurrentStart:  curStart,
+		CurrentEnd:    curEnd,
+		PreviousStart: prevStart,
+		PreviousEnd:   prevEnd,
+	}
+
+	byKey := make(map[string]*CategoryDelta)
+	add := func(name, typ string, amount float64, isCurrent bool) {
+		if amount == 0 {
+			return
+		}
+		if name == "" {
+			name = "Неизвестно"
+		}
+
+		key := typ + "|" + name
+		d, ok := byKey[key]
+		if !ok {
+			d = &CategoryDelta{Name: name, Type: typ}
+			byKey[key] = d
+		}
+
+		switch {
+		case isCurrent && typ == "income":
+			cmp.CurrentIncome += amount
+			d.Current += amount
+		case isCurrent:
+			cmp.CurrentExpense += amount
+			d.Current += amount
+		case typ == "income":
+			cmp.PreviousIncome += amount
+			d.Previous += amount
+		default:
+			cmp.PreviousExpense += amount
+			d.Previous += amount
+		}
+	}
+
+	for _, c := range current {
+		add(RootSumName(c), "income", c.Income, true)
+		add(RootSumName(c), "expense", c.Expense, true)
+	}
+	for _, c := range previous {
+		add(RootSumName(c), "income", c.Income, false)
+		add(RootSumName(c), "expense", c.Expense, false)
+	}
+
+	for _, d := range byKey {
+		cmp.Categories = append(cmp.Categories, *d)
+	}
+	sort.Slice(cmp.Categories, func(i, j int) bool {
+		a, b := cmp.Categories[i], cmp.Categories[j]
+		if a.Type != b.Type {
+			return a.Type == "expense"
+		}
+		if math.Abs(a.Delta()) != math.Abs(b.Delta()) {
+			return math.Abs(a.Delta()) > math.Abs(b.Delta())
+		}
+		return a.Name < b.Name
+	})
+
+	return cmp, nil
+}
+
+func (c *PeriodComparison) Movers(typ string, increases bool, limit int) []CategoryDelta {
+	var result []CategoryDelta
+	for _, d := range c.Categories {
+		if d.Type != typ {
+			continue
+		}
+		if (increases && d.Delta() > 0) || (!increases && d.Delta() < 0) {
+			result = append(result, d)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return math.Abs(result[i].Delta()) > math.Abs(result[j].Delta())
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+)
+
+type CreditCardStatus struct {
+	Debt               float64
+	Available          float64
+	Unbilled           float64
+	StatementDate      time.Time
+	NextStatementDate  time.Time
+	StatementBalance   float64
+	PaidSinceStatement float64
+	GraceAmount        float64
+	MinPayment         float64
+	DueDate            time.Time
+	GraceExpired       bool
+	Statements         []CreditCardStatement
+}
+
+type CreditCardStatement struct {
+	Date    time.Time
+	DueDate time.Time
+	Amount  float64
+	Unpaid  float64
+}
+
+var ledgerStart = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func (s *FinanceService) CreateCreditCard(name string, limit float64, statementDay, graceDays int, minPercent, minFixed float64) (int, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("название не может быть пустым")
+	}
+	if limit <= 0 {
+		return 0, fmt.Errorf("лимит должен быть положительным")
+	}
+	if statementDay < 1 || statementDay > 28 {
+		return 0, fmt.Errorf("день выписки должен быть от 1 до 28")
+	}
+	if graceDays <= 0 || graceDays > 120 {
+		return 0, fmt.Errorf("льготный период должен быть от 1 до 120 дней")
+	}
+	if minPercent < 0 || minPercent > 100 || minFixed < 0 {
+		return 0, fmt.Errorf("неверные условия минимального платежа")
+	}
+
+	return s.repo.CreateCreditCard(s.userID, repository.CreditCard{
+		Name:              name,
+		Limit:             limit,
+		StatementDay:      statementDay,
+		GraceDays:         graceDays,
+		MinPaymentPercent: minPercent,
+		MinPaymentFixed:   minFixed,
+	})
+}
+
+func (s *FinanceService) GetCreditCards() ([]repository.CreditCard, error) {
+	return s.repo.GetCreditCards(s.userID)
+}
+
+func (s *FinanceService) GetCreditCardByID(id int) (*repository.CreditCard, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("неверный ID карты")
+	}
+
+	card, err := s.repo.GetCreditCardByID(s.userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка базы данных: %v", err)
+	}
+	if card == nil {
+		return nil, fmt.Errorf("кредитная карта не найдена")
+	}
+	return card, nil
+}
+
+func (s *FinanceService) DeleteCreditCard(id int) error {
+	if id <= 0 {
+		return fmt.Errorf("неверный ID карты")
+	}
+	return s.repo.DeleteCreditCard(s.userID, id)
+}
+
+func (s *FinanceService) MarkCreditCardReminded(id int, at time.Time) error {
+	return s.repo.MarkCreditCardReminded(s.userID, id, at)
+}
+
+func (s *FinanceService) RepayCreditCard(id int, amount float64) error {
+	if _, err := s.GetCreditCardByID(id); err != nil {
+		return err
+	}
+	if amount <= 0 {
+		return fmt.Errorf("сумма должна быть положительной")
+	}
+
+	return s.repo.AddCreditCardPayment(s.userID, repository.CreditCardPayment{
+		CardID: id,
+		Amount: amount,
+		Date:   time.Now(),
+	})
+}
+
+func (s *FinanceService) GetCreditCardStatus(card *repository.CreditCard, now time.Time) (*CreditCardStatus, error) {
+	statement := lastStatementDate(now, card.StatementDay)
+	future := now.AddDate(100, 0, 0)
+
+	chargedBefore, err := s.repo.GetCreditCardChargesTotal(s.userID, card.ID, ledgerStart, statement)
+	if err != nil {
+		return nil, err
+	}
+	chargedAfter, err := s.repo.GetCreditCardChargesTotal(s.userID, card.ID, statement, future)
+	if err != nil {
+		return nil, err
+	}
+	paidBefore, err := s.repo.GetCreditCardPaymentsTotal(s.userID, card.ID, ledgerStart, statement)
+	if err != nil {
+		return nil, err
+	}
+	paidAfter, err := s.repo.GetCreditCardPaymentsTotal(s.userID, card.ID, statement, future)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &CreditCardStatus{
+		Unbilled:           -chargedAfter,
+		StatementDate:      statement,
+		NextStatementDate:  statement.AddDate(0, 1, 0),
+		StatementBalance:   roundMoney(-chargedBefore - paidBefore),
+		PaidSinceStatement: paidAfter,
+		DueDate:            statement.AddDate(0, 0, card.GraceDays),
+	}
+	status.Debt = roundMoney(status.StatementBalance + status.Unbilled - paidAfter)
+	status.Available = card.Limit - status.Debt
+
+	status.Statements, err = s.openCreditCardStatements(card, statement, -chargedBefore, paidBefore+paidAfter)
+	if err != nil {
+		return nil, err
+	}
+	if len(status.Statements) > 0 {
+		status.DueDate = status.Statements[0].DueDate
+		status.GraceAmount = status.Statements[0].Unpaid
+	}
+
+	if status.StatementBalance > 0 {
+		minimum := math.Max(card.MinPaymentFixed, status.StatementBalance*card.MinPaymentPercent/100)
+		minimum = math.Min(minimum, status.StatementBalance)
+		status.MinPayment = roundMoney(math.Max(0, minimum-paidAfter))
+	}
+
+	status.GraceExpired = status.GraceAmount > 0 && now.After(status.DueDate.AddDate(0, 0, 1))
+	return status, nil
+}
+
+func (s *FinanceService) openCreditCardStatements(card *repository.CreditCard, last time.Time, billed, paid float64) ([]CreditCardStatement, error) {
+	var statements []CreditCardStatement
+	for date := last; billed-paid > 0.005 && date.After(ledgerStart); date = date.AddDate(0, -1, 0) {
+		charged, err := s.repo.GetCreditCardChargesTotal(s.userID, card.ID, date.AddDate(0, -1, 0), date)
+		if err != nil {
+			return nil, err
+		}
+		prev := billed + charged
+		unpaid := roundMoney(billed - paid - math.Max(0, prev-paid))
+		if unpaid > 0 {
+			statements = append([]CreditCardStatement{{
+				Date:    date,
+				DueDate: date.AddDate(0, 0, card.GraceDays),
+				Amount:  roundMoney(-charged),
+				Unpaid:  unpaid,
+			}}, statements...)
+		}
+		billed = prev
+	}
+	return statements, nil
+}
+
+func lastStatementDate(now time.Time, day int) time.Time {
+	statement := time.Date(now.Year(), now.Month(), day, 0, 0, 0, 0, now.Location())
+	if statement.After(now) {
+		statement = statement.AddDate(0, -1, 0)
+	}
+	return statement
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+)
+
+const (
+	debtReturnCategory    = "🤝 Возврат долга"
+	debtRepaymentCategory = "🤝 Погашение долга"
+)
+
+type DebtBalance struct {
+	Counterparty string
+	Currency     string
+	Net          float64
+	Debts        int
+}
+
+func (s *FinanceService) CreateDebt(counterparty, direction string, amount float64, currency string, due *time.Time, comment string) (int, error) {
+	counterparty = strings.TrimSpace(counterparty)
+	if counterparty == "" {
+		return 0, fmt.Errorf("имя не может быть пустым")
+	}
+	if amount <= 0 {
+		return 0, fmt.Errorf("сумма должна быть положительной")
+	}
+	if direction != repository.DebtLent && direction != repository.DebtBorrowed {
+		return 0, fmt.Errorf("неизвестное направление долга: %s", direction)
+	}
+	if currency == "" {
+		currency = "RUB"
+	}
+
+	return s.repo.CreateDebt(s.userID, repository.Debt{
+		Counterparty: counterparty,
+		Direction:    direction,
+		Amount:       amount,
+		Currency:     strings.ToUpper(currency),
+		DueDate:      due,
+		Comment:      comment,
+	})
+}
+
+func (s *FinanceService) GetDebts(includeClosed bool) ([]repository.Debt, error) {
+	return s.repo.GetDebts(s.userID, includeClosed)
+}
+
+func (s *FinanceService) GetDebtByID(id int) (*repository.Debt, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("неверный ID долга")
+	}
+
+	debt, err := s.repo.GetDebtByID(s.userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка базы данных: %v", err)
+	}
+	if debt == nil {
+		return nil, fmt.Errorf("долг не найден")
+	}
+	return debt, nil
+}
+
+func (s *FinanceService) GetDebtPayments(id int) ([]repository.DebtPayment, error) {
+	return s.repo.GetDebtPayments(s.userID, id)
+}
+
+func (s *FinanceService) DeleteDebt(id int) error {
+	if id <= 0 {
+		return fmt.Errorf("неверный ID долга")
+	}
+	return s.repo.DeleteDebt(s.userID, id)
+}
+
+func (s *FinanceService) MarkDebtReminded(id int, at time.Time) error {
+	return s.repo.MarkDebtReminded(s.userID, id, at)
+}
+
+func (s *FinanceService) RepayDebt(id int, amount float64, withTransaction bool) (*repository.Debt, error) {
+	debt, err := s.GetDebtByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if debt.ClosedAt != nil {
+		return nil, fmt.Errorf("долг уже закрыт")
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("сумма должна быть положительной")
+	}
+
+	remaining := debt.Remaining()
+	if amount > remaining+0.005 {
+		return nil, fmt.Errorf("сумма больше остатка долга (%.2f)", remaining)
+	}
+
+	payment := repository.DebtPayment{DebtID: id, Amount: amount, Date: time.Now()}
+
+	if withTransaction {
+		transactionID, err := s.addDebtTransaction(debt, amount)
+		if err != nil {
+			return nil, err
+		}
+		payment.TransactionID = &transactionID
+	}
+
+	if err := s.repo.AddDebtPayment(s.userID, payment); err != nil {
+		return nil, fmt.Errorf("не удалось сохранить погашение: %v", err)
+	}
+
+	debt.Paid += amount
+	if debt.Remaining() < 0.005 {
+		now := time.Now()
+		if err := s.repo.CloseDebt(s.userID, id, now); err != nil {
+			return nil, err
+		}
+		debt.ClosedAt = &now
+	}
+
+	return debt, nil
+}
+
+func (s *FinanceService) addDebtTransaction(debt *repository.Debt, amount float64) (int, error) {
+	name, typ, signed := debtReturnCategory, "income", amount
+	if debt.Direction == repository.DebtBorrowed {
+		name, typ, signed = debtRepaymentCategory, "expense", -amount
+	}
+
+	categoryID, err := s.findOrCreateCategory(name, typ)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.AddTransaction(signed, categoryID, "card", fmt.Sprintf("Долг: %s", debt.Counterparty))
+}
+
+func (s *FinanceService) findOrCreateCategory(name, typ string) (int, error) {
+	categories, err := s.GetCategories()
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range categories {
+		if c.Name == name && c.Type == typ {
+			return c.ID, nil
+		}
+	}
+	return s.CreateCategory(name, typ, nil)
+}
+
+func (s *FinanceService) GetDebtBalances() ([]DebtBalance, error) {
+	debts, err := s.GetDebts(false)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]*DebtBalance)
+	var balances []*DebtBalance
+	for _, d := range debts {
+		key := strings.ToLower(d.Counterparty) + "|" + d.Currency
+		b, ok := index[key]
+		if !ok {
+			b = &DebtBalance{Counterparty: d.Counterparty, Currency: d.Currency}
+			index[key] = b
+			balances = append(balances, b)
+		}
+
+		if d.Direction == repository.DebtLent {
+			b.Net += d.Remaining()
+		} else {
+			b.Net -= d.Remaining()
+		}
+		b.Debts++
+	}
+
+	result := make([]DebtBalance, 0, len(balances))
+	for _, b := range balances {
+		result = append(result, *b)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return math.Abs(result[i].Net) > math.Abs(result[j].Net)
+	})
+	return result, nil
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+)
+
+type InterestAccrual struct {
+	Saving   repository.Saving
+	Interest float64
+}
+
+type DepositProjection struct {
+	Date     time.Time
+	Value    float64
+	Interest float64
+}
+
+func (s *FinanceService) SetSavingInterest(id int, rate float64, capitalization string, maturity *time.Time) error {
+	saving, err := s.GetSavingByID(id)
+	if err != nil {
+		return err
+	}
+	if rate < 0 || rate > 100 {
+		return fmt.Errorf("ставка должна быть от 0 до 100%%")
+	}
+	switch capitalization {
+	case repository.CapitalizationMonthly, repository.CapitalizationQuarterly, repository.CapitalizationMaturity:
+	default:
+		return fmt.Errorf("неизвестный тип капитализации: %s", capitalization)
+	}
+	if capitalization == repository.CapitalizationMaturity && maturity == nil && rate > 0 {
+		return fmt.Errorf("для выплаты в конце срока нужна дата окончания вклада")
+	}
+
+	if err := s.repo.UpdateSavingInterest(s.userID, id, rate, capitalization, maturity); err != nil {
+		return err
+	}
+
+	if saving.LastAccrualAt == nil && rate > 0 {
+		return s.repo.UpdateSavingAccrual(s.userID, id, saving.Amount, time.Now())
+	}
+	return nil
+}
+
+func (s *FinanceService) AccrueInterest(now time.Time) ([]InterestAccrual, error) {
+	savings, err := s.GetSavings()
+	if err != nil {
+		return nil, err
+	}
+
+	var accruals []InterestAccrual
+	for _, saving := range savings {
+		if saving.InterestRate <= 0 || saving.LastAccrualAt == nil {
+			continue
+		}
+
+		amount, anchor := accrueInterest(saving.Amount, saving.InterestRate, saving.Capitalization, *saving.LastAccrualAt, now, saving.MaturityDate)
+		if anchor.Equal(*saving.LastAccrualAt) {
+			continue
+		}
+
+		interest := math.Round((amount-saving.Amount)*100) / 100
+		if err := s.repo.UpdateSavingAccrual(s.userID, saving.ID, saving.Amount+interest, anchor); err != nil {
+			return accruals, err
+		}
+		if interest > 0 {
+			if err := s.repo.AddSavingContribution(s.userID, saving.ID, interest, repository.ContributionSourceInterest, anchor); err != nil {
+				return accruals, err
+			}
+			saving.Amount += interest
+			accruals = append(accruals, InterestAccrual{Saving: saving, Interest: interest})
+		}
+	}
+	return accruals, nil
+}
+
+func (s *FinanceService) ProjectDeposit(saving *repository.Saving, now time.Time) *DepositProjection {
+	if saving.InterestRate <= 0 {
+		return nil
+	}
+
+	until := now.AddDate(1, 0, 0)
+	if saving.MaturityDate != nil {
+		until = *saving.MaturityDate
+	}
+
+	anchor := now
+	if saving.LastAccrualAt != nil {
+		anchor = *saving.LastAccrualAt
+	}
+
+	value, _ := accrueInterest(saving.Amount, saving.InterestRate, saving.Capitalization, anchor, until, saving.MaturityDate)
+
+	return &DepositProjection{
+		Date:     until,
+		Value:    math.Round(value*100) / 100,
+		Interest: math.Round((value-saving.Amount)*100) / 100,
+	}
+}
+
+func accrueInterest(amount, rate float64, capitalization string, anchor, until time.Time, maturity *time.Time) (float64, time.Time) {
+	if capitalization == repository.CapitalizationMaturity {
+		if maturity == nil || until.Before(*maturity) || !anchor.Before(*maturity) {
+			return amount, anchor
+		}
+		return amount + simpleInterest(amount, rate, anchor, *maturity), *maturity
+	}
+
+	months := 1
+	if capitalization == repository.CapitalizationQuarterly {
+		months = 3
+	}
+
+	for {
+		next := anchor.AddDate(0, months, 0)
+		if maturity != nil && next.After(*maturity) {
+			next = *maturity
+		}
+		if next.After(until) || !next.After(anchor) {
+			break
+		}
+		amount += simpleInterest(amount, rate, anchor, next)
+		anchor = next
+	}
+	return amount, anchor
+}
+
+func simpleInterest(amount, rate float64, from, to time.Time) float64 {
+	days := to.Sub(from).Hours() / 24
+	if days <= 0 {
+		return 0
+	}
+	return amount * rate / 100 * days / 365
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+)
+
+type Digest struct {
+	Comparison  *PeriodComparison
+	TopExpenses []CategoryDelta
+	Savings     []repository.Saving
+}
+
+var DigestHours = []int{7, 8, 9, 10, 12, 18, 19, 20, 21}
+
+func (s *FinanceService) GetDigestSettings() (*repository.DigestSettings, error) {
+	return s.repo.GetDigestSettings(s.actorID)
+}
+
+func (s *FinanceService) SetDigestFrequency(frequency string) error {
+	switch frequency {
+	case repository.DigestOff, repository.DigestWeekly, repository.DigestMonthly, repository.DigestBoth:
+	default:
+		return fmt.Errorf("неизвестная частота дайджеста")
+	}
+
+	settings, err := s.GetDigestSettings()
+	if err != nil {
+		return err
+	}
+	settings.Frequency = frequency
+	return s.repo.SaveDigestSettings(*settings)
+}
+
+func (s *FinanceService) SetDigestHour(hour int) error {
+	valid := false
+	for _, h := range DigestHours {
+		valid = valid || h == hour
+	}
+	if !valid {
+		return fmt.Errorf("недопустимое время дайджеста")
+	}
+
+	settings, err := s.GetDigestSettings()
+	if err != nil {
+		return err
+	}
+	settings.Hour = hour
+	return s.repo.SaveDigestSettings(*settings)
+}
+
+func (s *FinanceService) ToggleDigestPDF() (bool, error) {
+	settings, err := s.GetDigestSettings()
+	if err != nil {
+		return false, err
+	}
+	settings.AttachPDF = !settings.AttachPDF
+	return settings.AttachPDF, s.repo.SaveDigestSettings(*settings)
+}
+
+func (s *FinanceService) MarkDigestSent(frequency string, at time.Time) error {
+	return s.repo.MarkDigestSent(s.actorID, frequency, at)
+}
+
+func (s *FinanceService) MarkYearReviewSent(year int) error {
+	return s.repo.MarkYearReviewSent(s.actorID, year)
+}
+
+func (s *FinanceService) BuildDigest(start, end, prevStart, prevEnd time.Time, topLimit int) (*Digest, error) {
+	cmp, err := s.ComparePeriods(start, end, prevStart, prevEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := &Digest{Comparison: cmp}
+	for _, d := range cmp.Categories {
+		if d.Type == "expense" && d.Current > 0 {
+			digest.TopExpenses = append(digest.TopExpenses, d)
+		}
+	}
+	sort.SliceStable(digest.TopExpenses, func(i, j int) bool {
+		return digest.TopExpenses[i].Current > digest.TopExpenses[j].Current
+	})
+	if len(digest.TopExpenses) > topLimit {
+		digest.TopExpenses = digest.TopExpenses[:topLimit]
+	}
+
+	savings, err := s.GetSavings()
+	if err != nil {
+		return nil, err
+	}
+	for _, saving := range savings {
+		if saving.Goal != nil && *saving.Goal > 0 {
+			digest.Savings = append(digest.Savings, saving)
+		}
+	}
+	return digest, nil
+}
+
+func WeeklyDigestPeriod(now time.Time) (time.Time, time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	offset := (int(today.Weekday()) + 6) % 7
+	end := today.AddDate(0, 0, -offset)
+	return end.AddDate(0, 0, -7), end
+}
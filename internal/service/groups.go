@@ -0,0 +1,364 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+)
+
+type GroupService struct {
+	repo  *repository.SQLiteRepository
+	group *repository.GroupChat
+}
+
+type GroupParticipant struct {
+	MemberID int
+	Amount   float64
+	Shares   float64
+}
+
+type GroupBalance struct {
+	Member  repository.GroupMember
+	Balance float64
+}
+
+type GroupTransfer struct {
+	From   repository.GroupMember
+	To     repository.GroupMember
+	Amount float64
+}
+
+func NewGroupService(repo *repository.SQLiteRepository, chatID int64, title string) (*GroupService, error) {
+	group, err := repo.GetOrCreateGroupChat(chatID, title)
+	if err != nil {
+		return nil, err
+	}
+	return &GroupService{repo: repo, group: group}, nil
+}
+
+func (g *GroupService) Group() *repository.GroupChat {
+	return g.group
+}
+
+func (g *GroupService) SetCurrency(currency string) error {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if len(currency) != 3 {
+		return fmt.Errorf("укажите код валюты из трёх букв, например RUB")
+	}
+	if err := g.repo.SetGroupCurrency(g.group.ID, currency); err != nil {
+		return err
+	}
+	g.group.Currency = currency
+	return nil
+}
+
+func (g *GroupService) EnsureMember(telegramID int64, username, name string) (*repository.GroupMember, error) {
+	member, err := g.repo.GetGroupMemberByTelegramID(g.group.ID, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil && username != "" {
+		if member, err = g.repo.GetGroupMemberByUsername(g.group.ID, username); err != nil {
+			return nil, err
+		}
+		if member != nil && member.TelegramID != 0 {
+			member = nil
+		}
+	}
+
+	if member == nil {
+		m := repository.GroupMember{GroupID: g.group.ID, TelegramID: telegramID, Username: username, Name: name}
+		if m.ID, err = g.repo.CreateGroupMember(m); err != nil {
+			return nil, err
+		}
+		return &m, nil
+	}
+
+	if member.TelegramID != telegramID || member.Username != username || member.Name != name {
+		member.TelegramID, member.Username, member.Name = telegramID, username, name
+		if err := g.repo.UpdateGroupMember(*member); err != nil {
+			return nil, err
+		}
+	}
+	return member, nil
+}
+
+func (g *GroupService) MemberByUsername(username string) (*repository.GroupMember, error) {
+	username = strings.TrimPrefix(username, "@")
+	if username == "" {
+		return nil, fmt.Errorf("пустое имя участника")
+	}
+
+	member, err := g.repo.GetGroupMemberByUsername(g.group.ID, username)
+	if err != nil || member != nil {
+		return member, err
+	}
+
+	m := repository.GroupMember{GroupID: g.group.ID, Username: username}
+	if m.ID, err = g.repo.CreateGroupMember(m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (g *GroupService) GetMembers() ([]repository.GroupMember, error) {
+	return g.repo.GetGroupMembers(g.group.ID)
+}
+
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func distributeCents(total int64, weights []float64) []int64 {
+	var sum float64
+	for _, w := range weights {
+		sum += w
+	}
+
+	result := make([]int64, len(weights))
+	var assigned int64
+	for i, w := range weights {
+		result[i] = int64(math.Floor(float64(total) * w / sum))
+		assigned += result[i]
+	}
+	for i := 0; assigned < total; i = (i + 1) % len(result) {
+		result[i]++
+		assigned++
+	}
+	return result
+}
+
+func SplitExpense(amount float64, parts []GroupParticipant) (string, []repository.GroupExpenseShare, error) {
+	if amount <= 0 {
+		return "", nil, fmt.Errorf("сумма должна быть положительной")
+	}
+	if len(parts) == 0 {
+		return "", nil, fmt.Errorf("не указаны участники")
+	}
+
+	splitType := repository.SplitEqual
+	for _, p := range parts {
+		switch {
+		case p.Amount < 0 || p.Shares < 0:
+			return "", nil, fmt.Errorf("доли не могут быть отрицательными")
+		case p.Amount > 0 && splitType == repository.SplitShares, p.Shares > 0 && splitType == repository.SplitExact:
+			return "", nil, fmt.Errorf("нельзя смешивать точные суммы и доли в одной операции")
+		case p.Amount > 0:
+			splitType = repository.SplitExact
+		case p.Shares > 0:
+			splitType = repository.SplitShares
+		}
+	}
+
+	total := toCents(amount)
+	cents := make([]int64, len(parts))
+
+	switch splitType {
+	case repository.SplitExact:
+		var fixed int64
+		var open []int
+		for i, p := range parts {
+			if p.Amount > 0 {
+				cents[i] = toCents(p.Amount)
+				fixed += cents[i]
+			} else {
+				open = append(open, i)
+			}
+		}
+		rest := total - fixed
+		if rest < 0 {
+			return "", nil, fmt.Errorf("сумма долей больше суммы расхода")
+		}
+		if len(open) == 0 && rest != 0 {
+			return "", nil, fmt.Errorf("сумма долей не совпадает с суммой расхода")
+		}
+		if len(open) > 0 {
+			weights := make([]float64, len(open))
+			for i := range weights {
+				weights[i] = 1
+			}
+			for i, c := range distributeCents(rest, weights) {
+				cents[open[i]] = c
+			}
+		}
+
+	default:
+		weights := make([]float64, len(parts))
+		for i, p := range parts {
+			weights[i] = 1
+			if splitType == repository.SplitShares && p.Shares > 0 {
+				weights[i] = p.Shares
+			}
+		}
+		cents = distributeCents(total, weights)
+	}
+
+	shares := make([]repository.GroupExpenseShare, 0, len(parts))
+	for i, p := range parts {
+		shares = append(shares, repository.GroupExpenseShare{MemberID: p.MemberID, Amount: float64(cents[i]) / 100})
+	}
+	return splitType, shares, nil
+}
+
+func (g *GroupService) AddExpense(payerID int, amount float64, description string, parts []GroupParticipant, createdBy int64) (int, []repository.GroupExpenseShare, error) {
+	seen := make(map[int]bool, len(parts))
+	for _, p := range parts {
+		if seen[p.MemberID] {
+			return 0, nil, fmt.Errorf("участник указан дважды")
+		}
+		seen[p.MemberID] = true
+	}
+
+	splitType, shares, err := SplitExpense(amount, parts)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	id, err := g.repo.AddGroupExpense(repository.GroupExpense{
+		GroupID:     g.group.ID,
+		PayerID:     payerID,
+		Amount:      amount,
+		Description: strings.TrimSpace(description),
+		SplitType:   splitType,
+		CreatedBy:   createdBy,
+	}, shares)
+	if err != nil {
+		return 0, nil, err
+	}
+	return id, shares, nil
+}
+
+func (g *GroupService) DeleteExpense(id int, requesterTelegramID int64) (*repository.GroupExpense, error) {
+	expense, err := g.repo.GetGroupExpenseByID(g.group.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	if expense == nil {
+		return nil, fmt.Errorf("расход не найден")
+	}
+	if expense.CreatedBy != requesterTelegramID {
+		return nil, fmt.Errorf("отменить расход может только тот, кто его добавил")
+	}
+	return expense, g.repo.DeleteGroupExpense(g.group.ID, id)
+}
+
+func (g *GroupService) GetExpenses(limit int) ([]repository.GroupExpense, error) {
+	return g.repo.GetGroupExpenses(g.group.ID, limit)
+}
+
+func (g *GroupService) AddSettlement(fromID, toID int, amount float64) error {
+	if fromID == toID {
+		return fmt.Errorf("нельзя перевести самому себе")
+	}
+	if amount <= 0 {
+		return fmt.Errorf("сумма должна быть положительной")
+	}
+	return g.repo.AddGroupSettlement(g.group.ID, fromID, toID, amount)
+}
+
+func (g *GroupService) SettleTransfer(fromID, toID int, amount float64) (float64, error) {
+	balances, err := g.GetBalances()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, t := range MinimalTransfers(balances) {
+		if t.From.ID != fromID || t.To.ID != toID {
+			continue
+		}
+		amount = math.Min(amount, t.Amount)
+		if err := g.AddSettlement(fromID, toID, amount); err != nil {
+			return 0, err
+		}
+		return amount, nil
+	}
+	return 0, fmt.Errorf("этот перевод уже не нужен — балансы изменились")
+}
+
+func (g *GroupService) GetBalances() ([]GroupBalance, error) {
+	members, err := g.GetMembers()
+	if err != nil {
+		return nil, err
+	}
+	net, err := g.repo.GetGroupNetBalances(g.group.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]GroupBalance, 0, len(members))
+	for _, m := range members {
+		balance := float64(toCents(net[m.ID])) / 100
+		if balance == 0 {
+			continue
+		}
+		balances = append(balances, GroupBalance{Member: m, Balance: balance})
+	}
+
+	sort.SliceStable(balances, func(i, j int) bool {
+		return balances[i].Balance > balances[j].Balance
+	})
+	return balances, nil
+}
+
+func MinimalTransfers(balances []GroupBalance) []GroupTransfer {
+	type entry struct {
+		member repository.GroupMember
+		cents  int64
+	}
+
+	var creditors, debtors []entry
+	for _, b := range balances {
+		c := toCents(b.Balance)
+		if c > 0 {
+			creditors = append(creditors, entry{b.Member, c})
+		} else if c < 0 {
+			debtors = append(debtors, entry{b.Member, -c})
+		}
+	}
+
+	var transfers []GroupTransfer
+	for i := 0; i < len(debtors); i++ {
+		for j := range creditors {
+			if debtors[i].cents != creditors[j].cents {
+				continue
+			}
+			transfers = append(transfers, GroupTransfer{
+				From:   debtors[i].member,
+				To:     creditors[j].member,
+				Amount: float64(debtors[i].cents) / 100,
+			})
+			debtors = append(debtors[:i], debtors[i+1:]...)
+			creditors = append(creditors[:j], creditors[j+1:]...)
+			i--
+			break
+		}
+	}
+
+	for len(creditors) > 0 && len(debtors) > 0 {
+		sort.SliceStable(creditors, func(i, j int) bool { return creditors[i].cents > creditors[j].cents })
+		sort.SliceStable(debtors, func(i, j int) bool { return debtors[i].cents > debtors[j].cents })
+
+		amount := creditors[0].cents
+		if debtors[0].cents < amount {
+			amount = debtors[0].cents
+		}
+		transfers = append(transfers, GroupTransfer{
+			From:   debtors[0].member,
+			To:     creditors[0].member,
+			Amount: float64(amount) / 100,
+		})
+
+		creditors[0].cents -= amount
+		debtors[0].cents -= amount
+		if creditors[0].cents == 0 {
+			creditors = creditors[1:]
+		}
+		if debtors[0].cents == 0 {
+			debtors = debtors[1:]
+		}
+	}
+	return transfers
+}
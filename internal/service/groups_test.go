@@ -0,0 +1,208 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+)
+
+func TestSplitExpense(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    float64
+		parts     []GroupParticipant
+		splitType string
+		want      []float64
+		wantErr   bool
+	}{
+		{
+			name:      "equal with remainder cent",
+			amount:    100,
+			parts:     []GroupParticipant{{MemberID: 1}, {MemberID: 2}, {MemberID: 3}},
+			splitType: repository.SplitEqual,
+			want:      []float64{33.34, 33.33, 33.33},
+		},
+		{
+			name:      "equal with two remainder cents",
+			amount:    0.05,
+			parts:     []GroupParticipant{{MemberID: 1}, {MemberID: 2}, {MemberID: 3}},
+			splitType: repository.SplitEqual,
+			want:      []float64{0.02, 0.02, 0.01},
+		},
+		{
+			name:      "shares with remainder cent",
+			amount:    100,
+			parts:     []GroupParticipant{{MemberID: 1, Shares: 1}, {MemberID: 2, Shares: 2}},
+			splitType: repository.SplitShares,
+			want:      []float64{33.34, 66.66},
+		},
+		{
+			name:      "shares default to one",
+			amount:    90,
+			parts:     []GroupParticipant{{MemberID: 1, Shares: 2}, {MemberID: 2}},
+			splitType: repository.SplitShares,
+			want:      []float64{60, 30},
+		},
+		{
+			name:      "exact amounts",
+			amount:    100,
+			parts:     []GroupParticipant{{MemberID: 1, Amount: 70.5}, {MemberID: 2, Amount: 29.5}},
+			splitType: repository.SplitExact,
+			want:      []float64{70.5, 29.5},
+		},
+		{
+			name:      "exact amount plus open shares",
+			amount:    100,
+			parts:     []GroupParticipant{{MemberID: 1, Amount: 33.33}, {MemberID: 2}, {MemberID: 3}},
+			splitType: repository.SplitExact,
+			want:      []float64{33.33, 33.34, 33.33},
+		},
+		{
+			name:    "exact amounts below total",
+			amount:  100,
+			parts:   []GroupParticipant{{MemberID: 1, Amount: 30}, {MemberID: 2, Amount: 30}},
+			wantErr: true,
+		},
+		{
+			name:    "exact amounts above total",
+			amount:  100,
+			parts:   []GroupParticipant{{MemberID: 1, Amount: 80}, {MemberID: 2, Amount: 30}, {MemberID: 3}},
+			wantErr: true,
+		},
+		{
+			name:    "mixed exact and shares",
+			amount:  100,
+			parts:   []GroupParticipant{{MemberID: 1, Amount: 50}, {MemberID: 2, Shares: 2}},
+			wantErr: true,
+		},
+		{
+			name:    "negative share",
+			amount:  100,
+			parts:   []GroupParticipant{{MemberID: 1, Shares: -1}, {MemberID: 2}},
+			wantErr: true,
+		},
+		{
+			name:    "zero amount",
+			amount:  0,
+			parts:   []GroupParticipant{{MemberID: 1}},
+			wantErr: true,
+		},
+		{
+			name:    "no participants",
+			amount:  100,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			splitType, shares, err := SplitExpense(tt.amount, tt.parts)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %s %v", splitType, shares)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if splitType != tt.splitType {
+				t.Errorf("split type = %s, want %s", splitType, tt.splitType)
+			}
+			if len(shares) != len(tt.want) {
+				t.Fatalf("got %d shares, want %d", len(shares), len(tt.want))
+			}
+
+			var total int64
+			for i, s := range shares {
+				if s.MemberID != tt.parts[i].MemberID {
+					t.Errorf("share %d member = %d, want %d", i, s.MemberID, tt.parts[i].MemberID)
+				}
+				if toCents(s.Amount) != toCents(tt.want[i]) {
+					t.Errorf("share %d = %.2f, want %.2f", i, s.Amount, tt.want[i])
+				}
+				total += toCents(s.Amount)
+			}
+			if total != toCents(tt.amount) {
+				t.Errorf("shares sum to %d cents, want %d", total, toCents(tt.amount))
+			}
+		})
+	}
+}
+
+func TestDistributeCents(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int64
+		weights []float64
+		want    []int64
+	}{
+		{"even", 900, []float64{1, 1, 1}, []int64{300, 300, 300}},
+		{"single remainder", 10000, []float64{1, 1, 1}, []int64{3334, 3333, 3333}},
+		{"remainder smaller than parts", 2, []float64{1, 1, 1}, []int64{1, 1, 0}},
+		{"weighted", 1000, []float64{1, 3}, []int64{250, 750}},
+		{"zero", 0, []float64{1, 1}, []int64{0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := distributeCents(tt.total, tt.weights)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMinimalTransfers(t *testing.T) {
+	tests := []struct {
+		name     string
+		balances []float64
+		max      int
+	}{
+		{"nobody owes", nil, 0},
+		{"one debtor", []float64{50, -50}, 1},
+		{"uneven split of 100 over 3", []float64{66.67, -33.34, -33.33}, 2},
+		{"matching pairs settle directly", []float64{50, 30, -30, -50}, 2},
+		{"many to many", []float64{120.5, 40, -60.25, -60.25, -40}, 4},
+		{"sub-cent balance nets to zero", []float64{0.004, -0.004}, 0},
+		{"zero balance member", []float64{25, 0, -25}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			balances := make([]GroupBalance, len(tt.balances))
+			left := make(map[int]int64, len(tt.balances))
+			for i, b := range tt.balances {
+				balances[i] = GroupBalance{Member: repository.GroupMember{ID: i + 1}, Balance: b}
+				left[i+1] = toCents(b)
+			}
+
+			transfers := MinimalTransfers(balances)
+			if len(transfers) > tt.max {
+				t.Errorf("got %d transfers, want at most %d", len(transfers), tt.max)
+			}
+
+			for _, tr := range transfers {
+				if tr.Amount <= 0 {
+					t.Errorf("transfer %d -> %d has non-positive amount %.2f", tr.From.ID, tr.To.ID, tr.Amount)
+				}
+				if tr.From.ID == tr.To.ID {
+					t.Errorf("member %d transfers to themselves", tr.From.ID)
+				}
+				left[tr.From.ID] += toCents(tr.Amount)
+				left[tr.To.ID] -= toCents(tr.Amount)
+			}
+			for id, cents := range left {
+				if cents != 0 {
+					t.Errorf("member %d left with %d cents after transfers", id, cents)
+				}
+			}
+		})
+	}
+}
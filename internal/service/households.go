@@ -0,0 +1,214 @@
+package service
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/logger"
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+)
+
+const inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+type HouseholdMemberTotals struct {
+	Member  repository.HouseholdMember
+	Income  float64
+	Expense float64
+	Count   int
+}
+
+func (s *FinanceService) ActorID() int {
+	return s.actorID
+}
+
+func (s *FinanceService) Household() *repository.Household {
+	return s.household
+}
+
+func (s *FinanceService) IsHouseholdOwner() bool {
+	return s.household != nil && s.member.Role == repository.HouseholdRoleOwner
+}
+
+func (s *FinanceService) IsHouseholdGuest() bool {
+	return s.household != nil && s.member.Role == repository.HouseholdRoleMember
+}
+
+func (s *FinanceService) PersonalScope() bool {
+	return s.household != nil && s.member.ReportScope == repository.ReportScopePersonal
+}
+
+func generateInviteCode() (string, error) {
+	var sb strings.Builder
+	max := big.NewInt(int64(len(inviteCodeAlphabet)))
+	for i := 0; i < 8; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(inviteCodeAlphabet[n.Int64()])
+	}
+	return sb.String(), nil
+}
+
+func (s *FinanceService) CreateHousehold(name string) error {
+	if s.household != nil {
+		return fmt.Errorf("вы уже состоите в семье «%s»", s.household.Name)
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("название не может быть пустым")
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		return fmt.Errorf("не удалось создать код приглашения: %v", err)
+	}
+
+	id, err := s.repo.CreateHousehold(s.actorID, name, code)
+	if err != nil {
+		return err
+	}
+
+	s.household, s.member, err = s.repo.GetUserHousehold(s.actorID)
+	if err != nil {
+		return err
+	}
+	logger.Info("Household created", "household_id", id, "owner_id", s.actorID)
+	return nil
+}
+
+func (s *FinanceService) JoinHousehold(code string) (*repository.Household, error) {
+	if s.household != nil {
+		return nil, fmt.Errorf("вы уже состоите в семье «%s». Сначала выйдите из неё", s.household.Name)
+	}
+
+	h, err := s.repo.GetHouseholdByInviteCode(strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		return nil, err
+	}
+	if h == nil {
+		return nil, fmt.Errorf("код приглашения не найден или устарел")
+	}
+
+	if err := s.repo.AddHouseholdMember(h.ID, s.actorID); err != nil {
+		return nil, err
+	}
+
+	s.household, s.member, err = s.repo.GetUserHousehold(s.actorID)
+	if err != nil {
+		return nil, err
+	}
+	s.userID = h.OwnerUserID
+	logger.Info("Household joined", "household_id", h.ID, "user_id", s.actorID)
+	return h, nil
+}
+
+func (s *FinanceService) LeaveHousehold() error {
+	if !s.IsHouseholdGuest() {
+		return fmt.Errorf("владелец не может выйти из семьи, её можно только распустить")
+	}
+
+	if err := s.repo.RemoveHouseholdMember(s.household.ID, s.actorID); err != nil {
+		return err
+	}
+	s.household, s.member, s.userID = nil, nil, s.actorID
+	return nil
+}
+
+func (s *FinanceService) RemoveHouseholdMember(userID int) error {
+	if !s.IsHouseholdOwner() {
+		return fmt.Errorf("удалять участников может только владелец")
+	}
+	if userID == s.actorID {
+		return fmt.Errorf("нельзя удалить владельца")
+	}
+	return s.repo.RemoveHouseholdMember(s.household.ID, userID)
+}
+
+func (s *FinanceService) DissolveHousehold() error {
+	if !s.IsHouseholdOwner() {
+		return fmt.Errorf("распустить семью может только владелец")
+	}
+
+	if err := s.repo.DeleteHousehold(s.household.ID); err != nil {
+		return err
+	}
+	s.household, s.member = nil, nil
+	return nil
+}
+
+func (s *FinanceService) RegenerateInviteCode() (string, error) {
+	if !s.IsHouseholdOwner() {
+		return "", fmt.Errorf("менять код может только владелец")
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		return "", err
+	}
+	if err := s.repo.UpdateHouseholdInviteCode(s.household.ID, code); err != nil {
+		return "", err
+	}
+	s.household.InviteCode = code
+	return code, nil
+}
+
+func (s *FinanceService) GetHouseholdMembers() ([]repository.HouseholdMember, error) {
+	if s.household == nil {
+		return nil, nil
+	}
+	return s.repo.GetHouseholdMembers(s.household.ID)
+}
+
+func (s *FinanceService) SetReportScope(scope string) error {
+	if s.household == nil {
+		return fmt.Errorf("вы не состоите в семье")
+	}
+	if scope != repository.ReportScopeHousehold && scope != repository.ReportScopePersonal {
+		return fmt.Errorf("неизвестный режим отчётов: %s", scope)
+	}
+
+	if err := s.repo.SetHouseholdReportScope(s.household.ID, s.actorID, scope); err != nil {
+		return err
+	}
+	s.member.ReportScope = scope
+	return nil
+}
+
+func (s *FinanceService) GetHouseholdMemberTotals(start, end time.Time) ([]HouseholdMemberTotals, error) {
+	members, err := s.GetHouseholdMembers()
+	if err != nil || len(members) == 0 {
+		return nil, err
+	}
+
+	sums, err := s.repo.GetMemberSums(s.userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	byUser := make(map[int]*HouseholdMemberTotals, len(members))
+	result := make([]HouseholdMemberTotals, len(members))
+	for i, m := range members {
+		result[i].Member = m
+		byUser[m.UserID] = &result[i]
+	}
+
+	for _, m := range sums {
+		total, ok := byUser[m.UserID]
+		if !ok {
+			continue
+		}
+		total.Income += m.Income
+		total.Expense += m.Expense
+		total.Count += m.Count
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Expense > result[j].Expense
+	})
+	return result, nil
+}
@@ -0,0 +1,397 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+)
+
+const (
+	loanInterestCategory  = "🏦 Проценты по кредиту"
+	loanPrincipalCategory = "🏦 Погашение кредита"
+	maxLoanTermMonths     = 600
+)
+
+type LoanState struct {
+	Balance           float64
+	MonthsLeft        int
+	Payment           float64
+	NextPrincipal     float64
+	NextInterest      float64
+	NextDate          time.Time
+	EndDate           time.Time
+	PaidPrincipal     float64
+	PaidInterest      float64
+	RemainingInterest float64
+	PaymentsMade      int
+	Closed            bool
+}
+
+type LoanScheduleRow struct {
+	Number    int
+	Date      time.Time
+	Payment   float64
+	Principal float64
+	Interest  float64
+	Balance   float64
+}
+
+type loanCalc struct {
+	loan    *repository.Loan
+	balance float64
+	rate    float64
+	months  int
+	payment float64
+	part    float64
+	made    int
+}
+
+func (s *FinanceService) CreateLoan(name string, principal, rate float64, termMonths int, scheduleType string, issueDate time.Time) (int, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("название не может быть пустым")
+	}
+	if principal <= 0 {
+		return 0, fmt.Errorf("сумма кредита должна быть положительной")
+	}
+	if rate < 0 || rate > 100 {
+		return 0, fmt.Errorf("ставка должна быть от 0 до 100")
+	}
+	if termMonths <= 0 || termMonths > maxLoanTermMonths {
+		return 0, fmt.Errorf("срок должен быть от 1 до %d месяцев", maxLoanTermMonths)
+	}
+	if scheduleType != repository.LoanAnnuity && scheduleType != repository.LoanDifferentiated {
+		return 0, fmt.Errorf("неизвестный тип графика: %s", scheduleType)
+	}
+
+	return s.repo.CreateLoan(s.userID, repository.Loan{
+		Name:         name,
+		Principal:    principal,
+		Rate:         rate,
+		TermMonths:   termMonths,
+		ScheduleType: scheduleType,
+		IssueDate:    issueDate,
+	})
+}
+
+func (s *FinanceService) GetLoans() ([]repository.Loan, error) {
+	return s.repo.GetLoans(s.userID, false)
+}
+
+func (s *FinanceService) GetLoanByID(id int) (*repository.Loan, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("неверный ID кредита")
+	}
+
+	loan, err := s.repo.GetLoanByID(s.userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка базы данных: %v", err)
+	}
+	if loan == nil {
+		return nil, fmt.Errorf("кредит не найден")
+	}
+	return loan, nil
+}
+
+func (s *FinanceService) GetLoanPayments(id int) ([]repository.LoanPayment, error) {
+	return s.repo.GetLoanPayments(s.userID, id)
+}
+
+func (s *FinanceService) DeleteLoan(id int) error {
+	if id <= 0 {
+		return fmt.Errorf("неверный ID кредита")
+	}
+	return s.repo.DeleteLoan(s.userID, id)
+}
+
+func (s *FinanceService) GetLoanState(loan *repository.Loan) (*LoanState, error) {
+	calc, state, err := s.replayLoan(loan)
+	if err != nil {
+		return nil, err
+	}
+	calc.fill(state)
+	return state, nil
+}
+
+func (s *FinanceService) GetLoanSchedule(loan *repository.Loan) ([]LoanScheduleRow, error) {
+	calc, _, err := s.replayLoan(loan)
+	if err != nil {
+		return nil, err
+	}
+	return calc.schedule(), nil
+}
+
+func (s *FinanceService) PayLoanInstallment(id int) (*repository.LoanPayment, *LoanState, error) {
+	loan, err := s.GetLoanByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if loan.ClosedAt != nil {
+		return nil, nil, fmt.Errorf("кредит уже погашен")
+	}
+
+	calc, _, err := s.replayLoan(loan)
+	if err != nil {
+		return nil, nil, err
+	}
+	if calc.balance < 0.005 {
+		return nil, nil, fmt.Errorf("кредит уже погашен")
+	}
+
+	principal, interest := calc.next()
+	payment := repository.LoanPayment{
+		LoanID:    id,
+		Kind:      repository.LoanPaymentScheduled,
+		Principal: principal,
+		Interest:  interest,
+		Date:      time.Now(),
+	}
+
+	if payment.PrincipalTransactionID, err = s.addLoanTransaction(loan, loanPrincipalCategory, principal, true); err != nil {
+		return nil, nil, err
+	}
+	if payment.TransactionID, err = s.addLoanTransaction(loan, loanInterestCategory, interest, false); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.repo.AddLoanPayment(s.userID, payment); err != nil {
+		return nil, nil, fmt.Errorf("не удалось сохранить платёж: %v", err)
+	}
+
+	state, err := s.afterLoanPayment(loan)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &payment, state, nil
+}
+
+func (s *FinanceService) EarlyRepayLoan(id int, amount float64, mode string) (*LoanState, error) {
+	loan, err := s.GetLoanByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if loan.ClosedAt != nil {
+		return nil, fmt.Errorf("кредит уже погашен")
+	}
+	if mode != repository.EarlyReduceTerm && mode != repository.EarlyReducePayment {
+		return nil, fmt.Errorf("неизвестный режим досрочного погашения: %s", mode)
+	}
+
+	calc, _, err := s.replayLoan(loan)
+	if err != nil {
+		return nil, err
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("сумма должна быть положительной")
+	}
+	if amount > calc.balance+0.005 {
+		return nil, fmt.Errorf("сумма больше остатка долга (%.2f)", calc.balance)
+	}
+
+	payment := repository.LoanPayment{
+		LoanID:    id,
+		Kind:      repository.LoanPaymentEarly,
+		EarlyMode: mode,
+		Principal: math.Min(amount, calc.balance),
+		Date:      time.Now(),
+	}
+	if payment.PrincipalTransactionID, err = s.addLoanTransaction(loan, loanPrincipalCategory, payment.Principal, true); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.AddLoanPayment(s.userID, payment); err != nil {
+		return nil, fmt.Errorf("не удалось сохранить платёж: %v", err)
+	}
+
+	return s.afterLoanPayment(loan)
+}
+
+func (s *FinanceService) addLoanTransaction(loan *repository.Loan, category string, amount float64, transfer bool) (*int, error) {
+	if amount <= 0 {
+		return nil, nil
+	}
+
+	categoryID, err := s.findOrCreateCategory(category, "expense")
+	if err != nil {
+		return nil, err
+	}
+	transactionID, err := s.addTransaction(repository.Transaction{
+		Amount:        -amount,
+		CategoryID:    categoryID,
+		Date:          time.Now(),
+		PaymentMethod: repository.PaymentMethodCard,
+		Comment:       fmt.Sprintf("Кредит: %s", loan.Name),
+		Transfer:      transfer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &transactionID, nil
+}
+
+func (s *FinanceService) PreviewEarlyRepayment(loan *repository.Loan, amount float64, mode string) (*LoanState, error) {
+	calc, state, err := s.replayLoan(loan)
+	if err != nil {
+		return nil, err
+	}
+	if amount <= 0 || amount > calc.balance+0.005 {
+		return nil, fmt.Errorf("сумма должна быть от 0 до %.2f", calc.balance)
+	}
+
+	calc.apply(repository.LoanPayment{Kind: repository.LoanPaymentEarly, EarlyMode: mode, Principal: amount}, state)
+	calc.fill(state)
+	return state, nil
+}
+
+func (s *FinanceService) afterLoanPayment(loan *repository.Loan) (*LoanState, error) {
+	state, err := s.GetLoanState(loan)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.Closed {
+		if err := s.repo.CloseLoan(s.userID, loan.ID, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+func (s *FinanceService) replayLoan(loan *repository.Loan) (*loanCalc, *LoanState, error) {
+	payments, err := s.repo.GetLoanPayments(s.userID, loan.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	calc := newLoanCalc(loan)
+	state := &LoanState{}
+	for _, p := range payments {
+		calc.apply(p, state)
+	}
+	return calc, state, nil
+}
+
+func newLoanCalc(loan *repository.Loan) *loanCalc {
+	c := &loanCalc{
+		loan:    loan,
+		balance: loan.Principal,
+		rate:    loan.Rate / 12 / 100,
+		months:  loan.TermMonths,
+	}
+	c.payment = annuityPayment(c.balance, c.rate, c.months)
+	c.part = c.balance / float64(c.months)
+	return c
+}
+
+func (c *loanCalc) apply(p repository.LoanPayment, state *LoanState) {
+	c.balance = roundMoney(c.balance - p.Principal)
+	state.PaidPrincipal += p.Principal
+	state.PaidInterest += p.Interest
+
+	if p.Kind == repository.LoanPaymentScheduled {
+		c.months--
+		c.made++
+		state.PaymentsMade++
+		return
+	}
+
+	if c.balance < 0.005 || c.months <= 0 {
+		return
+	}
+
+	switch {
+	case p.EarlyMode == repository.EarlyReducePayment && c.loan.ScheduleType == repository.LoanAnnuity:
+		c.payment = annuityPayment(c.balance, c.rate, c.months)
+	case p.EarlyMode == repository.EarlyReducePayment:
+		c.part = c.balance / float64(c.months)
+	case c.loan.ScheduleType == repository.LoanAnnuity:
+		c.months = annuityTerm(c.balance, c.rate, c.payment)
+	default:
+		c.months = int(math.Ceil(c.balance/c.part - 1e-9))
+	}
+}
+
+func (c *loanCalc) next() (float64, float64) {
+	interest := roundMoney(c.balance * c.rate)
+	var principal float64
+	if c.loan.ScheduleType == repository.LoanAnnuity {
+		principal = roundMoney(c.payment - interest)
+	} else {
+		principal = roundMoney(c.part)
+	}
+
+	if c.months <= 1 || principal > c.balance {
+		principal = c.balance
+	}
+	return principal, interest
+}
+
+func (c *loanCalc) fill(state *LoanState) {
+	state.Balance = c.balance
+	state.Closed = c.balance < 0.005
+	if state.Closed {
+		state.MonthsLeft = 0
+		return
+	}
+
+	rows := c.schedule()
+	state.MonthsLeft = len(rows)
+	if len(rows) > 0 {
+		state.NextDate = rows[0].Date
+		state.Payment = rows[0].Payment
+		state.NextPrincipal = rows[0].Principal
+		state.NextInterest = rows[0].Interest
+		state.EndDate = rows[len(rows)-1].Date
+	}
+	for _, r := range rows {
+		state.RemainingInterest += r.Interest
+	}
+	state.RemainingInterest = roundMoney(state.RemainingInterest)
+}
+
+func (c *loanCalc) schedule() []LoanScheduleRow {
+	sim := *c
+	var rows []LoanScheduleRow
+	for sim.balance >= 0.005 && len(rows) < maxLoanTermMonths {
+		principal, interest := sim.next()
+		sim.balance = roundMoney(sim.balance - principal)
+		sim.months--
+		sim.made++
+		rows = append(rows, LoanScheduleRow{
+			Number:    sim.made,
+			Date:      c.loan.IssueDate.AddDate(0, sim.made, 0),
+			Payment:   roundMoney(principal + interest),
+			Principal: principal,
+			Interest:  interest,
+			Balance:   sim.balance,
+		})
+	}
+	return rows
+}
+
+func annuityPayment(balance, rate float64, months int) float64 {
+	if months <= 0 {
+		return balance
+	}
+	if rate == 0 {
+		return roundMoney(balance / float64(months))
+	}
+	return roundMoney(balance * rate / (1 - math.Pow(1+rate, -float64(months))))
+}
+
+func annuityTerm(balance, rate, payment float64) int {
+	if rate == 0 {
+		return int(math.Ceil(balance/payment - 1e-9))
+	}
+	if payment <= balance*rate {
+		return maxLoanTermMonths
+	}
+	return int(math.Ceil(-math.Log(1-balance*rate/payment)/math.Log(1+rate) - 1e-9))
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
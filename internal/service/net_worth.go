@@ -0,0 +1,203 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+)
+
+const (
+	NetWorthAccount    = "account"
+	NetWorthSaving     = "saving"
+	NetWorthDebt       = "debt"
+	NetWorthLoan       = "loan"
+	NetWorthCreditCard = "credit_card"
+	NetWorthAsset      = "asset"
+)
+
+type NetWorthItem struct {
+	Kind   string
+	Name   string
+	Amount float64
+}
+
+type NetWorth struct {
+	Assets           []NetWorthItem
+	Liabilities      []NetWorthItem
+	TotalAssets      float64
+	TotalLiabilities float64
+	SkippedDebts     int
+}
+
+func (n *NetWorth) Net() float64 {
+	return n.TotalAssets - n.TotalLiabilities
+}
+
+func (n *NetWorth) addAsset(kind, name string, amount float64) {
+	if amount <= 0 {
+		return
+	}
+	n.Assets = append(n.Assets, NetWorthItem{Kind: kind, Name: name, Amount: amount})
+	n.TotalAssets += amount
+}
+
+func (n *NetWorth) addLiability(kind, name string, amount float64) {
+	if amount <= 0 {
+		return
+	}
+	n.Liabilities = append(n.Liabilities, NetWorthItem{Kind: kind, Name: name, Amount: amount})
+	n.TotalLiabilities += amount
+}
+
+func (s *FinanceService) GetNetWorth(now time.Time) (*NetWorth, error) {
+	currency, err := s.GetCurrency()
+	if err != nil {
+		return nil, err
+	}
+
+	var nw NetWorth
+
+	balance, err := s.repo.GetAccountBalance(s.userID)
+	if err != nil {
+		return nil, err
+	}
+	if balance >= 0 {
+		nw.addAsset(NetWorthAccount, "Остаток на счетах", balance)
+	} else {
+		nw.addLiability(NetWorthAccount, "Перерасход по счетам", -balance)
+	}
+
+	savings, err := s.GetSavings()
+	if err != nil {
+		return nil, err
+	}
+	for _, saving := range savings {
+		nw.addAsset(NetWorthSaving, saving.Name, saving.Amount)
+	}
+
+	assets, err := s.GetAssets()
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range assets {
+		nw.addAsset(NetWorthAsset, a.Name, a.Value)
+	}
+
+	debts, err := s.GetDebts(false)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range debts {
+		if d.Currency != currency {
+			nw.SkippedDebts++
+			continue
+		}
+		if d.Direction == repository.DebtLent {
+			nw.addAsset(NetWorthDebt, d.Counterparty, d.Remaining())
+		} else {
+			nw.addLiability(NetWorthDebt, d.Counterparty, d.Remaining())
+		}
+	}
+
+	loans, err := s.GetLoans()
+	if err != nil {
+		return nil, err
+	}
+	for i := range loans {
+		state, err := s.GetLoanState(&loans[i])
+		if err != nil {
+			return nil, err
+		}
+		nw.addLiability(NetWorthLoan, loans[i].Name, state.Balance)
+	}
+
+	cards, err := s.GetCreditCards()
+	if err != nil {
+		return nil, err
+	}
+	for i := range cards {
+		status, err := s.GetCreditCardStatus(&cards[i], now)
+		if err != nil {
+			return nil, err
+		}
+		nw.addLiability(NetWorthCreditCard, cards[i].Name, status.Debt)
+	}
+
+	sort.SliceStable(nw.Assets, func(i, j int) bool { return nw.Assets[i].Amount > nw.Assets[j].Amount })
+	sort.SliceStable(nw.Liabilities, func(i, j int) bool { return nw.Liabilities[i].Amount > nw.Liabilities[j].Amount })
+	return &nw, nil
+}
+
+func (s *FinanceService) TakeNetWorthSnapshot(now time.Time) (*NetWorth, error) {
+	nw, err := s.GetNetWorth(now)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.SaveNetWorthSnapshot(repository.NetWorthSnapshot{
+		UserID:      s.userID,
+		Month:       time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()),
+		Assets:      roundMoney(nw.TotalAssets),
+		Liabilities: roundMoney(nw.TotalLiabilities),
+		TakenAt:     now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nw, nil
+}
+
+func (s *FinanceService) GetNetWorthHistory(now time.Time, months int) ([]repository.NetWorthSnapshot, error) {
+	since := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -(months - 1), 0)
+	return s.repo.GetNetWorthSnapshots(s.userID, since)
+}
+
+func (s *FinanceService) GetAssets() ([]repository.Asset, error) {
+	return s.repo.GetAssets(s.userID)
+}
+
+func (s *FinanceService) GetAssetByID(id int) (*repository.Asset, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("неверный ID имущества")
+	}
+
+	asset, err := s.repo.GetAssetByID(s.userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка базы данных: %v", err)
+	}
+	if asset == nil {
+		return nil, fmt.Errorf("имущество не найдено")
+	}
+	return asset, nil
+}
+
+func (s *FinanceService) CreateAsset(name string, value float64) (int, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("название не может быть пустым")
+	}
+	if value < 0 {
+		return 0, fmt.Errorf("стоимость не может быть отрицательной")
+	}
+	return s.repo.CreateAsset(s.userID, name, value)
+}
+
+func (s *FinanceService) UpdateAssetValue(id int, value float64) error {
+	if value < 0 {
+		return fmt.Errorf("стоимость не может быть отрицательной")
+	}
+	if _, err := s.GetAssetByID(id); err != nil {
+		return err
+	}
+	return s.repo.UpdateAssetValue(s.userID, id, value)
+}
+
+func (s *FinanceService) DeleteAsset(id int) error {
+	if id <= 0 {
+		return fmt.Errorf("неверный ID имущества")
+	}
+	return s.repo.DeleteAsset(s.userID, id)
+}
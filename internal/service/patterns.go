@@ -0,0 +1,54 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+)
+
+type SpendingPatterns struct {
+	Weekday  [7]float64
+	Hour     [24]float64
+	MonthDay [31]float64
+	Daily    map[string]float64
+	Total    float64
+}
+
+func BuildSpendingPatterns(sums []repository.PeriodSum, loc *time.Location) *SpendingPatterns {
+	p := &SpendingPatterns{Daily: make(map[string]float64)}
+	for _, h := range sums {
+		if h.Expense == 0 {
+			continue
+		}
+		date, err := time.ParseInLocation("2006-01-02 15", h.Period, loc)
+		if err != nil {
+			continue
+		}
+
+		p.Weekday[(int(date.Weekday())+6)%7] += h.Expense
+		p.Hour[date.Hour()] += h.Expense
+		p.MonthDay[date.Day()-1] += h.Expense
+		p.Daily[date.Format("2006-01-02")] += h.Expense
+		p.Total += h.Expense
+	}
+	return p
+}
+
+func (s *FinanceService) GetSpendingPatterns(start, end time.Time) (*SpendingPatterns, error) {
+	sums, err := s.repo.GetHourlySums(s.userID, s.scopeCreator(), start, end)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить суммы по часам: %v", err)
+	}
+	return BuildSpendingPatterns(sums, s.location), nil
+}
+
+func PeakIndex(values []float64) int {
+	peak := 0
+	for i, v := range values {
+		if v > values[peak] {
+			peak = i
+		}
+	}
+	return peak
+}
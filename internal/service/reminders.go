@@ -0,0 +1,38 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+)
+
+var ReminderHours = []int{9, 12, 16, 19, 20, 21}
+
+func (s *FinanceService) SetTimezone(name string) error {
+	name = strings.TrimSpace(name)
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "" || name == "Local" {
+		return fmt.Errorf("неизвестный часовой пояс «%s»", name)
+	}
+	if err := s.repo.UpdateUserTimezone(s.actorID, loc.String()); err != nil {
+		return fmt.Errorf("не удалось сохранить часовой пояс: %v", err)
+	}
+	s.location = loc
+	return nil
+}
+
+func (s *FinanceService) SetReminderTime(minutes int) error {
+	if minutes < 0 || minutes >= 24*60 {
+		return fmt.Errorf("время напоминания должно быть от 00:00 до 23:59")
+	}
+	return s.repo.UpdateUserReminderTime(s.actorID, minutes)
+}
+
+func (s *FinanceService) SetReminderDays(days int) error {
+	if days < 0 || days > repository.AllWeekdays {
+		return fmt.Errorf("неверные дни напоминаний")
+	}
+	return s.repo.UpdateUserReminderDays(s.actorID, days)
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/logger"
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+)
+
+const defaultRoundUpStep = 100
+
+func (s *FinanceService) CreateSavingRule(savingID int, ruleType string, value float64, intervalDays int) error {
+	if _, err := s.GetSavingByID(savingID); err != nil {
+		return err
+	}
+
+	rule := repository.SavingRule{
+		SavingID: savingID,
+		Type:     ruleType,
+		Value:    value,
+	}
+
+	switch ruleType {
+	case repository.SavingRulePercentIncome:
+		if value <= 0 || value > 100 {
+			return fmt.Errorf("процент должен быть от 0 до 100")
+		}
+	case repository.SavingRuleRoundUp:
+		if value <= 0 {
+			rule.Value = defaultRoundUpStep
+		}
+	case repository.SavingRuleSchedule:
+		if value <= 0 {
+			return fmt.Errorf("сумма должна быть положительной")
+		}
+		if intervalDays <= 0 {
+			return fmt.Errorf("интервал должен быть не меньше одного дня")
+		}
+		rule.IntervalDays = intervalDays
+		nextRun := time.Now().AddDate(0, 0, intervalDays)
+		rule.NextRun = &nextRun
+	default:
+		return fmt.Errorf("неизвестный тип правила: %s", ruleType)
+	}
+
+	_, err := s.repo.CreateSavingRule(s.userID, rule)
+	return err
+}
+
+func (s *FinanceService) GetSavingRules(savingID int) ([]repository.SavingRule, error) {
+	return s.repo.GetSavingRulesBySaving(s.userID, savingID)
+}
+
+func (s *FinanceService) DeleteSavingRule(id int) error {
+	if id <= 0 {
+		return fmt.Errorf("неверный ID правила")
+	}
+	return s.repo.DeleteSavingRule(s.userID, id)
+}
+
+func (s *FinanceService) GetSavingAutoMoves(savingID, limit int) ([]repository.SavingAutoMove, error) {
+	return s.repo.GetSavingAutoMoves(s.userID, savingID, limit)
+}
+
+func (s *FinanceService) GetAutoMovesForTransaction(transactionID int) ([]repository.SavingAutoMove, error) {
+	return s.repo.GetSavingAutoMovesByTransaction(s.userID, transactionID)
+}
+
+func (s *FinanceService) ApplySavingRules(transactionID int, amount float64) {
+	rules, err := s.repo.GetSavingRules(s.userID)
+	if err != nil {
+		logger.Error("Failed to load saving rules", "user_id", s.userID, "error", err)
+		return
+	}
+
+	for _, rule := range rules {
+		var move float64
+		switch {
+		case rule.Type == repository.SavingRulePercentIncome && amount > 0:
+			move = math.Round(amount*rule.Value) / 100
+		case rule.Type == repository.SavingRuleRoundUp && amount < 0:
+			move = roundUpDifference(-amount, rule.Value)
+		}
+
+		if move <= 0 {
+			continue
+		}
+
+		if _, err := s.moveToSaving(rule, move, &transactionID, time.Now()); err != nil {
+			logger.Error("Failed to apply saving rule", "user_id", s.userID, "rule_id", rule.ID, "error", err)
+		}
+	}
+}
+
+func (s *FinanceService) RunScheduledSavingRules(now time.Time) ([]repository.SavingAutoMove, error) {
+	rules, err := s.repo.GetDueSavingRules(s.userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var moves []repository.SavingAutoMove
+	for _, rule := range rules {
+		move, err := s.moveToSaving(rule, rule.Value, nil, now)
+		if err != nil {
+			logger.Error("Failed to run scheduled saving rule", "user_id", s.userID, "rule_id", rule.ID, "error", err)
+			continue
+		}
+		moves = append(moves, *move)
+
+		next := *rule.NextRun
+		for !next.After(now) {
+			next = next.AddDate(0, 0, rule.IntervalDays)
+		}
+		if err := s.repo.UpdateSavingRuleNextRun(s.userID, rule.ID, next); err != nil {
+			return moves, err
+		}
+	}
+	return moves, nil
+}
+
+func (s *FinanceService) moveToSaving(rule repository.SavingRule, amount float64, transactionID *int, now time.Time) (*repository.SavingAutoMove, error) {
+	saving, err := s.GetSavingByID(rule.SavingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.updateSavingAmount(saving.ID, saving.Amount+amount, repository.ContributionSourceAuto); err != nil {
+		return nil, err
+	}
+
+	move := repository.SavingAutoMove{
+		RuleID:        rule.ID,
+		SavingID:      saving.ID,
+		TransactionID: transactionID,
+		Amount:        amount,
+		CreatedAt:     now,
+		RuleType:      rule.Type,
+		SavingName:    saving.Name,
+	}
+	move.ID, err = s.repo.AddSavingAutoMove(s.userID, move)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Automatic saving move",
+		"user_id", s.userID,
+		"rule_id", rule.ID,
+		"saving_id", saving.ID,
+		"amount", amount)
+
+	return &move, nil
+}
+
+func (s *FinanceService) UndoAutoMove(id int) (*repository.SavingAutoMove, error) {
+	move, err := s.repo.GetSavingAutoMoveByID(s.userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if move == nil {
+		return nil, fmt.Errorf("автопополнение не найдено")
+	}
+	if move.Undone {
+		return nil, fmt.Errorf("автопополнение уже отменено")
+	}
+
+	saving, err := s.GetSavingByID(move.SavingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.updateSavingAmount(saving.ID, math.Max(saving.Amount-move.Amount, 0), repository.ContributionSourceAuto); err != nil {
+		return nil, err
+	}
+	if err := s.repo.MarkSavingAutoMoveUndone(s.userID, id); err != nil {
+		return nil, err
+	}
+
+	move.Undone = true
+	return move, nil
+}
+
+func roundUpDifference(amount, step float64) float64 {
+	if step <= 0 {
+		step = defaultRoundUpStep
+	}
+	rounded := math.Ceil(amount/step) * step
+	return math.Round((rounded-amount)*100) / 100
+}
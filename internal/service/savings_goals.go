@@ -0,0 +1,146 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+)
+
+const (
+	ContributionWeekly  = "week"
+	ContributionMonthly = "month"
+)
+
+type SavingPlan struct {
+	Remaining         float64
+	Required          float64
+	PeriodsLeft       int
+	Period            string
+	PeriodStart       time.Time
+	PeriodEnd         time.Time
+	ContributedPeriod float64
+	AvgPerPeriod      float64
+	ProjectedDate     *time.Time
+	OnTrack           bool
+	Reached           bool
+	Overdue           bool
+}
+
+func (s *FinanceService) SetSavingTargetDate(id int, targetDate *time.Time) error {
+	if id <= 0 {
+		return fmt.Errorf("неверный ID копилки")
+	}
+	if targetDate != nil && !targetDate.After(time.Now()) {
+		return fmt.Errorf("дата цели должна быть в будущем")
+	}
+	return s.repo.UpdateSavingTargetDate(s.userID, id, targetDate)
+}
+
+func (s *FinanceService) SetSavingContributionPeriod(id int, period string) error {
+	if period != ContributionWeekly && period != ContributionMonthly {
+		return fmt.Errorf("неизвестный период пополнения: %s", period)
+	}
+	return s.repo.UpdateSavingContributionPeriod(s.userID, id, period)
+}
+
+func (s *FinanceService) MarkSavingReminded(id int, at time.Time) error {
+	return s.repo.MarkSavingReminded(s.userID, id, at)
+}
+
+func (s *FinanceService) GetSavingPlan(saving *repository.Saving, now time.Time) (*SavingPlan, error) {
+	if saving.Goal == nil || saving.TargetDate == nil {
+		return nil, nil
+	}
+
+	period := saving.ContributionPeriod
+	if period != ContributionWeekly {
+		period = ContributionMonthly
+	}
+
+	plan := &SavingPlan{
+		Period:    period,
+		Remaining: math.Max(*saving.Goal-saving.Amount, 0),
+	}
+	plan.PeriodStart, plan.PeriodEnd = contributionPeriodBounds(now, period)
+
+	if plan.Remaining == 0 {
+		plan.Reached = true
+		plan.OnTrack = true
+		return plan, nil
+	}
+
+	target := *saving.TargetDate
+	if !target.After(now) {
+		plan.Overdue = true
+		plan.Required = plan.Remaining
+		return plan, nil
+	}
+
+	plan.PeriodsLeft = countPeriods(plan.PeriodStart, target, period)
+	plan.Required = plan.Remaining / float64(plan.PeriodsLeft)
+
+	historyStart := now.AddDate(0, -6, 0)
+	contributions, err := s.repo.GetSavingContributions(s.userID, saving.ID, historyStart)
+	if err != nil {
+		return nil, err
+	}
+
+	var total float64
+	first := now
+	for _, c := range contributions {
+		total += c.Amount
+		if c.Date.Before(first) {
+			first = c.Date
+		}
+		if !c.Date.Before(plan.PeriodStart) {
+			plan.ContributedPeriod += c.Amount
+		}
+	}
+
+	days := math.Max(now.Sub(first).Hours()/24, 7)
+	perDay := total / days
+	plan.AvgPerPeriod = perDay * periodDays(period)
+
+	if perDay > 0 {
+		projected := now.Add(time.Duration(plan.Remaining / perDay * 24 * float64(time.Hour)))
+		plan.ProjectedDate = &projected
+		plan.OnTrack = !projected.After(target)
+	}
+
+	return plan, nil
+}
+
+func contributionPeriodBounds(now time.Time, period string) (time.Time, time.Time) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if period == ContributionWeekly {
+		offset := (int(day.Weekday()) + 6) % 7
+		start := day.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 7)
+	}
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	return start, start.AddDate(0, 1, 0)
+}
+
+func countPeriods(from, to time.Time, period string) int {
+	count := 0
+	for cursor := from; cursor.Before(to); count++ {
+		if period == ContributionWeekly {
+			cursor = cursor.AddDate(0, 0, 7)
+		} else {
+			cursor = cursor.AddDate(0, 1, 0)
+		}
+	}
+	if count < 1 {
+		count = 1
+	}
+	return count
+}
+
+func periodDays(period string) float64 {
+	if period == ContributionWeekly {
+		return 7
+	}
+	return 30.4
+}
@@ -8,18 +8,57 @@ import (
 )
 
 type FinanceService struct {
-	repo   *repository.SQLiteRepository
-	userID int
+	repo      *repository.SQLiteRepository
+	userID    int
+	actorID   int
+	household *repository.Household
+	member    *repository.HouseholdMember
+	location  *time.Location
 }
 
 func NewService(repo *repository.SQLiteRepository, user *repository.User) *FinanceService {
-	return &FinanceService{
-		repo:   repo,
-		userID: user.ID,
+	s := &FinanceService{
+		repo:     repo,
+		userID:   user.ID,
+		actorID:  user.ID,
+		location: user.Location(),
 	}
+
+	if user.ID > 0 {
+		if h, m, err := repo.GetUserHousehold(user.ID); err == nil && h != nil {
+			s.userID = h.OwnerUserID
+			s.household = h
+			s.member = m
+		}
+	}
+
+	return s
+}
+
+func (s *FinanceService) Location() *time.Location {
+	return s.location
+}
+
+func (s *FinanceService) Now() time.Time {
+	return time.Now().In(s.location)
+}
+
+func (s *FinanceService) CategoryHasTransactions(id int) (bool, error) {
+	return s.repo.CategoryHasTransactions(s.userID, id)
 }
 
 func (s *FinanceService) DeleteCategory(id int) error {
+	if s.IsHouseholdGuest() {
+		return fmt.Errorf("удалять категории общего бюджета может только владелец семьи")
+	}
+
+	children, err := s.repo.CountSubcategories(s.userID, id)
+	if err != nil {
+		return err
+	}
+	if children > 0 {
+		return fmt.Errorf("у категории есть подкатегории, сначала удалите или переместите их")
+	}
 	return s.repo.DeleteCategory(s.userID, id)
 }
 
@@ -70,13 +109,38 @@ func (s *FinanceService) GetCategoryWithTypeCheck(id int, expectedType string) (
 }
 
 func (s *FinanceService) AddTransaction(amount float64, categoryID int, method, comment string) (int, error) {
-	cat, err := s.GetCategoryByID(categoryID)
+	return s.addTransaction(repository.Transaction{
+		Amount:        amount,
+		CategoryID:    categoryID,
+		Date:          time.Now(),
+		PaymentMethod: method,
+		Comment:       comment,
+	})
+}
+
+func (s *FinanceService) AddCreditCardTransaction(amount float64, categoryID, cardID int, comment string) (int, error) {
+	if _, err := s.GetCreditCardByID(cardID); err != nil {
+		return 0, err
+	}
+
+	return s.addTransaction(repository.Transaction{
+		Amount:        amount,
+		CategoryID:    categoryID,
+		Date:          time.Now(),
+		PaymentMethod: repository.PaymentMethodCard,
+		Comment:       comment,
+		CreditCardID:  &cardID,
+	})
+}
+
+func (s *FinanceService) addTransaction(t repository.Transaction) (int, error) {
+	cat, err := s.GetCategoryByID(t.CategoryID)
 	if err != nil {
 		return 0, fmt.Errorf("ошибка категории: %v", err)
 	}
 
 	expectedType := "income"
-	if amount < 0 {
+	if t.Amount < 0 {
 		expectedType = "expense"
 	}
 
@@ -84,13 +148,8 @@ func (s *FinanceService) AddTransaction(amount float64, categoryID int, method,
 		return 0, fmt.Errorf("несоответствие типа: категория %s, операция %s", cat.Type, expectedType)
 	}
 
-	return s.repo.AddTransaction(s.userID, repository.Transaction{
-		Amount:        amount,
-		CategoryID:    categoryID,
-		Date:          time.Now(),
-		PaymentMethod: method,
-		Comment:       comment,
-	})
+	t.CreatedBy = s.actorID
+	return s.repo.AddTransaction(s.userID, t)
 }
 
 func (s *FinanceService) GetTransactionsForPeriod(start, end time.Time) ([]repository.Transaction, error) {
@@ -99,10 +158,18 @@ func (s *FinanceService) GetTransactionsForPeriod(start, end time.Time) ([]repos
 		return nil, fmt.Errorf("не удалось получить транзакции: %v", err)
 	}
 
+	if s.PersonalScope() {
+		personal := transactions[:0]
+		for _, t := range transactions {
+			if t.CreatedBy == s.actorID {
+				personal = append(personal, t)
+			}
+		}
+		transactions = personal
+	}
+
 	for i := range transactions {
-		if cat, err := s.repo.GetCategoryByID(s.userID, transactions[i].CategoryID); err == nil {
-			transactions[i].CategoryName = cat.Name
-		} else {
+		if transactions[i].CategoryName == "" {
 			transactions[i].CategoryName = "Неизвестно"
 		}
 	}
@@ -132,28 +199,48 @@ func (s *FinanceService) GetSavingByID(id int) (*repository.Saving, error) {
 }
 
 func (s *FinanceService) UpdateSavingAmount(id int, amount float64) error {
+	return s.updateSavingAmount(id, amount, repository.ContributionSourceManual)
+}
+
+func (s *FinanceService) updateSavingAmount(id int, amount float64, source string) error {
 	if id <= 0 {
 		return fmt.Errorf("неверный ID копилки")
 	}
 	if amount < 0 {
 		return fmt.Errorf("сумма не может быть отрицательной")
 	}
-	return s.repo.UpdateSavingAmount(s.userID, id, amount)
+
+	saving, err := s.GetSavingByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateSavingAmount(s.userID, id, amount); err != nil {
+		return err
+	}
+
+	if delta := amount - saving.Amount; delta != 0 {
+		return s.repo.AddSavingContribution(s.userID, id, delta, source, time.Now())
+	}
+	return nil
 }
 
-func (s *FinanceService) CreateSaving(name string, goal *float64) error {
-	return s.repo.CreateSaving(s.userID, name, goal)
+func (s *FinanceService) CreateSaving(name string, goal *float64, targetDate *time.Time) error {
+	return s.repo.CreateSaving(s.userID, name, goal, targetDate)
 }
 
 func (s *FinanceService) SetNotificationsEnabled(enabled bool) error {
-	return s.repo.UpdateUserNotifications(s.userID, enabled)
+	return s.repo.UpdateUserNotifications(s.actorID, enabled)
 }
 
 func (s *FinanceService) GetNotificationsEnabled() (bool, error) {
-	return s.repo.GetUserNotificationsEnabled(s.userID)
+	return s.repo.GetUserNotificationsEnabled(s.actorID)
 }
 
 func (s *FinanceService) ClearUserData() error {
+	if s.IsHouseholdGuest() {
+		return fmt.Errorf("очистить общий бюджет может только владелец семьи")
+	}
 	return s.repo.ClearUserData(s.userID)
 }
 
@@ -199,15 +286,19 @@ func (s *FinanceService) GetLatestVersion() (*repository.Version, error) {
 }
 
 func (s *FinanceService) MarkVersionAsRead(versionID int) error {
-	return s.repo.MarkVersionAsRead(s.userID, versionID)
+	return s.repo.MarkVersionAsRead(s.actorID, versionID)
 }
 
 func (s *FinanceService) HasUserReadVersion(versionID int) (bool, error) {
-	return s.repo.HasUserReadVersion(s.userID, versionID)
+	return s.repo.HasUserReadVersion(s.actorID, versionID)
+}
+
+func (s *FinanceService) GetCurrency() (string, error) {
+	return s.repo.GetUserCurrency(s.userID)
 }
 
 func (s *FinanceService) SetPeriodStartDay(day int) error {
-	return s.repo.UpdateUserPeriodStartDay(s.userID, day)
+	return s.repo.UpdateUserPeriodStartDay(s.actorID, day)
 }
 
 func (s *FinanceService) UpdateSavingGoal(savingID int, goal *float64) error {
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/IlyaMakar/finance_bot/internal/repository"
+)
+
+type CategoryTotal struct {
+	Name   string
+	Amount float64
+	Share  float64
+}
+
+type YearReview struct {
+	Start            time.Time
+	End              time.Time
+	Income           float64
+	Expense          float64
+	Saved            float64
+	Months           []MonthlyTotals
+	BestMonth        *MonthlyTotals
+	WorstMonth       *MonthlyTotals
+	TopCategories    []CategoryTotal
+	BiggestPurchases []repository.Transaction
+	NoSpendStreak    int
+	StreakStart      time.Time
+	GoalsReached     []repository.Saving
+	Trends           []CategoryTrend
+}
+
+func (r *YearReview) Balance() float64 {
+	return r.Income - r.Expense
+}
+
+func (s *FinanceService) BuildYearReview(start, end, now time.Time, limit int) (*YearReview, error) {
+	review := &YearReview{Start: start, End: end}
+	for m := start; m.Before(end); m = m.AddDate(0, 1, 0) {
+		review.Months = append(review.Months, MonthlyTotals{Month: m})
+	}
+
+	monthly, err := s.categorySumsByMonth(review.Months, end)
+	if err != nil {
+		return nil, err
+	}
+
+	categories := make(map[string]float64)
+	trends := make(map[string][]float64)
+	for i, sums := range monthly {
+		for _, c := range sums {
+			review.Months[i].Income += c.Income
+			review.Months[i].Expense += c.Expense
+			if c.Expense == 0 {
+				continue
+			}
+			name := RootSumName(c)
+			categories[name] += c.Expense
+			if _, ok := trends[name]; !ok {
+				trends[name] = make([]float64, len(review.Months))
+			}
+			trends[name][i] += c.Expense
+		}
+		review.Income += review.Months[i].Income
+		review.Expense += review.Months[i].Expense
+	}
+
+	for i := range review.Months {
+		m := &review.Months[i]
+		if m.Month.After(now) || (m.Income == 0 && m.Expense == 0) {
+			continue
+		}
+		if review.BestMonth == nil || m.Income-m.Expense > review.BestMonth.Income-review.BestMonth.Expense {
+			review.BestMonth = m
+		}
+		if review.WorstMonth == nil || m.Income-m.Expense < review.WorstMonth.Income-review.WorstMonth.Expense {
+			review.WorstMonth = m
+		}
+	}
+
+	for _, name := range sortedByAmount(categories) {
+		if len(review.TopCategories) == limit {
+			break
+		}
+		review.TopCategories = append(review.TopCategories, CategoryTotal{
+			Name:   name,
+			Amount: categories[name],
+			Share:  categories[name] / review.Expense * 100,
+		})
+	}
+
+	purchases, err := s.GetExpenses(start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+	review.BiggestPurchases = purchases
+
+	for _, c := range review.TopCategories {
+		review.Trends = append(review.Trends, CategoryTrend{Name: c.Name, Values: trends[c.Name]})
+	}
+
+	last := end
+	if today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, start.Location()).AddDate(0, 0, 1); today.Before(last) {
+		last = today
+	}
+	daily, err := s.GetDailySums(start, last)
+	if err != nil {
+		return nil, err
+	}
+	spendDays := make(map[string]bool, len(daily))
+	for _, d := range daily {
+		if d.Expense > 0 {
+			spendDays[d.Period] = true
+		}
+	}
+
+	streak := 0
+	for day := start; day.Before(last); day = day.AddDate(0, 0, 1) {
+		if spendDays[day.Format("2006-01-02")] {
+			streak = 0
+			continue
+		}
+		streak++
+		if streak > review.NoSpendStreak {
+			review.NoSpendStreak = streak
+			review.StreakStart = day.AddDate(0, 0, 1-streak)
+		}
+	}
+
+	if err := s.fillYearSavings(review); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (s *FinanceService) categorySumsByMonth(months []MonthlyTotals, end time.Time) ([][]repository.CategorySum, error) {
+	res := make([][]repository.CategorySum, len(months))
+	if len(months) == 0 {
+		return res, nil
+	}
+
+	start := months[0].Month
+	if !start.Equal(time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location())) {
+		for i, m := range months {
+			sums, err := s.GetCategorySums(m.Month, m.Month.AddDate(0, 1, 0))
+			if err != nil {
+				return nil, err
+			}
+			res[i] = sums
+		}
+		return res, nil
+	}
+
+	sums, err := s.repo.GetMonthlyCategorySums(s.userID, s.scopeCreator(), start, end)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить суммы по категориям: %v", err)
+	}
+	index := make(map[string]int, len(months))
+	for i, m := range months {
+		index[m.Month.Format("2006-01")] = i
+	}
+	for _, m := range sums {
+		i, ok := index[m.Month]
+		if !ok {
+			continue
+		}
+		if m.CategoryName == "" {
+			m.CategoryName = "Неизвестно"
+		}
+		res[i] = append(res[i], m.CategorySum)
+	}
+	return res, nil
+}
+
+func (s *FinanceService) fillYearSavings(review *YearReview) error {
+	savings, err := s.GetSavings()
+	if err != nil {
+		return err
+	}
+
+	for _, saving := range savings {
+		contributions, err := s.repo.GetSavingContributions(s.userID, saving.ID, review.Start)
+		if err != nil {
+			return err
+		}
+
+		var inYear, afterYear float64
+		for _, c := range contributions {
+			if c.Date.Before(review.End) {
+				inYear += c.Amount
+			} else {
+				afterYear += c.Amount
+			}
+		}
+		review.Saved += inYear
+
+		if saving.Goal == nil || *saving.Goal <= 0 {
+			continue
+		}
+		atEnd := saving.Amount - afterYear
+		atStart := atEnd - inYear
+		if atEnd >= *saving.Goal && atStart < *saving.Goal {
+			review.GoalsReached = append(review.GoalsReached, saving)
+		}
+	}
+	return nil
+}
+
+func sortedByAmount(amounts map[string]float64) []string {
+	names := make([]string, 0, len(amounts))
+	for name := range amounts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if amounts[names[i]] != amounts[names[j]] {
+			return amounts[names[i]] > amounts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}